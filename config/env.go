@@ -2,15 +2,20 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/go-playground/validator/v10"
 )
 
 type EnvConfig struct {
-	APP AppConfig `envPrefix:"APP_"`
-	DB  DBConfig  `envPrefix:"DB_"`
-	JWT JWTConfig `envPrefix:"JWT_"`
+	APP       AppConfig       `envPrefix:"APP_"`
+	DB        DBConfig        `envPrefix:"DB_"`
+	JWT       JWTConfig       `envPrefix:"JWT_"`
+	PAYMENT   PaymentConfig   `envPrefix:"PAYMENT_"`
+	INVENTORY InventoryConfig `envPrefix:"INVENTORY_"`
+	NOTIFY    NotifyConfig    `envPrefix:"NOTIFICATION_"`
+	SEARCH    SearchConfig    `envPrefix:"SEARCH_"`
 }
 
 type AppConfig struct {
@@ -32,6 +37,26 @@ type JWTConfig struct {
 	RefreshKey string `env:"REFRESH_KEY" validate:"required"`
 }
 
+type PaymentConfig struct {
+	WebhookSecret    string        `env:"WEBHOOK_SECRET" validate:"required"`
+	WebhookTolerance time.Duration `env:"WEBHOOK_TOLERANCE" envDefault:"5m"`
+}
+
+type InventoryConfig struct {
+	ReservationTTL time.Duration `env:"RESERVATION_TTL" envDefault:"15m"`
+	SweepInterval  time.Duration `env:"SWEEP_INTERVAL" envDefault:"1m"`
+}
+
+type NotifyConfig struct {
+	Sender           string        `env:"SENDER" envDefault:"log"`
+	DispatchInterval time.Duration `env:"DISPATCH_INTERVAL" envDefault:"30s"`
+}
+
+type SearchConfig struct {
+	SuggestCacheTTL  time.Duration `env:"SUGGEST_CACHE_TTL" envDefault:"1m"`
+	QueryLogInterval time.Duration `env:"QUERY_LOG_INTERVAL" envDefault:"10s"`
+}
+
 func LoadConfig() (*EnvConfig, error) {
 	cfg := new(EnvConfig)
 
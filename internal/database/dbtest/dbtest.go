@@ -0,0 +1,42 @@
+// Package dbtest lets service tests run transactions without a database.
+// Transactions begin, commit and roll back as no-ops and every statement
+// fails, the tests fake the repositories that would run them.
+package dbtest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+const driverName = "dbtest"
+
+func init() {
+	sql.Register(driverName, txDriver{})
+}
+
+// Open returns a database whose transactions do nothing, closed with the test.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+type txDriver struct{}
+
+func (txDriver) Open(string) (driver.Conn, error) { return txConn{}, nil }
+
+type txConn struct{}
+
+func (txConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("dbtest: no statements") }
+func (txConn) Close() error                        { return nil }
+func (txConn) Begin() (driver.Tx, error)           { return txConn{}, nil }
+func (txConn) Commit() error                       { return nil }
+func (txConn) Rollback() error                     { return nil }
@@ -0,0 +1,4 @@
+ALTER TABLE
+    orders DROP COLUMN cancelled_by,
+    DROP COLUMN cancel_reason,
+    DROP COLUMN cancelled_at;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE
+    orders
+ADD
+    COLUMN cancelled_by UUID REFERENCES users(id),
+ADD
+    COLUMN cancel_reason TEXT,
+ADD
+    COLUMN cancelled_at TIMESTAMP;
\ No newline at end of file
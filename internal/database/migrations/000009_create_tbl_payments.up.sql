@@ -0,0 +1,18 @@
+CREATE TYPE payment_status AS ENUM ('pending', 'captured', 'failed', 'refunded');
+
+CREATE TABLE payments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'THB',
+    status payment_status NOT NULL DEFAULT 'pending',
+    failure_reason TEXT,
+    captured_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    UNIQUE (provider, provider_ref)
+);
+
+CREATE INDEX idx_payments_order_id ON payments(order_id);
@@ -0,0 +1,9 @@
+CREATE TABLE payment_webhook_events (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    UNIQUE (provider, event_id)
+);
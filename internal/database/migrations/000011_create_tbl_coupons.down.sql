@@ -0,0 +1,10 @@
+ALTER TABLE
+    orders DROP COLUMN coupon_code,
+    DROP COLUMN discount_amount;
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupon_categories;
+DROP TABLE IF EXISTS coupon_products;
+DROP TABLE IF EXISTS coupons;
+
+DROP TYPE IF EXISTS discount_type;
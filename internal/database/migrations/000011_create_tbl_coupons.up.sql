@@ -0,0 +1,50 @@
+CREATE TYPE discount_type AS ENUM ('percentage', 'fixed');
+
+CREATE TABLE coupons (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    discount_type discount_type NOT NULL,
+    discount_value NUMERIC(12, 2) NOT NULL CHECK (discount_value > 0),
+    min_order_value NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    max_uses INT CHECK (max_uses > 0),
+    max_uses_per_user INT CHECK (max_uses_per_user > 0),
+    used_count INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    CHECK (discount_type <> 'percentage' OR discount_value <= 100)
+);
+
+CREATE TABLE coupon_products (
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, product_id)
+);
+
+CREATE TABLE coupon_categories (
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, category_id)
+);
+
+CREATE TABLE coupon_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    discount_amount NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    UNIQUE (coupon_id, order_id)
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
+
+ALTER TABLE
+    orders
+ADD
+    COLUMN coupon_code VARCHAR(50),
+ADD
+    COLUMN discount_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE order_items
+    ALTER COLUMN sub_total DROP NOT NULL,
+    ALTER COLUMN sub_total TYPE DECIMAL(10, 2);
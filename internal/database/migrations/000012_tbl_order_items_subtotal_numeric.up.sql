@@ -0,0 +1,5 @@
+UPDATE order_items SET sub_total = price * quantity WHERE sub_total IS NULL;
+
+ALTER TABLE order_items
+    ALTER COLUMN sub_total TYPE NUMERIC(12, 2),
+    ALTER COLUMN sub_total SET NOT NULL;
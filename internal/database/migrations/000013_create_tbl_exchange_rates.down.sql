@@ -0,0 +1,8 @@
+ALTER TABLE
+    orders DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE
+    products DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,20 @@
+ALTER TABLE
+    products
+ADD
+    COLUMN currency CHAR(3) NOT NULL DEFAULT 'THB';
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency)
+);
+
+ALTER TABLE
+    orders
+ADD
+    COLUMN currency CHAR(3) NOT NULL DEFAULT 'THB',
+ADD
+    COLUMN exchange_rate NUMERIC(18, 8) NOT NULL DEFAULT 1;
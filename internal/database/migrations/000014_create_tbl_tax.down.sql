@@ -0,0 +1,15 @@
+ALTER TABLE
+    orders DROP COLUMN IF EXISTS tax_total;
+
+ALTER TABLE
+    order_items DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_name;
+
+DROP TABLE IF EXISTS tax_rates;
+
+ALTER TABLE
+    categories DROP COLUMN IF EXISTS tax_class_id;
+
+DROP TABLE IF EXISTS tax_classes;
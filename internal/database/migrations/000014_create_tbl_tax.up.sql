@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS tax_classes (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+ALTER TABLE
+    categories
+ADD
+    COLUMN tax_class_id BIGINT REFERENCES tax_classes(id) ON DELETE SET NULL;
+
+-- NULL tax_class_id, state or postal_code_prefix match anything,
+-- the most specific matching rule wins.
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id BIGSERIAL PRIMARY KEY,
+    tax_class_id BIGINT REFERENCES tax_classes(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    state VARCHAR(100),
+    postal_code_prefix VARCHAR(20),
+    rate NUMERIC(7, 4) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    is_inclusive BOOLEAN NOT NULL DEFAULT false,
+    priority INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+ALTER TABLE
+    order_items
+ADD
+    COLUMN tax_name VARCHAR(100),
+ADD
+    COLUMN tax_rate NUMERIC(7, 4) NOT NULL DEFAULT 0,
+ADD
+    COLUMN tax_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+ADD
+    COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE
+    orders
+ADD
+    COLUMN tax_total NUMERIC(12, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+ALTER TABLE
+    orders DROP COLUMN IF EXISTS shipping_fee,
+    DROP COLUMN IF EXISTS shipping_method_name,
+    DROP COLUMN IF EXISTS shipping_method_id;
+
+DROP TABLE IF EXISTS shipping_methods;
+
+DROP TYPE IF EXISTS shipping_rate_type;
+
+DROP TABLE IF EXISTS shipping_zones;
+
+ALTER TABLE
+    products DROP COLUMN IF EXISTS weight_grams;
@@ -0,0 +1,43 @@
+ALTER TABLE
+    products
+ADD
+    COLUMN weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0);
+
+-- Empty states / postal_code_prefixes match any address.
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    states TEXT [] NOT NULL DEFAULT '{}',
+    postal_code_prefixes TEXT [] NOT NULL DEFAULT '{}',
+    priority INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TYPE shipping_rate_type AS ENUM ('flat', 'weight', 'free_over_threshold');
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id BIGSERIAL PRIMARY KEY,
+    zone_id BIGINT NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    rate_type shipping_rate_type NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'THB',
+    base_fee NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    per_kg_fee NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    free_threshold NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_shipping_methods_zone_id ON shipping_methods(zone_id);
+
+ALTER TABLE
+    orders
+ADD
+    COLUMN shipping_method_id BIGINT REFERENCES shipping_methods(id) ON DELETE SET NULL,
+ADD
+    COLUMN shipping_method_name VARCHAR(100),
+ADD
+    COLUMN shipping_fee NUMERIC(12, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shipment_items;
+
+DROP TABLE IF EXISTS shipments;
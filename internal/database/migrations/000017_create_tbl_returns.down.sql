@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS refunds;
+
+DROP TYPE IF EXISTS refund_method;
+
+DROP TABLE IF EXISTS return_items;
+
+DROP TABLE IF EXISTS returns;
+
+DROP TYPE IF EXISTS return_status;
+
+ALTER TABLE
+    payments DROP COLUMN IF EXISTS refunded_amount;
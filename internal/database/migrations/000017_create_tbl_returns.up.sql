@@ -0,0 +1,63 @@
+ALTER TABLE
+    payments
+ADD
+    COLUMN refunded_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+UPDATE
+    payments
+SET
+    refunded_amount = amount
+WHERE
+    status = 'refunded';
+
+CREATE TYPE return_status AS ENUM ('requested', 'approved', 'rejected', 'received');
+
+CREATE TABLE IF NOT EXISTS returns (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status return_status NOT NULL DEFAULT 'requested',
+    currency CHAR(3) NOT NULL DEFAULT 'THB',
+    refund_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    note TEXT,
+    staff_note TEXT,
+    restocked BOOLEAN NOT NULL DEFAULT FALSE,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    received_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_returns_order_id ON returns(order_id);
+CREATE INDEX idx_returns_status ON returns(status);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason TEXT NOT NULL,
+    refund_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    UNIQUE (return_id, order_item_id)
+);
+
+CREATE INDEX idx_return_items_order_item_id ON return_items(order_item_id);
+
+CREATE TYPE refund_method AS ENUM ('payment', 'manual');
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL UNIQUE REFERENCES returns(id) ON DELETE CASCADE,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    method refund_method NOT NULL,
+    provider_ref VARCHAR(255),
+    currency CHAR(3) NOT NULL DEFAULT 'THB',
+    amount NUMERIC(12, 2) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
@@ -0,0 +1,21 @@
+CREATE TYPE reservation_status AS ENUM ('active', 'converted', 'released');
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    status reservation_status NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    UNIQUE (order_id, product_id)
+);
+
+CREATE INDEX idx_stock_reservations_active ON stock_reservations(product_id, expires_at)
+WHERE
+    status = 'active';
+
+CREATE INDEX idx_stock_reservations_expires_at ON stock_reservations(expires_at)
+WHERE
+    status = 'active';
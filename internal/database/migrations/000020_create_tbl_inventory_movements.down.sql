@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS inventory_movements;
+
+DROP TYPE IF EXISTS movement_reason;
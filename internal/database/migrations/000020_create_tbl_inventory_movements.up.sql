@@ -0,0 +1,29 @@
+CREATE TYPE movement_reason AS ENUM ('initial', 'adjustment', 'order', 'cancellation', 'return');
+
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    delta INT NOT NULL,
+    balance INT NOT NULL,
+    reason movement_reason NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ref_type VARCHAR(30),
+    ref_id BIGINT,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_inventory_movements_product_id ON inventory_movements(product_id, created_at);
+CREATE INDEX idx_inventory_movements_ref ON inventory_movements(ref_type, ref_id);
+
+-- Opening balance of existing products
+INSERT INTO
+    inventory_movements (product_id, delta, balance, reason, note)
+SELECT
+    id,
+    stock,
+    stock,
+    'initial',
+    'opening balance'
+FROM
+    products;
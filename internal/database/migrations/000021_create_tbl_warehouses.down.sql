@@ -0,0 +1,13 @@
+ALTER TABLE
+    inventory_movements DROP COLUMN IF EXISTS warehouse_balance,
+    DROP COLUMN IF EXISTS warehouse_id;
+
+ALTER TABLE
+    stock_reservations DROP COLUMN IF EXISTS warehouse_id;
+
+ALTER TABLE
+    order_items DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS warehouse_stock;
+
+DROP TABLE IF EXISTS warehouses;
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(30) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    warehouse_id BIGINT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (warehouse_id, product_id)
+);
+
+CREATE INDEX idx_warehouse_stock_product_id ON warehouse_stock(product_id);
+
+-- Existing stock moves to the main warehouse, products.stock stays the total
+INSERT INTO
+    warehouses (code, name, priority)
+VALUES
+    ('MAIN', 'Main warehouse', 100);
+
+INSERT INTO
+    warehouse_stock (warehouse_id, product_id, stock)
+SELECT
+    w.id,
+    p.id,
+    GREATEST(p.stock, 0)
+FROM
+    products p,
+    warehouses w
+WHERE
+    w.code = 'MAIN';
+
+ALTER TABLE
+    order_items
+ADD
+    COLUMN warehouse_id BIGINT REFERENCES warehouses(id) ON DELETE SET NULL;
+
+ALTER TABLE
+    stock_reservations
+ADD
+    COLUMN warehouse_id BIGINT REFERENCES warehouses(id) ON DELETE SET NULL;
+
+ALTER TABLE
+    inventory_movements
+ADD
+    COLUMN warehouse_id BIGINT REFERENCES warehouses(id) ON DELETE SET NULL,
+ADD
+    COLUMN warehouse_balance INT;
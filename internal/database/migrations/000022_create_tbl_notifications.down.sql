@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS stock_subscriptions;
+
+DROP TYPE IF EXISTS subscription_status;
+
+DROP TABLE IF EXISTS notifications;
+
+DROP TYPE IF EXISTS notification_status;
+
+ALTER TABLE
+    products DROP COLUMN IF EXISTS low_stock_threshold;
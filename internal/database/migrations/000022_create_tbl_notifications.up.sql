@@ -0,0 +1,42 @@
+ALTER TABLE
+    products
+ADD
+    COLUMN low_stock_threshold INT NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0);
+
+CREATE TYPE notification_status AS ENUM ('pending', 'sent', 'failed');
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    channel VARCHAR(20) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status notification_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    ref_type VARCHAR(30),
+    ref_id BIGINT,
+    created_at TIMESTAMP DEFAULT now(),
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_pending ON notifications(created_at)
+WHERE
+    status = 'pending';
+
+CREATE TYPE subscription_status AS ENUM ('pending', 'notified');
+
+CREATE TABLE IF NOT EXISTS stock_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status subscription_status NOT NULL DEFAULT 'pending',
+    notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+-- One open subscription per customer and product
+CREATE UNIQUE INDEX uq_stock_subscriptions_pending ON stock_subscriptions(product_id, user_id)
+WHERE
+    status = 'pending';
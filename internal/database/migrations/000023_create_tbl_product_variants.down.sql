@@ -0,0 +1,66 @@
+ALTER TABLE
+    inventory_movements DROP COLUMN IF EXISTS variant_id;
+
+ALTER TABLE
+    stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_order_id_variant_id_key,
+    DROP COLUMN IF EXISTS variant_id,
+ADD
+    CONSTRAINT stock_reservations_order_id_product_id_key UNIQUE (order_id, product_id);
+
+ALTER TABLE
+    order_items DROP COLUMN IF EXISTS sku,
+    DROP COLUMN IF EXISTS variant_id;
+
+-- Variants of the same product collapse into one cart line
+DELETE FROM
+    carts a USING carts b
+WHERE
+    a.user_id = b.user_id
+    AND a.product_id = b.product_id
+    AND a.ctid > b.ctid;
+
+ALTER TABLE
+    carts DROP CONSTRAINT IF EXISTS carts_user_id_variant_id_key,
+    DROP COLUMN IF EXISTS variant_id,
+ADD
+    CONSTRAINT carts_user_id_product_id_key UNIQUE (user_id, product_id);
+
+-- Stock of the variants is merged back per product
+CREATE TEMP TABLE merged_warehouse_stock AS
+SELECT
+    warehouse_id,
+    product_id,
+    SUM(stock) AS stock,
+    MAX(updated_at) AS updated_at
+FROM
+    warehouse_stock
+GROUP BY
+    warehouse_id,
+    product_id;
+
+DELETE FROM
+    warehouse_stock;
+
+ALTER TABLE
+    warehouse_stock DROP CONSTRAINT IF EXISTS warehouse_stock_pkey,
+    DROP COLUMN IF EXISTS variant_id,
+ADD
+    PRIMARY KEY (warehouse_id, product_id);
+
+INSERT INTO
+    warehouse_stock (warehouse_id, product_id, stock, updated_at)
+SELECT
+    warehouse_id,
+    product_id,
+    stock,
+    updated_at
+FROM
+    merged_warehouse_stock;
+
+DROP TABLE IF EXISTS variant_option_values;
+
+DROP TABLE IF EXISTS product_variants;
+
+DROP TABLE IF EXISTS product_option_values;
+
+DROP TABLE IF EXISTS product_options;
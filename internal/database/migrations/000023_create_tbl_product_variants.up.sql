@@ -0,0 +1,163 @@
+CREATE TABLE IF NOT EXISTS product_options (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT now(),
+    UNIQUE (product_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS product_option_values (
+    id BIGSERIAL PRIMARY KEY,
+    option_id BIGINT NOT NULL REFERENCES product_options(id) ON DELETE CASCADE,
+    value VARCHAR(50) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    UNIQUE (option_id, value)
+);
+
+-- price overrides the product price (same currency) when set, stock is the
+-- total over warehouses
+CREATE TABLE IF NOT EXISTS product_variants (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    price NUMERIC(12, 2) CHECK (price > 0),
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    image_url TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_product_variants_product_id ON product_variants(product_id);
+
+CREATE TABLE IF NOT EXISTS variant_option_values (
+    variant_id BIGINT NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    option_value_id BIGINT NOT NULL REFERENCES product_option_values(id) ON DELETE CASCADE,
+    PRIMARY KEY (variant_id, option_value_id)
+);
+
+-- Every existing product becomes a single-variant product
+INSERT INTO
+    product_variants (product_id, sku, stock)
+SELECT
+    id,
+    'SKU-' || id,
+    GREATEST(stock, 0)
+FROM
+    products;
+
+-- Stock is held per variant
+ALTER TABLE
+    warehouse_stock
+ADD
+    COLUMN variant_id BIGINT REFERENCES product_variants(id) ON DELETE CASCADE;
+
+UPDATE
+    warehouse_stock ws
+SET
+    variant_id = v.id
+FROM
+    product_variants v
+WHERE
+    v.product_id = ws.product_id;
+
+ALTER TABLE
+    warehouse_stock
+ALTER COLUMN
+    variant_id
+SET
+    NOT NULL,
+    DROP CONSTRAINT warehouse_stock_pkey,
+ADD
+    PRIMARY KEY (warehouse_id, variant_id);
+
+-- Cart lines
+ALTER TABLE
+    carts
+ADD
+    COLUMN variant_id BIGINT REFERENCES product_variants(id) ON DELETE CASCADE;
+
+UPDATE
+    carts c
+SET
+    variant_id = v.id
+FROM
+    product_variants v
+WHERE
+    v.product_id = c.product_id;
+
+ALTER TABLE
+    carts
+ALTER COLUMN
+    variant_id
+SET
+    NOT NULL,
+    DROP CONSTRAINT carts_user_id_product_id_key,
+ADD
+    CONSTRAINT carts_user_id_variant_id_key UNIQUE (user_id, variant_id);
+
+-- Order lines keep the SKU they were sold as
+ALTER TABLE
+    order_items
+ADD
+    COLUMN variant_id BIGINT REFERENCES product_variants(id),
+ADD
+    COLUMN sku VARCHAR(64);
+
+UPDATE
+    order_items oi
+SET
+    variant_id = v.id,
+    sku = v.sku
+FROM
+    product_variants v
+WHERE
+    v.product_id = oi.product_id;
+
+ALTER TABLE
+    order_items
+ALTER COLUMN
+    variant_id
+SET
+    NOT NULL;
+
+-- Reservations
+ALTER TABLE
+    stock_reservations
+ADD
+    COLUMN variant_id BIGINT REFERENCES product_variants(id) ON DELETE CASCADE;
+
+UPDATE
+    stock_reservations sr
+SET
+    variant_id = v.id
+FROM
+    product_variants v
+WHERE
+    v.product_id = sr.product_id;
+
+ALTER TABLE
+    stock_reservations
+ALTER COLUMN
+    variant_id
+SET
+    NOT NULL,
+    DROP CONSTRAINT stock_reservations_order_id_product_id_key,
+ADD
+    CONSTRAINT stock_reservations_order_id_variant_id_key UNIQUE (order_id, variant_id);
+
+-- Ledger
+ALTER TABLE
+    inventory_movements
+ADD
+    COLUMN variant_id BIGINT REFERENCES product_variants(id) ON DELETE SET NULL;
+
+UPDATE
+    inventory_movements m
+SET
+    variant_id = v.id
+FROM
+    product_variants v
+WHERE
+    v.product_id = m.product_id;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS product_attribute_values;
+
+DROP TABLE IF EXISTS attributes;
+
+DROP TYPE IF EXISTS attribute_type;
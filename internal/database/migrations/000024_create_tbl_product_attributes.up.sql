@@ -0,0 +1,28 @@
+CREATE TYPE attribute_type AS ENUM ('text', 'number', 'boolean');
+
+CREATE TABLE IF NOT EXISTS attributes (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    type attribute_type NOT NULL,
+    unit VARCHAR(20),
+    is_filterable BOOLEAN NOT NULL DEFAULT TRUE,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+-- One typed value per product and attribute, the column matches the attribute type
+CREATE TABLE IF NOT EXISTS product_attribute_values (
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    attribute_id BIGINT NOT NULL REFERENCES attributes(id) ON DELETE CASCADE,
+    value_text VARCHAR(255),
+    value_number NUMERIC,
+    value_boolean BOOLEAN,
+    PRIMARY KEY (product_id, attribute_id),
+    CHECK (num_nonnulls(value_text, value_number, value_boolean) = 1)
+);
+
+CREATE INDEX idx_product_attribute_values_text ON product_attribute_values(attribute_id, value_text);
+
+CREATE INDEX idx_product_attribute_values_number ON product_attribute_values(attribute_id, value_number);
@@ -0,0 +1,14 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Generated, so it follows every insert and update of name and description.
+-- The simple configuration does not stem, names are in several languages
+ALTER TABLE
+    products
+ADD
+    COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(name, '')), 'A') || setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+
+CREATE INDEX idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_categories_name_trgm;
+
+DROP TABLE IF EXISTS search_queries;
@@ -0,0 +1,10 @@
+-- Normalized search queries that found products, counted for suggestions
+CREATE TABLE IF NOT EXISTS search_queries (
+    query VARCHAR(100) PRIMARY KEY,
+    count BIGINT NOT NULL DEFAULT 0,
+    last_searched_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_search_queries_query_pattern ON search_queries(query text_pattern_ops);
+
+CREATE INDEX idx_categories_name_trgm ON categories USING GIN (name gin_trgm_ops);
@@ -0,0 +1,17 @@
+-- Categories form a tree, path lists the IDs from the root down to the
+-- category itself ('/1/5/9/'), so a subtree is a path prefix
+ALTER TABLE
+    categories
+ADD
+    COLUMN parent_id BIGINT REFERENCES categories(id) ON DELETE RESTRICT,
+ADD
+    COLUMN path TEXT NOT NULL DEFAULT '';
+
+UPDATE
+    categories
+SET
+    path = '/' || id || '/';
+
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+CREATE INDEX idx_categories_path ON categories(path text_pattern_ops);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS slug_history;
+
+ALTER TABLE
+    categories DROP COLUMN IF EXISTS slug;
+
+ALTER TABLE
+    products DROP COLUMN IF EXISTS slug;
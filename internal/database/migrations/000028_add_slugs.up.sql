@@ -0,0 +1,52 @@
+-- Existing rows get a slug from their ASCII name and ID, names without
+-- ASCII letters (e.g. Thai) fall back to product-<id> / category-<id>
+ALTER TABLE
+    products
+ADD
+    COLUMN slug VARCHAR(255);
+
+UPDATE
+    products
+SET
+    slug = COALESCE(
+        NULLIF(trim(BOTH '-' FROM lower(regexp_replace(name, '[^A-Za-z0-9]+', '-', 'g'))), ''),
+        'product'
+    ) || '-' || id;
+
+ALTER TABLE
+    products
+ALTER COLUMN
+    slug SET NOT NULL,
+ADD
+    CONSTRAINT products_slug_key UNIQUE (slug);
+
+ALTER TABLE
+    categories
+ADD
+    COLUMN slug VARCHAR(255);
+
+UPDATE
+    categories
+SET
+    slug = COALESCE(
+        NULLIF(trim(BOTH '-' FROM lower(regexp_replace(name, '[^A-Za-z0-9]+', '-', 'g'))), ''),
+        'category'
+    ) || '-' || id;
+
+ALTER TABLE
+    categories
+ALTER COLUMN
+    slug SET NOT NULL,
+ADD
+    CONSTRAINT categories_slug_key UNIQUE (slug);
+
+-- Slugs replaced by a rename or an edit, so old links still resolve
+CREATE TABLE IF NOT EXISTS slug_history (
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('product', 'category')),
+    slug VARCHAR(255) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (entity_type, slug)
+);
+
+CREATE INDEX idx_slug_history_entity ON slug_history(entity_type, entity_id);
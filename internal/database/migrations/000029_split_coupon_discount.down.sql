@@ -0,0 +1,28 @@
+ALTER TABLE
+    coupons DROP CONSTRAINT IF EXISTS coupons_discount_check;
+
+ALTER TABLE
+    coupons
+ADD
+    COLUMN discount_value NUMERIC(12, 2);
+
+UPDATE
+    coupons
+SET
+    discount_value = COALESCE(discount_percent, discount_amount);
+
+ALTER TABLE
+    coupons
+ALTER COLUMN
+    discount_value
+SET
+    NOT NULL,
+ADD
+    CHECK (discount_value > 0),
+ADD
+    CHECK (discount_type <> 'percentage' OR discount_value <= 100);
+
+ALTER TABLE
+    coupons DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS discount_percent,
+    DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,36 @@
+-- Fixed discounts become an amount in the coupon currency, percentages keep
+-- their own column
+ALTER TABLE
+    coupons
+ADD
+    COLUMN currency CHAR(3) NOT NULL DEFAULT 'THB',
+ADD
+    COLUMN discount_percent NUMERIC(5, 2),
+ADD
+    COLUMN discount_amount NUMERIC(12, 2);
+
+UPDATE
+    coupons
+SET
+    discount_percent = CASE WHEN discount_type = 'percentage' THEN discount_value END,
+    discount_amount = CASE WHEN discount_type = 'fixed' THEN discount_value END;
+
+ALTER TABLE
+    coupons DROP COLUMN discount_value;
+
+ALTER TABLE
+    coupons
+ADD
+    CONSTRAINT coupons_discount_check CHECK (
+        (
+            discount_type = 'percentage'
+            AND discount_percent > 0
+            AND discount_percent <= 100
+            AND discount_amount IS NULL
+        )
+        OR (
+            discount_type = 'fixed'
+            AND discount_amount > 0
+            AND discount_percent IS NULL
+        )
+    );
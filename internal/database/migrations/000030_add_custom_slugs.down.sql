@@ -0,0 +1,5 @@
+ALTER TABLE
+    categories DROP COLUMN IF EXISTS custom_slug;
+
+ALTER TABLE
+    products DROP COLUMN IF EXISTS custom_slug;
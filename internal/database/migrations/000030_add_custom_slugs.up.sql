@@ -0,0 +1,10 @@
+-- Slugs set by hand are kept when the name changes
+ALTER TABLE
+    products
+ADD
+    COLUMN custom_slug BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE
+    categories
+ADD
+    COLUMN custom_slug BOOLEAN NOT NULL DEFAULT false;
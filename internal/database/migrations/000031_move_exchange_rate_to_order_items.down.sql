@@ -0,0 +1,20 @@
+ALTER TABLE
+    orders
+ADD
+    COLUMN exchange_rate NUMERIC(18, 8) NOT NULL DEFAULT 1;
+
+UPDATE
+    orders o
+SET
+    exchange_rate = oi.exchange_rate
+FROM
+    (
+        SELECT DISTINCT ON (order_id) order_id, exchange_rate
+        FROM order_items
+        ORDER BY order_id, id
+    ) oi
+WHERE
+    oi.order_id = o.id;
+
+ALTER TABLE
+    order_items DROP COLUMN IF EXISTS exchange_rate;
@@ -0,0 +1,17 @@
+-- Each line keeps the rate from its product currency to the order currency
+ALTER TABLE
+    order_items
+ADD
+    COLUMN exchange_rate NUMERIC(18, 8) NOT NULL DEFAULT 1;
+
+UPDATE
+    order_items oi
+SET
+    exchange_rate = o.exchange_rate
+FROM
+    orders o
+WHERE
+    o.id = oi.order_id;
+
+ALTER TABLE
+    orders DROP COLUMN IF EXISTS exchange_rate;
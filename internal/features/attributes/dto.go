@@ -0,0 +1,26 @@
+package attributes
+
+type AttributeType string
+
+const (
+	TypeText    AttributeType = "text"
+	TypeNumber  AttributeType = "number"
+	TypeBoolean AttributeType = "boolean"
+)
+
+type AttributeCreate struct {
+	Code         string  `json:"code" validate:"required,max=50"`
+	Name         string  `json:"name" validate:"required,max=100"`
+	Type         string  `json:"type" validate:"required,oneof=text number boolean"`
+	Unit         *string `json:"unit,omitempty" validate:"omitempty,max=20"`
+	IsFilterable *bool   `json:"is_filterable,omitempty"`
+	Position     int     `json:"position"`
+}
+
+// AttributeUpdate cannot change the type, values are stored per type.
+type AttributeUpdate struct {
+	Name         *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Unit         *string `json:"unit,omitempty" validate:"omitempty,max=20"`
+	IsFilterable *bool   `json:"is_filterable,omitempty" validate:"omitempty"`
+	Position     *int    `json:"position,omitempty" validate:"omitempty"`
+}
@@ -0,0 +1,110 @@
+package attributes
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const attributeIDKey = "attribute_id"
+
+type attributeHandler struct {
+	srv IAttributeService
+}
+
+func NewAttributeHandler(srv IAttributeService) *attributeHandler {
+	return &attributeHandler{srv: srv}
+}
+
+func (h *attributeHandler) Create(ctx *fiber.Ctx) error {
+	req := new(AttributeCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	a, err := h.srv.Create(ctx.Context(), req)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "attribute created", a)
+}
+
+func (h *attributeHandler) List(ctx *fiber.Ctx) error {
+	attributes, err := h.srv.List(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", attributes)
+}
+
+func (h *attributeHandler) GetByID(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, attributeIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	a, err := h.srv.GetByID(ctx.Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", a)
+}
+
+func (h *attributeHandler) Update(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, attributeIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(AttributeUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.Update(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "attribute updated", nil)
+}
+
+func (h *attributeHandler) Delete(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, attributeIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.Delete(ctx.Context(), id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+func (h *attributeHandler) handleError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrAttributeNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrAttributeCodeExists):
+		return response.Conflict(ctx, err.Error())
+	case errors.Is(err, errs.ErrAttributeCodeInvalid),
+		errors.Is(err, errs.ErrNoFieldUpdate):
+		return response.BadRequest(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
@@ -0,0 +1,17 @@
+package attributes
+
+import "time"
+
+// Attribute is a typed product property (brand, material, a numeric spec),
+// filterable attributes are offered as facets on the product listing.
+type Attribute struct {
+	ID           int64     `json:"id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Unit         *string   `json:"unit,omitempty"`
+	IsFilterable bool      `json:"is_filterable"`
+	Position     int       `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,169 @@
+package attributes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const (
+	selectAttributeQuery = `
+		SELECT id, code, name, type, unit, is_filterable, position, created_at, updated_at
+		FROM attributes
+	`
+)
+
+type IAttributeRepository interface {
+	Create(ctx context.Context, input *Attribute) error
+	GetByID(ctx context.Context, id int64) (*Attribute, error)
+	List(ctx context.Context) ([]*Attribute, error)
+	Update(ctx context.Context, id int64, input *AttributeUpdate) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type attributeRepository struct {
+	db *sql.DB
+}
+
+func NewAttributeRepository(db *sql.DB) IAttributeRepository {
+	return &attributeRepository{db: db}
+}
+
+func (r *attributeRepository) Create(ctx context.Context, input *Attribute) error {
+	query := `
+		INSERT INTO attributes (code, name, type, unit, is_filterable, position)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.Code,
+		input.Name,
+		input.Type,
+		input.Unit,
+		input.IsFilterable,
+		input.Position,
+	).Scan(
+		&input.ID,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *attributeRepository) GetByID(ctx context.Context, id int64) (*Attribute, error) {
+	a := new(Attribute)
+	err := scanAttribute(r.db.QueryRowContext(ctx, selectAttributeQuery+" WHERE id = $1", id), a)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrAttributeNotFound
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (r *attributeRepository) List(ctx context.Context) ([]*Attribute, error) {
+	rows, err := r.db.QueryContext(ctx, selectAttributeQuery+" ORDER BY position, code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attributes []*Attribute
+	for rows.Next() {
+		a := new(Attribute)
+		if err = scanAttribute(rows, a); err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, a)
+	}
+
+	return attributes, rows.Err()
+}
+
+func (r *attributeRepository) Update(ctx context.Context, id int64, input *AttributeUpdate) error {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if input.Name != nil {
+		columns = append(columns, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+
+	if input.Unit != nil {
+		columns = append(columns, fmt.Sprintf("unit = NULLIF($%d, '')", idx))
+		args = append(args, *input.Unit)
+		idx++
+	}
+
+	if input.IsFilterable != nil {
+		columns = append(columns, fmt.Sprintf("is_filterable = $%d", idx))
+		args = append(args, *input.IsFilterable)
+		idx++
+	}
+
+	if input.Position != nil {
+		columns = append(columns, fmt.Sprintf("position = $%d", idx))
+		args = append(args, *input.Position)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE attributes SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return r.execAffected(ctx, errs.ErrAttributeNotFound, query, args...)
+}
+
+// Delete removes the attribute together with its product values.
+func (r *attributeRepository) Delete(ctx context.Context, id int64) error {
+	return r.execAffected(ctx, errs.ErrAttributeNotFound, "DELETE FROM attributes WHERE id = $1", id)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttribute(row rowScanner, a *Attribute) error {
+	return row.Scan(
+		&a.ID,
+		&a.Code,
+		&a.Name,
+		&a.Type,
+		&a.Unit,
+		&a.IsFilterable,
+		&a.Position,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+}
+
+func (r *attributeRepository) execAffected(ctx context.Context, notFound error, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
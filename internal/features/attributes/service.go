@@ -0,0 +1,84 @@
+package attributes
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+type IAttributeService interface {
+	Create(ctx context.Context, req *AttributeCreate) (*Attribute, error)
+	GetByID(ctx context.Context, id int64) (*Attribute, error)
+	List(ctx context.Context) ([]*Attribute, error)
+	Update(ctx context.Context, id int64, req *AttributeUpdate) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type attributeService struct {
+	repo IAttributeRepository
+}
+
+func NewAttributeService(repo IAttributeRepository) IAttributeService {
+	return &attributeService{repo: repo}
+}
+
+func (s *attributeService) Create(ctx context.Context, req *AttributeCreate) (*Attribute, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	a := &Attribute{
+		Code:         strings.ToLower(strings.TrimSpace(req.Code)),
+		Name:         req.Name,
+		Type:         req.Type,
+		Unit:         req.Unit,
+		IsFilterable: true,
+		Position:     req.Position,
+	}
+	if req.IsFilterable != nil {
+		a.IsFilterable = *req.IsFilterable
+	}
+
+	// Codes are used as query parameters (attr.<code>)
+	if a.Code == "" || strings.ContainsAny(a.Code, ".,= &") {
+		return nil, errs.ErrAttributeCodeInvalid
+	}
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		if strings.Contains(err.Error(), "attributes_code_key") {
+			return nil, errs.ErrAttributeCodeExists
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (s *attributeService) GetByID(ctx context.Context, id int64) (*Attribute, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *attributeService) List(ctx context.Context) ([]*Attribute, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.List(ctx)
+}
+
+func (s *attributeService) Update(ctx context.Context, id int64, req *AttributeUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *attributeService) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.Delete(ctx, id)
+}
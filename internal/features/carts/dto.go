@@ -1,13 +1,24 @@
 package carts
 
+import "github.com/codepnw/core-ecommerce-system/internal/utils/money"
+
+// CartItemRequest adds a product variant to the cart, VariantID can be left
+// out for single-variant products.
 type CartItemRequest struct {
-	ProductID int64 `json:"product_id" validate:"required"`
-	Quantity  int   `json:"quantity" validate:"required,gt=0"`
+	ProductID int64  `json:"product_id" validate:"required"`
+	VariantID *int64 `json:"variant_id,omitempty"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0"`
 }
 
+// CartItemsResponse has the variant price, the product price unless the
+// variant overrides it.
 type CartItemsResponse struct {
-	ProductID       int64  `json:"product_id"`
-	ProductName     string `json:"product_name"`
-	ProductPrice    float64  `json:"product_price"`
-	ProductQuantity int64    `json:"product_quantity"`
+	ProductID       int64             `json:"product_id"`
+	VariantID       int64             `json:"variant_id"`
+	SKU             string            `json:"sku"`
+	VariantOptions  map[string]string `json:"variant_options,omitempty"`
+	ProductName     string            `json:"product_name"`
+	ProductPrice    money.Money       `json:"product_price"`
+	ProductQuantity int64             `json:"product_quantity"`
+	ProductWeight   int               `json:"product_weight_grams"`
 }
@@ -1,8 +1,11 @@
 package carts
 
 import (
+	"errors"
+
 	"github.com/codepnw/core-ecommerce-system/internal/middleware"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
 	"github.com/gofiber/fiber/v2"
@@ -32,6 +35,13 @@ func (h *cartHandler) AddItem(ctx *fiber.Ctx) error {
 	}
 
 	if err := h.srv.AddItem(ctx.Context(), user.UserID, req); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrProductNotFound),
+			errors.Is(err, errs.ErrVariantNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrVariantRequired):
+			return response.BadRequest(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
@@ -44,8 +54,11 @@ func (h *cartHandler) GetCart(ctx *fiber.Ctx) error {
 		return response.Unauthorized(ctx, err.Error())
 	}
 
-	res, err := h.srv.GetCart(ctx.Context(), user.UserID)
+	res, err := h.srv.GetCart(ctx.Context(), user.UserID, ctx.Query("currency"))
 	if err != nil {
+		if errors.Is(err, errs.ErrExchangeRateNotFound) {
+			return response.BadRequest(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
@@ -63,7 +76,13 @@ func (h *cartHandler) RemoveItem(ctx *fiber.Ctx) error {
 		return response.BadRequest(ctx, err.Error())
 	}
 
-	if err := h.srv.RemoveItem(ctx.Context(), user.ID, productID); err != nil {
+	var variantID *int64
+	if id := ctx.QueryInt("variant_id"); id > 0 {
+		v := int64(id)
+		variantID = &v
+	}
+
+	if err := h.srv.RemoveItem(ctx.Context(), user.ID, productID, variantID); err != nil {
 		return response.InternalServerError(ctx, err)
 	}
 
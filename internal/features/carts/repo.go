@@ -3,14 +3,15 @@ package carts
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 )
 
 type ICartRepository interface {
 	GetByUser(ctx context.Context, userID string) ([]*CartItemsResponse, error)
-	AddOrUpdate(ctx context.Context, userID string, productID int64, qty int) error
-	RemoveItem(ctx context.Context, userID string, productID int64) error
+	AddOrUpdate(ctx context.Context, userID string, productID, variantID int64, qty int) error
+	RemoveItem(ctx context.Context, userID string, productID int64, variantID *int64) error
 	ClearCart(ctx context.Context, userID string) error
 	ClearCartTx(ctx context.Context, tx *sql.Tx, userID string) error
 }
@@ -23,23 +24,33 @@ func NewCartRepository(db *sql.DB) ICartRepository {
 	return &cartRepository{db: db}
 }
 
-func (r *cartRepository) AddOrUpdate(ctx context.Context, userID string, productID int64, qty int) error {
+func (r *cartRepository) AddOrUpdate(ctx context.Context, userID string, productID, variantID int64, qty int) error {
 	query := `
-		INSERT INTO carts (user_id, product_id, quantity)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (user_id, product_id)
+		INSERT INTO carts (user_id, product_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, variant_id)
 		DO UPDATE SET quantity = carts.quantity + EXCLUDED.quantity, updated_at = now()
 	`
-	_, err := r.db.ExecContext(ctx, query, userID, productID, qty)
+	_, err := r.db.ExecContext(ctx, query, userID, productID, variantID, qty)
 	return err
 }
 
 func (r *cartRepository) GetByUser(ctx context.Context, userID string) ([]*CartItemsResponse, error) {
 	query := `
-		SELECT c.product_id, p.name, p.price, c.quantity
+		SELECT c.product_id, c.variant_id, v.sku,
+			COALESCE((
+				SELECT json_object_agg(o.name, ov.value)
+				FROM variant_option_values vov
+				JOIN product_option_values ov ON ov.id = vov.option_value_id
+				JOIN product_options o ON o.id = ov.option_id
+				WHERE vov.variant_id = v.id
+			), '{}'),
+			p.name, p.currency, COALESCE(v.price, p.price), c.quantity, p.weight_grams
 		FROM carts c
+		JOIN product_variants v ON v.id = c.variant_id
 		JOIN products p ON p.id = c.product_id
 		WHERE c.user_id = $1
+		ORDER BY c.created_at, c.variant_id
 	`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -50,24 +61,38 @@ func (r *cartRepository) GetByUser(ctx context.Context, userID string) ([]*CartI
 	var items []*CartItemsResponse
 	for rows.Next() {
 		item := new(CartItemsResponse)
+		var options []byte
 		err = rows.Scan(
 			&item.ProductID,
+			&item.VariantID,
+			&item.SKU,
+			&options,
 			&item.ProductName,
+			&item.ProductPrice.Currency,
 			&item.ProductPrice,
 			&item.ProductQuantity,
+			&item.ProductWeight,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err = json.Unmarshal(options, &item.VariantOptions); err != nil {
+			return nil, err
+		}
 		items = append(items, item)
 	}
 
 	return items, rows.Err()
 }
 
-func (r *cartRepository) RemoveItem(ctx context.Context, userID string, productID int64) error {
-	query := "DELETE FROM carts WHERE user_id = $1 AND product_id = $2"
-	res, err := r.db.ExecContext(ctx, query, userID, productID)
+// RemoveItem removes one variant of the product, or all of them when
+// variantID is nil.
+func (r *cartRepository) RemoveItem(ctx context.Context, userID string, productID int64, variantID *int64) error {
+	query := `
+		DELETE FROM carts
+		WHERE user_id = $1 AND product_id = $2 AND ($3::bigint IS NULL OR variant_id = $3)
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, productID, variantID)
 	if err != nil {
 		return err
 	}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/features/products"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 	"github.com/gofiber/fiber/v2/log"
@@ -12,18 +14,20 @@ import (
 
 type ICartService interface {
 	AddItem(ctx context.Context, userID string, req *CartItemRequest) error
-	GetCart(ctx context.Context, userID string) ([]*CartItemsResponse, error)
-	RemoveItem(ctx context.Context, userID string, productID int64) error
+	GetCart(ctx context.Context, userID, currency string) ([]*CartItemsResponse, error)
+	RemoveItem(ctx context.Context, userID string, productID int64, variantID *int64) error
 	ClearCart(ctx context.Context, userID string) error
 	ClearCartTx(ctx context.Context, tx *sql.Tx, userID string) error
 }
 
 type cartService struct {
-	repo ICartRepository
+	repo    ICartRepository
+	prodSrv products.IProductService
+	rateSrv currencies.IExchangeRateService
 }
 
-func NewCartService(repo ICartRepository) ICartService {
-	return &cartService{repo: repo}
+func NewCartService(repo ICartRepository, prodSrv products.IProductService, rateSrv currencies.IExchangeRateService) ICartService {
+	return &cartService{repo: repo, prodSrv: prodSrv, rateSrv: rateSrv}
 }
 
 func (s *cartService) AddItem(ctx context.Context, userID string, req *CartItemRequest) error {
@@ -34,7 +38,12 @@ func (s *cartService) AddItem(ctx context.Context, userID string, req *CartItemR
 		return errs.ErrQuantityIsZero
 	}
 
-	err := s.repo.AddOrUpdate(ctx, userID, req.ProductID, req.Quantity)
+	variant, err := s.prodSrv.ResolveVariant(ctx, req.ProductID, req.VariantID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.AddOrUpdate(ctx, userID, req.ProductID, variant.ID, req.Quantity)
 	if err != nil {
 		log.Errorf("add or update cart failed: %v", err)
 		return errors.New("add items to cart failed")
@@ -72,7 +81,8 @@ func (s *cartService) ClearCartTx(ctx context.Context, tx *sql.Tx, userID string
 	return nil
 }
 
-func (s *cartService) GetCart(ctx context.Context, userID string) ([]*CartItemsResponse, error) {
+// GetCart returns prices in each product's own currency, or converted to currency when given.
+func (s *cartService) GetCart(ctx context.Context, userID, currency string) ([]*CartItemsResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
@@ -84,14 +94,24 @@ func (s *cartService) GetCart(ctx context.Context, userID string) ([]*CartItemsR
 		log.Errorf("get cart failed: %v", err)
 		return nil, errors.New("get cart failed")
 	}
+
+	if currency != "" {
+		conv := s.rateSrv.NewConverter(currency)
+		for _, item := range cart {
+			if item.ProductPrice, err = conv.Convert(ctx, item.ProductPrice); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return cart, nil
 }
 
-func (s *cartService) RemoveItem(ctx context.Context, userID string, productID int64) error {
+func (s *cartService) RemoveItem(ctx context.Context, userID string, productID int64, variantID *int64) error {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	err := s.repo.RemoveItem(ctx, userID, productID)
+	err := s.repo.RemoveItem(ctx, userID, productID, variantID)
 	if err != nil {
 		if errors.Is(err, errs.ErrCartNotFound) {
 			return err
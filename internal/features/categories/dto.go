@@ -1,11 +1,20 @@
 package categories
 
 type CategoryCreate struct {
+	ParentID    *int64 `json:"parent_id,omitempty"`
 	Name        string `json:"name" validate:"required,gte=3"`
+	Slug        string `json:"slug,omitempty" validate:"max=80"`
 	Description string `json:"description"`
 }
 
+// CategoryMove moves a category with its subtree under ParentID, null makes
+// it a root category.
+type CategoryMove struct {
+	ParentID *int64 `json:"parent_id"`
+}
+
 type CategoryUpdate struct {
 	Name        *string `json:"name"`
+	Slug        *string `json:"slug,omitempty" validate:"omitempty,max=80"`
 	Description *string `json:"description"`
 }
@@ -1,7 +1,10 @@
 package categories
 
 import (
+	"errors"
+
 	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
 	"github.com/gofiber/fiber/v2"
@@ -28,19 +31,33 @@ func (h *categoryHandler) Create(ctx *fiber.Ctx) error {
 	}
 
 	if err := h.srv.Create(ctx.Context(), req); err != nil {
-		return response.InternalServerError(ctx, err)
+		return h.handleError(ctx, err)
 	}
 
 	return response.Created(ctx, "category created", req.Name)
 }
 
+// GetBySlug also resolves the old slugs of a category, redirect_slug then
+// tells the slug to link to instead.
+func (h *categoryHandler) GetBySlug(ctx *fiber.Ctx) error {
+	c, err := h.srv.GetBySlug(ctx.Context(), ctx.Params("slug"))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	if c.RedirectSlug != "" {
+		return response.Success(ctx, "slug moved", c)
+	}
+	return response.Success(ctx, "", c)
+}
+
 func (h *categoryHandler) List(ctx *fiber.Ctx) error {
-	cats, err := h.srv.List(ctx.Context())
+	cats, page, err := h.srv.List(ctx.Context(), ctx.QueryInt("limit"), ctx.QueryInt("offset"))
 	if err != nil {
 		return response.InternalServerError(ctx, err)
 	}
 
-	return response.Success(ctx, "", cats)
+	return response.Paginated(ctx, "", cats, page)
 }
 
 func (h *categoryHandler) Update(ctx *fiber.Ctx) error {
@@ -59,7 +76,7 @@ func (h *categoryHandler) Update(ctx *fiber.Ctx) error {
 	}
 
 	if err := h.srv.Update(ctx.Context(), id, req); err != nil {
-		return response.InternalServerError(ctx, err)
+		return h.handleError(ctx, err)
 	}
 
 	return response.Success(ctx, "category updated", nil)
@@ -72,8 +89,65 @@ func (h *categoryHandler) Delete(ctx *fiber.Ctx) error {
 	}
 
 	if err := h.srv.Delete(ctx.Context(), id); err != nil {
-		return response.InternalServerError(ctx, err)
+		return h.handleError(ctx, err)
 	}
 
 	return response.Success(ctx, "category deleted", nil)
 }
+
+func (h *categoryHandler) Tree(ctx *fiber.Ctx) error {
+	tree, err := h.srv.Tree(ctx.Context())
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", tree)
+}
+
+func (h *categoryHandler) Subtree(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, categoryIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	tree, err := h.srv.Subtree(ctx.Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", tree)
+}
+
+func (h *categoryHandler) Move(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, categoryIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(CategoryMove)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.Move(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "category moved", nil)
+}
+
+func (h *categoryHandler) handleError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrCategoryNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrCategoryParentNotFound),
+		errors.Is(err, errs.ErrNoFieldUpdate),
+		errors.Is(err, errs.ErrSlugInvalid):
+		return response.BadRequest(ctx, err.Error())
+	case errors.Is(err, errs.ErrCategoryCycle),
+		errors.Is(err, errs.ErrCategoryHasChildren),
+		errors.Is(err, errs.ErrSlugExists):
+		return response.Conflict(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
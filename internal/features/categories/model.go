@@ -4,8 +4,25 @@ import "time"
 
 type Category struct {
 	ID          int64     `json:"id"`
+	ParentID    *int64    `json:"parent_id"`
+	Path        string    `json:"path,omitempty"`
 	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	CustomSlug  bool      `json:"custom_slug"`
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// CategoryBySlugResponse is a category found by slug, RedirectSlug is its
+// current slug when an old one was asked for.
+type CategoryBySlugResponse struct {
+	*Category
+	RedirectSlug string `json:"redirect_slug,omitempty"`
+}
+
+// CategoryNode is a category with its child categories, ordered by name.
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
@@ -3,16 +3,33 @@ package categories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 )
 
+const selectCategoryQuery = `
+	SELECT id, parent_id, path, name, slug, custom_slug, COALESCE(description, ''), created_at, updated_at
+	FROM categories
+`
+
 type CategoryRepository interface {
 	Create(ctx context.Context, input *Category) error
-	List(ctx context.Context) ([]*Category, error)
-	Update(ctx context.Context, input *Category) error
+	GetByID(ctx context.Context, id int64) (*Category, error)
+	FindSlug(ctx context.Context, slug string) (id int64, current bool, err error)
+	SlugTaken(ctx context.Context, slug string, excludeID int64) (bool, error)
+	LockSlug(ctx context.Context, exec database.DBExec, id int64) (custom bool, err error)
+	SetSlug(ctx context.Context, exec database.DBExec, id int64, slug string, custom bool) error
+	ListSubtree(ctx context.Context, path string) ([]*Category, error)
+	LockMove(ctx context.Context, exec database.DBExec, id int64, parentID *int64) (c, parent *Category, err error)
+	Move(ctx context.Context, exec database.DBExec, id int64, parentID *int64) error
+	List(ctx context.Context, limit, offset int) ([]*Category, *cursor.Page, error)
+	Count(ctx context.Context) (int64, error)
+	Update(ctx context.Context, exec database.DBExec, input *Category) error
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -24,45 +41,272 @@ func NewCategoryRepository(db *sql.DB) CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
+// Create inserts the category under input.ParentID, its path is the parent
+// path followed by its own ID.
 func (r *categoryRepository) Create(ctx context.Context, input *Category) error {
-	query := `INSERT INTO categories (name, description) VALUES ($1, $2)`
-	_, err := r.db.ExecContext(ctx, query, input.Name, input.Description)
+	query := `
+		WITH n AS (
+			SELECT nextval(pg_get_serial_sequence('categories', 'id')) AS id
+		)
+		INSERT INTO categories (id, parent_id, path, name, slug, custom_slug, description)
+		SELECT n.id, $1, COALESCE((SELECT path FROM categories WHERE id = $1), '/') || n.id || '/', $2, $3, $4, $5
+		FROM n
+	`
+	_, err := r.db.ExecContext(ctx, query, input.ParentID, input.Name, input.Slug, input.CustomSlug, input.Description)
+	if err != nil {
+		if strings.Contains(err.Error(), "categories_parent_id_fkey") {
+			return errs.ErrCategoryParentNotFound
+		}
+		if strings.Contains(err.Error(), "categories_slug_key") {
+			return errs.ErrSlugExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *categoryRepository) GetByID(ctx context.Context, id int64) (*Category, error) {
+	query := selectCategoryQuery + ` WHERE id = $1`
+
+	c := new(Category)
+	if err := scanCategory(r.db.QueryRowContext(ctx, query, id), c); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// FindSlug returns the category with the slug, current is false when it is a
+// slug the category had before.
+func (r *categoryRepository) FindSlug(ctx context.Context, slug string) (id int64, current bool, err error) {
+	query := `
+		SELECT id, true FROM categories WHERE slug = $1
+		UNION ALL
+		SELECT entity_id, false FROM slug_history WHERE entity_type = 'category' AND slug = $1
+		ORDER BY 2 DESC
+		LIMIT 1
+	`
+	err = r.db.QueryRowContext(ctx, query, slug).Scan(&id, &current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, errs.ErrCategoryNotFound
+		}
+		return 0, false, err
+	}
+
+	return id, current, nil
+}
+
+func (r *categoryRepository) SlugTaken(ctx context.Context, slug string, excludeID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE slug = $1 AND id <> $2)`
+
+	var taken bool
+	if err := r.db.QueryRowContext(ctx, query, slug, excludeID).Scan(&taken); err != nil {
+		return false, err
+	}
+
+	return taken, nil
+}
+
+// LockSlug locks the category row for a slug change, custom tells whether its
+// slug was set by hand.
+func (r *categoryRepository) LockSlug(ctx context.Context, exec database.DBExec, id int64) (custom bool, err error) {
+	query := `SELECT custom_slug FROM categories WHERE id = $1 FOR UPDATE`
+	if err = exec.QueryRowContext(ctx, query, id).Scan(&custom); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, errs.ErrCategoryNotFound
+		}
+		return false, err
+	}
+
+	return custom, nil
+}
+
+// SetSlug changes the slug of the category and keeps the old one in the slug
+// history, the new slug stops redirecting to whatever had it before. custom
+// marks a slug set by hand.
+func (r *categoryRepository) SetSlug(ctx context.Context, exec database.DBExec, id int64, slug string, custom bool) error {
+	query := `
+		WITH old AS (
+			SELECT id, slug FROM categories WHERE id = $1
+		), h AS (
+			INSERT INTO slug_history (entity_type, slug, entity_id)
+			SELECT 'category', old.slug, old.id FROM old WHERE old.slug <> $2
+			ON CONFLICT (entity_type, slug) DO UPDATE SET
+				entity_id = EXCLUDED.entity_id,
+				created_at = now()
+		), d AS (
+			DELETE FROM slug_history WHERE entity_type = 'category' AND slug = $2
+		)
+		UPDATE categories SET slug = $2, custom_slug = $3, updated_at = now() WHERE id = $1
+	`
+	res, err := exec.ExecContext(ctx, query, id, slug, custom)
 	if err != nil {
+		if strings.Contains(err.Error(), "categories_slug_key") {
+			return errs.ErrSlugExists
+		}
 		return err
 	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrCategoryNotFound
+	}
+
 	return nil
 }
 
-func (r *categoryRepository) List(ctx context.Context) ([]*Category, error) {
-	query := `SELECT id, name, description FROM categories`
-	rows, err := r.db.QueryContext(ctx, query)
+// ListSubtree returns the categories whose path starts with path, parents
+// before their children and siblings by name.
+func (r *categoryRepository) ListSubtree(ctx context.Context, path string) ([]*Category, error) {
+	query := selectCategoryQuery + `
+		WHERE path LIKE $1 || '%'
+		ORDER BY length(path) - length(replace(path, '/', '')), name, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, path)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var cats []*Category
+	for rows.Next() {
+		c := new(Category)
+		if err = scanCategory(rows, c); err != nil {
+			return nil, err
+		}
+		cats = append(cats, c)
+	}
+
+	return cats, rows.Err()
+}
+
+// LockMove locks the category with the new parent and all its ancestors, in
+// id order. Two moves that could close a cycle together both lock each
+// other's category, so the second waits and sees the first one's paths.
+// parent is nil when parentID is.
+func (r *categoryRepository) LockMove(ctx context.Context, exec database.DBExec, id int64, parentID *int64) (c, parent *Category, err error) {
+	query := selectCategoryQuery + `
+		WHERE id = $1 OR id = $2
+			OR (SELECT path FROM categories WHERE id = $2) LIKE path || '%'
+		ORDER BY id
+		FOR UPDATE
+	`
+	rows, err := exec.QueryContext(ctx, query, id, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		locked := new(Category)
+		if err = scanCategory(rows, locked); err != nil {
+			return nil, nil, err
+		}
+		if locked.ID == id {
+			c = locked
+		}
+		if parentID != nil && locked.ID == *parentID {
+			parent = locked
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if c == nil {
+		return nil, nil, errs.ErrCategoryNotFound
+	}
+	if parentID != nil && parent == nil {
+		return nil, nil, errs.ErrCategoryParentNotFound
+	}
+
+	return c, parent, nil
+}
+
+// Move puts the category under parentID and rewrites the path of its whole
+// subtree. Nothing moves when the new parent is inside the subtree.
+func (r *categoryRepository) Move(ctx context.Context, exec database.DBExec, id int64, parentID *int64) error {
+	query := `
+		WITH c AS (
+			SELECT id, path FROM categories WHERE id = $1
+		), np AS (
+			SELECT COALESCE((SELECT path FROM categories WHERE id = $2), '/') || c.id || '/' AS path
+			FROM c
+		)
+		UPDATE categories t SET
+			path = np.path || substr(t.path, length(c.path) + 1),
+			parent_id = CASE WHEN t.id = c.id THEN $2 ELSE t.parent_id END,
+			updated_at = CASE WHEN t.id = c.id THEN now() ELSE t.updated_at END
+		FROM c, np
+		WHERE t.path LIKE c.path || '%'
+			AND NOT EXISTS (
+				SELECT 1 FROM categories p WHERE p.id = $2 AND p.path LIKE c.path || '%'
+			)
+	`
+	res, err := exec.ExecContext(ctx, query, id, parentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "categories_parent_id_fkey") {
+			return errs.ErrCategoryParentNotFound
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrCategoryCycle
+	}
+
+	return nil
+}
+
+func (r *categoryRepository) List(ctx context.Context, limit, offset int) ([]*Category, *cursor.Page, error) {
+	// One extra row tells whether there is a next page
+	query := selectCategoryQuery + ` ORDER BY id LIMIT $1 OFFSET $2`
+	rows, err := r.db.QueryContext(ctx, query, limit+1, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
 
 	var categories []*Category
 
 	for rows.Next() {
 		c := new(Category)
-		err = rows.Scan(
-			&c.ID,
-			&c.Name,
-			&c.Description,
-		)
-		if err != nil {
-			return nil, err
+		if err = scanCategory(rows, c); err != nil {
+			return nil, nil, err
 		}
 		categories = append(categories, c)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return categories, nil
+	categories, page := cursor.OffsetPage(categories, limit, offset)
+	return categories, page, nil
+}
+
+func (r *categoryRepository) Count(ctx context.Context) (int64, error) {
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM categories`).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
 }
 
-func (r *categoryRepository) Update(ctx context.Context, input *Category) error {
+func (r *categoryRepository) Update(ctx context.Context, exec database.DBExec, input *Category) error {
 	columns := []string{}
 	args := []any{}
 	idx := 1
@@ -87,7 +331,7 @@ func (r *categoryRepository) Update(ctx context.Context, input *Category) error
 	query := fmt.Sprintf(`UPDATE categories SET %s, updated_at = now() WHERE id = $%d`, setColumns, idx)
 	args = append(args, input.ID)
 
-	res, err := r.db.ExecContext(ctx, query, args...)
+	res, err := exec.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -107,6 +351,9 @@ func (r *categoryRepository) Update(ctx context.Context, input *Category) error
 func (r *categoryRepository) Delete(ctx context.Context, id int64) error {
 	res, err := r.db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
 	if err != nil {
+		if strings.Contains(err.Error(), "categories_parent_id_fkey") {
+			return errs.ErrCategoryHasChildren
+		}
 		return err
 	}
 
@@ -121,3 +368,21 @@ func (r *categoryRepository) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+func scanCategory(row rowScanner, c *Category) error {
+	return row.Scan(
+		&c.ID,
+		&c.ParentID,
+		&c.Path,
+		&c.Name,
+		&c.Slug,
+		&c.CustomSlug,
+		&c.Description,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
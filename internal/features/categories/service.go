@@ -2,24 +2,36 @@ package categories
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 
+	"github.com/codepnw/core-ecommerce-system/internal/database"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/slug"
 )
 
 type CategoryService interface {
 	Create(ctx context.Context, req *CategoryCreate) error
-	List(ctx context.Context) ([]*Category, error)
+	GetBySlug(ctx context.Context, slug string) (*CategoryBySlugResponse, error)
+	List(ctx context.Context, limit, offset int) ([]*Category, *cursor.Page, error)
 	Update(ctx context.Context, id int64, req *CategoryUpdate) error
 	Delete(ctx context.Context, id int64) error
+
+	// Tree
+	Tree(ctx context.Context) ([]*CategoryNode, error)
+	Subtree(ctx context.Context, id int64) (*CategoryNode, error)
+	Move(ctx context.Context, id int64, req *CategoryMove) error
 }
 
 type categoryService struct {
 	repo CategoryRepository
+	tx   *database.TxManager
 }
 
-func NewCategoryService(repo CategoryRepository) CategoryService {
-	return &categoryService{repo: repo}
+func NewCategoryService(repo CategoryRepository, tx *database.TxManager) CategoryService {
+	return &categoryService{repo: repo, tx: tx}
 }
 
 func (s *categoryService) Create(ctx context.Context, req *CategoryCreate) error {
@@ -27,33 +39,158 @@ func (s *categoryService) Create(ctx context.Context, req *CategoryCreate) error
 	defer cancel()
 
 	input := &Category{
+		ParentID:    req.ParentID,
 		Name:        req.Name,
 		Description: req.Description,
 	}
+
+	var err error
+	if input.Slug, err = s.newSlug(ctx, 0, req.Name, req.Slug); err != nil {
+		return err
+	}
+	input.CustomSlug = req.Slug != ""
+
 	return s.repo.Create(ctx, input)
 }
 
-func (s *categoryService) List(ctx context.Context) ([]*Category, error) {
+// GetBySlug returns the category with the slug, or with a slug it had before
+// along with its current slug to redirect to.
+func (s *categoryService) GetBySlug(ctx context.Context, slug string) (*CategoryBySlugResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	return s.repo.List(ctx)
+	id, current, err := s.repo.FindSlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CategoryBySlugResponse{Category: c}
+	if !current {
+		res.RedirectSlug = c.Slug
+	}
+
+	return res, nil
+}
+
+// newSlug returns the explicit slug when it is free, otherwise a free slug
+// made from the name. excludeID is the category the slug is for.
+func (s *categoryService) newSlug(ctx context.Context, excludeID int64, name, explicit string) (string, error) {
+	taken := func(ctx context.Context, candidate string) (bool, error) {
+		return s.repo.SlugTaken(ctx, candidate, excludeID)
+	}
+
+	if explicit != "" {
+		value := slug.Make(explicit)
+		if value == "" {
+			return "", errs.ErrSlugInvalid
+		}
+
+		used, err := taken(ctx, value)
+		if err != nil {
+			return "", err
+		}
+		if used {
+			return "", errs.ErrSlugExists
+		}
+		return value, nil
+	}
+
+	base := slug.Make(name)
+	if base == "" {
+		base = "category"
+	}
+	return slug.Unique(ctx, base, taken)
+}
+
+func (s *categoryService) List(ctx context.Context, limit, offset int) ([]*Category, *cursor.Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	cats, page, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if page.Total, err = s.repo.Count(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return cats, page, nil
 }
 
 func (s *categoryService) Update(ctx context.Context, id int64, req *CategoryUpdate) error {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	if req.Name == nil && req.Description == nil {
+	if req.Name == nil && req.Description == nil && req.Slug == nil {
 		return errs.ErrNoFieldUpdate
 	}
 
-	input := &Category{
-		ID:          id,
-		Name:        *req.Name,
-		Description: *req.Description,
+	// The slug changes with the other fields or not at all
+	return s.tx.Transaction(ctx, func(tx *sql.Tx) error {
+		if req.Slug != nil || req.Name != nil {
+			if err := s.updateSlug(ctx, tx, id, req); err != nil {
+				return err
+			}
+		}
+
+		if req.Name == nil && req.Description == nil {
+			return nil
+		}
+
+		input := &Category{ID: id}
+		if req.Name != nil {
+			input.Name = *req.Name
+		}
+		if req.Description != nil {
+			input.Description = *req.Description
+		}
+		return s.repo.Update(ctx, tx, input)
+	})
+}
+
+// updateSlug sets the slug given in req, or one made from the new name while
+// the current slug is a generated one, a slug set by hand stays on rename.
+// The old slug keeps resolving through the slug history.
+func (s *categoryService) updateSlug(ctx context.Context, tx *sql.Tx, id int64, req *CategoryUpdate) error {
+	custom, err := s.repo.LockSlug(ctx, tx, id)
+	if err != nil {
+		return err
 	}
-	return s.repo.Update(ctx, input)
+
+	if req.Slug == nil {
+		if custom {
+			return nil
+		}
+
+		value, err := s.newSlug(ctx, id, *req.Name, "")
+		if err != nil {
+			return err
+		}
+		return s.repo.SetSlug(ctx, tx, id, value, false)
+	}
+
+	if *req.Slug == "" {
+		return errs.ErrSlugInvalid
+	}
+
+	value, err := s.newSlug(ctx, id, "", *req.Slug)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetSlug(ctx, tx, id, value, true)
 }
 
 func (s *categoryService) Delete(ctx context.Context, id int64) error {
@@ -62,3 +199,82 @@ func (s *categoryService) Delete(ctx context.Context, id int64) error {
 
 	return s.repo.Delete(ctx, id)
 }
+
+// Tree returns every root category with its descendants.
+func (s *categoryService) Tree(ctx context.Context) ([]*CategoryNode, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	cats, err := s.repo.ListSubtree(ctx, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	return buildTree(cats), nil
+}
+
+// Subtree returns the category with its descendants.
+func (s *categoryService) Subtree(ctx context.Context, id int64) (*CategoryNode, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cats, err := s.repo.ListSubtree(ctx, c.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := buildTree(cats)
+	if len(roots) == 0 {
+		return nil, errs.ErrCategoryNotFound
+	}
+
+	return roots[0], nil
+}
+
+// Move puts the category and its subtree under another parent, or at the
+// root when req.ParentID is nil. A category cannot move below itself, the
+// rows are locked so a concurrent move cannot close a cycle either.
+func (s *categoryService) Move(ctx context.Context, id int64, req *CategoryMove) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.tx.Transaction(ctx, func(tx *sql.Tx) error {
+		c, parent, err := s.repo.LockMove(ctx, tx, id, req.ParentID)
+		if err != nil {
+			return err
+		}
+
+		if parent != nil && strings.HasPrefix(parent.Path, c.Path) {
+			return errs.ErrCategoryCycle
+		}
+
+		return s.repo.Move(ctx, tx, id, req.ParentID)
+	})
+}
+
+// buildTree links categories listed parents first into trees, categories
+// whose parent is not listed are roots.
+func buildTree(cats []*Category) []*CategoryNode {
+	nodes := make(map[int64]*CategoryNode, len(cats))
+	roots := []*CategoryNode{}
+
+	for _, c := range cats {
+		node := &CategoryNode{Category: c, Children: []*CategoryNode{}}
+		nodes[c.ID] = node
+
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return roots
+}
@@ -0,0 +1,89 @@
+package categories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/database/dbtest"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+// fakeCategoryRepo holds the tree /1/2/3/ and /4/, it records the
+// transactions of the lock and of the move.
+type fakeCategoryRepo struct {
+	CategoryRepository
+
+	lockExec database.DBExec
+	moveExec database.DBExec
+}
+
+var fakePaths = map[int64]string{1: "/1/", 2: "/1/2/", 3: "/1/2/3/", 4: "/4/"}
+
+func (r *fakeCategoryRepo) LockMove(ctx context.Context, exec database.DBExec, id int64, parentID *int64) (*Category, *Category, error) {
+	r.lockExec = exec
+
+	path, ok := fakePaths[id]
+	if !ok {
+		return nil, nil, errs.ErrCategoryNotFound
+	}
+	c := &Category{ID: id, Path: path}
+
+	if parentID == nil {
+		return c, nil, nil
+	}
+	path, ok = fakePaths[*parentID]
+	if !ok {
+		return nil, nil, errs.ErrCategoryParentNotFound
+	}
+	return c, &Category{ID: *parentID, Path: path}, nil
+}
+
+func (r *fakeCategoryRepo) Move(ctx context.Context, exec database.DBExec, id int64, parentID *int64) error {
+	r.moveExec = exec
+	return nil
+}
+
+func TestMove(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name     string
+		id       int64
+		parentID *int64
+		wantErr  error
+	}{
+		{name: "under another tree", id: 2, parentID: id(4)},
+		{name: "to the root", id: 3},
+		{name: "under itself", id: 2, parentID: id(2), wantErr: errs.ErrCategoryCycle},
+		{name: "under its descendant", id: 1, parentID: id(3), wantErr: errs.ErrCategoryCycle},
+		{name: "unknown parent", id: 2, parentID: id(9), wantErr: errs.ErrCategoryParentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCategoryRepo{}
+			s := &categoryService{repo: repo, tx: database.NewTxManager(dbtest.Open(t))}
+
+			err := s.Move(context.Background(), tt.id, &CategoryMove{ParentID: tt.parentID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Move error = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, ok := repo.lockExec.(*sql.Tx); !ok {
+				t.Fatalf("rows locked on %T, want a transaction", repo.lockExec)
+			}
+			if tt.wantErr != nil {
+				if repo.moveExec != nil {
+					t.Error("rejected move was applied")
+				}
+				return
+			}
+			if repo.moveExec != repo.lockExec {
+				t.Error("moved outside the locking transaction")
+			}
+		})
+	}
+}
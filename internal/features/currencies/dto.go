@@ -0,0 +1,7 @@
+package currencies
+
+type ExchangeRateRequest struct {
+	BaseCurrency  string  `json:"base_currency" validate:"required,len=3,alpha"`
+	QuoteCurrency string  `json:"quote_currency" validate:"required,len=3,alpha"`
+	Rate          float64 `json:"rate" validate:"required,gt=0"`
+}
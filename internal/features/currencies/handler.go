@@ -0,0 +1,65 @@
+package currencies
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	baseKey  = "base"
+	quoteKey = "quote"
+)
+
+type exchangeRateHandler struct {
+	srv IExchangeRateService
+}
+
+func NewExchangeRateHandler(srv IExchangeRateService) *exchangeRateHandler {
+	return &exchangeRateHandler{srv: srv}
+}
+
+func (h *exchangeRateHandler) UpsertRate(ctx *fiber.Ctx) error {
+	req := new(ExchangeRateRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	rate, err := h.srv.UpsertRate(ctx.Context(), req)
+	if err != nil {
+		if errors.Is(err, errs.ErrSameCurrency) {
+			return response.BadRequest(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "exchange rate saved", rate)
+}
+
+func (h *exchangeRateHandler) ListRates(ctx *fiber.Ctx) error {
+	rates, err := h.srv.ListRates(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", rates)
+}
+
+func (h *exchangeRateHandler) DeleteRate(ctx *fiber.Ctx) error {
+	err := h.srv.DeleteRate(ctx.Context(), ctx.Params(baseKey), ctx.Params(quoteKey))
+	if err != nil {
+		if errors.Is(err, errs.ErrExchangeRateNotFound) {
+			return response.NotFound(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
@@ -0,0 +1,11 @@
+package currencies
+
+import "time"
+
+// ExchangeRate is how many units of QuoteCurrency one unit of BaseCurrency buys.
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,113 @@
+package currencies
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+type IExchangeRateRepository interface {
+	Upsert(ctx context.Context, input *ExchangeRate) error
+	Get(ctx context.Context, base, quote string) (*ExchangeRate, error)
+	List(ctx context.Context) ([]*ExchangeRate, error)
+	Delete(ctx context.Context, base, quote string) error
+}
+
+type exchangeRateRepository struct {
+	db *sql.DB
+}
+
+func NewExchangeRateRepository(db *sql.DB) IExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+func (r *exchangeRateRepository) Upsert(ctx context.Context, input *ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (base_currency, quote_currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.BaseCurrency,
+		input.QuoteCurrency,
+		input.Rate,
+	).Scan(&input.UpdatedAt)
+}
+
+func (r *exchangeRateRepository) Get(ctx context.Context, base, quote string) (*ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate, updated_at
+		FROM exchange_rates
+		WHERE base_currency = $1 AND quote_currency = $2
+	`
+	e := new(ExchangeRate)
+	err := r.db.QueryRowContext(ctx, query, base, quote).Scan(
+		&e.BaseCurrency,
+		&e.QuoteCurrency,
+		&e.Rate,
+		&e.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrExchangeRateNotFound
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (r *exchangeRateRepository) List(ctx context.Context) ([]*ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate, updated_at
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*ExchangeRate
+	for rows.Next() {
+		e := new(ExchangeRate)
+		err = rows.Scan(
+			&e.BaseCurrency,
+			&e.QuoteCurrency,
+			&e.Rate,
+			&e.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, e)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *exchangeRateRepository) Delete(ctx context.Context, base, quote string) error {
+	query := `DELETE FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2`
+	res, err := r.db.ExecContext(ctx, query, base, quote)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrExchangeRateNotFound
+	}
+
+	return nil
+}
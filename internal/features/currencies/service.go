@@ -0,0 +1,134 @@
+package currencies
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type IExchangeRateService interface {
+	UpsertRate(ctx context.Context, req *ExchangeRateRequest) (*ExchangeRate, error)
+	ListRates(ctx context.Context) ([]*ExchangeRate, error)
+	DeleteRate(ctx context.Context, base, quote string) error
+	GetRate(ctx context.Context, from, to string) (float64, error)
+	NewConverter(to string) *Converter
+}
+
+type exchangeRateService struct {
+	repo IExchangeRateRepository
+}
+
+func NewExchangeRateService(repo IExchangeRateRepository) IExchangeRateService {
+	return &exchangeRateService{repo: repo}
+}
+
+func (s *exchangeRateService) UpsertRate(ctx context.Context, req *ExchangeRateRequest) (*ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	rate := &ExchangeRate{
+		BaseCurrency:  strings.ToUpper(req.BaseCurrency),
+		QuoteCurrency: strings.ToUpper(req.QuoteCurrency),
+		Rate:          req.Rate,
+	}
+	if rate.BaseCurrency == rate.QuoteCurrency {
+		return nil, errs.ErrSameCurrency
+	}
+
+	if err := s.repo.Upsert(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (s *exchangeRateService) ListRates(ctx context.Context) ([]*ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.List(ctx)
+}
+
+func (s *exchangeRateService) DeleteRate(ctx context.Context, base, quote string) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.Delete(ctx, strings.ToUpper(base), strings.ToUpper(quote))
+}
+
+// GetRate returns how many units of `to` one unit of `from` buys. When only
+// the opposite pair is stored its inverse is used.
+func (s *exchangeRateService) GetRate(ctx context.Context, from, to string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	rate, err := s.repo.Get(ctx, from, to)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, errs.ErrExchangeRateNotFound) {
+		return 0, err
+	}
+
+	inverse, err := s.repo.Get(ctx, to, from)
+	if err != nil {
+		return 0, err
+	}
+	return 1 / inverse.Rate, nil
+}
+
+func (s *exchangeRateService) NewConverter(to string) *Converter {
+	if to == "" {
+		to = money.DefaultCurrency
+	}
+	return &Converter{
+		srv:   s,
+		to:    strings.ToUpper(to),
+		rates: make(map[string]float64),
+	}
+}
+
+// Converter converts amounts into one currency, looking each rate up once.
+// It is meant for a single request and is not safe for concurrent use.
+type Converter struct {
+	srv   IExchangeRateService
+	to    string
+	rates map[string]float64
+}
+
+func (c *Converter) Currency() string {
+	return c.to
+}
+
+// Rate returns the rate from `from` to the converter currency.
+func (c *Converter) Rate(ctx context.Context, from string) (float64, error) {
+	from = strings.ToUpper(from)
+	if rate, ok := c.rates[from]; ok {
+		return rate, nil
+	}
+
+	rate, err := c.srv.GetRate(ctx, from, c.to)
+	if err != nil {
+		return 0, err
+	}
+	c.rates[from] = rate
+
+	return rate, nil
+}
+
+func (c *Converter) Convert(ctx context.Context, m money.Money) (money.Money, error) {
+	rate, err := c.Rate(ctx, m.CurrencyCode())
+	if err != nil {
+		return money.Money{}, err
+	}
+	return m.Convert(c.to, rate), nil
+}
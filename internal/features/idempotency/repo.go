@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository returns a Postgres backed middleware.IdempotencyStore.
+func NewIdempotencyRepository(db *sql.DB) middleware.IdempotencyStore {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, rec *middleware.IdempotencyRecord, ttl time.Duration) (*middleware.IdempotencyRecord, bool, error) {
+	// Expired keys can be reused
+	deleteQuery := `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND idem_key = $2 AND created_at < $3
+	`
+	if _, err := r.db.ExecContext(ctx, deleteQuery, rec.Scope, rec.Key, time.Now().Add(-ttl)); err != nil {
+		return nil, false, err
+	}
+
+	insertQuery := `
+		INSERT INTO idempotency_keys (idem_key, scope, method, path, request_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, idem_key) DO NOTHING
+		RETURNING created_at
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		insertQuery,
+		rec.Key,
+		rec.Scope,
+		rec.Method,
+		rec.Path,
+		rec.RequestHash,
+	).Scan(&rec.CreatedAt)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	existing, err := r.get(ctx, rec.Scope, rec.Key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, rec *middleware.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3, completed_at = now()
+		WHERE scope = $4 AND idem_key = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, rec.StatusCode, rec.ContentType, rec.ResponseBody, rec.Scope, rec.Key)
+	return err
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND idem_key = $2 AND completed_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, scope, key)
+	return err
+}
+
+func (r *idempotencyRepository) get(ctx context.Context, scope, key string) (*middleware.IdempotencyRecord, error) {
+	query := `
+		SELECT idem_key, scope, method, path, request_hash, COALESCE(status_code, 0),
+			COALESCE(content_type, ''), response_body, completed_at, created_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND idem_key = $2
+	`
+	rec := new(middleware.IdempotencyRecord)
+	err := r.db.QueryRowContext(ctx, query, scope, key).Scan(
+		&rec.Key,
+		&rec.Scope,
+		&rec.Method,
+		&rec.Path,
+		&rec.RequestHash,
+		&rec.StatusCode,
+		&rec.ContentType,
+		&rec.ResponseBody,
+		&rec.CompletedAt,
+		&rec.CreatedAt,
+	)
+	if err != nil {
+		// Released by the first request between our insert and select
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("idempotency key %q was released, retry the request", key)
+		}
+		return nil, err
+	}
+
+	return rec, nil
+}
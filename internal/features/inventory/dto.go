@@ -0,0 +1,12 @@
+package inventory
+
+type ReservationStatus string
+
+const (
+	// StatusActive holds stock for an unpaid order until it expires.
+	StatusActive ReservationStatus = "active"
+	// StatusConverted was deducted from stock when the order was paid.
+	StatusConverted ReservationStatus = "converted"
+	// StatusReleased gave the held stock back without deducting it.
+	StatusReleased ReservationStatus = "released"
+)
@@ -0,0 +1,23 @@
+package inventory
+
+import "time"
+
+type Reservation struct {
+	ID          int64     `json:"id"`
+	OrderID     int64     `json:"order_id"`
+	ProductID   int64     `json:"product_id"`
+	VariantID   int64     `json:"variant_id"`
+	WarehouseID *int64    `json:"warehouse_id,omitempty"`
+	Quantity    int       `json:"quantity"`
+	Status      string    `json:"status"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WarehouseAvailability is the stock of a variant in one warehouse minus its
+// unexpired reservations.
+type WarehouseAvailability struct {
+	WarehouseID int64
+	Available   int
+}
@@ -0,0 +1,187 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const (
+	selectReservationQuery = `
+		SELECT id, order_id, product_id, variant_id, warehouse_id, quantity, status, expires_at, created_at, updated_at
+		FROM stock_reservations
+	`
+)
+
+type IInventoryRepository interface {
+	LockAvailable(ctx context.Context, tx *sql.Tx, variantID int64) ([]*WarehouseAvailability, error)
+	Create(ctx context.Context, tx *sql.Tx, input *Reservation) error
+	ListByOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) ([]*Reservation, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status ReservationStatus) error
+}
+
+type inventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) IInventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// LockAvailable locks the stock rows of the variant in active warehouses, so
+// reservations of the same variant are serialized, and returns what is
+// available in each of them, highest priority first.
+func (r *inventoryRepository) LockAvailable(ctx context.Context, tx *sql.Tx, variantID int64) ([]*WarehouseAvailability, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = $1)`, variantID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errs.ErrVariantNotFound
+	}
+
+	query := `
+		SELECT ws.warehouse_id, ws.stock
+		FROM warehouse_stock ws
+		JOIN warehouses w ON w.id = ws.warehouse_id
+		WHERE ws.variant_id = $1 AND w.is_active
+		ORDER BY w.priority DESC, w.id
+		FOR UPDATE OF ws
+	`
+	rows, err := tx.QueryContext(ctx, query, variantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stock []*WarehouseAvailability
+	for rows.Next() {
+		wa := new(WarehouseAvailability)
+		if err = rows.Scan(&wa.WarehouseID, &wa.Available); err != nil {
+			return nil, err
+		}
+		stock = append(stock, wa)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reserved, err := r.reservedByWarehouse(ctx, tx, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, wa := range stock {
+		wa.Available -= reserved[wa.WarehouseID]
+		// Reservations made before warehouses existed hold stock of the first one
+		if i == 0 {
+			wa.Available -= reserved[0]
+		}
+	}
+
+	return stock, nil
+}
+
+func (r *inventoryRepository) reservedByWarehouse(ctx context.Context, tx *sql.Tx, variantID int64) (map[int64]int, error) {
+	query := `
+		SELECT COALESCE(warehouse_id, 0), SUM(quantity)
+		FROM stock_reservations
+		WHERE variant_id = $1 AND status = 'active' AND expires_at > now()
+		GROUP BY 1
+	`
+	rows, err := tx.QueryContext(ctx, query, variantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reserved := make(map[int64]int)
+	for rows.Next() {
+		var warehouseID int64
+		var qty int
+		if err = rows.Scan(&warehouseID, &qty); err != nil {
+			return nil, err
+		}
+		reserved[warehouseID] = qty
+	}
+
+	return reserved, rows.Err()
+}
+
+func (r *inventoryRepository) Create(ctx context.Context, tx *sql.Tx, input *Reservation) error {
+	query := `
+		INSERT INTO stock_reservations (order_id, product_id, variant_id, warehouse_id, quantity, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at, updated_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.ProductID,
+		input.VariantID,
+		input.WarehouseID,
+		input.Quantity,
+		input.ExpiresAt,
+	).Scan(
+		&input.ID,
+		&input.Status,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *inventoryRepository) ListByOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) ([]*Reservation, error) {
+	rows, err := tx.QueryContext(ctx, selectReservationQuery+" WHERE order_id = $1 ORDER BY variant_id FOR UPDATE", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []*Reservation
+	for rows.Next() {
+		res := new(Reservation)
+		err = rows.Scan(
+			&res.ID,
+			&res.OrderID,
+			&res.ProductID,
+			&res.VariantID,
+			&res.WarehouseID,
+			&res.Quantity,
+			&res.Status,
+			&res.ExpiresAt,
+			&res.CreatedAt,
+			&res.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, res)
+	}
+
+	return reservations, rows.Err()
+}
+
+func (r *inventoryRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status ReservationStatus) error {
+	query := `
+		UPDATE stock_reservations SET status = $1, updated_at = now()
+		WHERE id = $2
+	`
+	res, err := tx.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrReservationNotFound
+	}
+
+	return nil
+}
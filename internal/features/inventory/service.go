@@ -0,0 +1,163 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/features/products"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+)
+
+// IInventoryService holds stock for unpaid orders. Product stock is only
+// deducted once the order is paid, until then the quantity is reserved and
+// not available to other customers.
+type IInventoryService interface {
+	Reserve(ctx context.Context, tx *sql.Tx, orderID, productID, variantID int64, qty int) (int64, bool, error)
+	CheckOrder(ctx context.Context, tx *sql.Tx, orderID int64) error
+	ConvertOrder(ctx context.Context, tx *sql.Tx, orderID int64, actorID string) error
+	ReleaseOrder(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]bool, error)
+}
+
+type InventoryServiceConfig struct {
+	InventoryRepo  IInventoryRepository     `validate:"required"`
+	ProdSrv        products.IProductService `validate:"required"`
+	ReservationTTL time.Duration            `validate:"required"`
+}
+
+func NewInventoryService(cfg *InventoryServiceConfig) (IInventoryService, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("InventoryServiceConfig required all fields: %w", err)
+	}
+	return cfg, nil
+}
+
+// Reserve holds qty of the product variant for the order in the warehouse
+// fulfilling the line and returns that warehouse. It returns false when no
+// warehouse has enough stock available.
+func (s *InventoryServiceConfig) Reserve(ctx context.Context, tx *sql.Tx, orderID, productID, variantID int64, qty int) (int64, bool, error) {
+	if qty <= 0 {
+		return 0, false, errs.ErrQuantityIsZero
+	}
+
+	stock, err := s.InventoryRepo.LockAvailable(ctx, tx, variantID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	warehouseID, ok := allocate(stock, qty)
+	if !ok {
+		return 0, false, nil
+	}
+
+	err = s.InventoryRepo.Create(ctx, tx, &Reservation{
+		OrderID:     orderID,
+		ProductID:   productID,
+		VariantID:   variantID,
+		WarehouseID: &warehouseID,
+		Quantity:    qty,
+		ExpiresAt:   time.Now().Add(s.ReservationTTL),
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("insert reservation failed: %w", err)
+	}
+
+	return warehouseID, true, nil
+}
+
+// allocate picks the warehouse for an order line: the highest priority one
+// that covers the whole quantity, so a line is never split over warehouses.
+func allocate(stock []*WarehouseAvailability, qty int) (int64, bool) {
+	for _, wa := range stock {
+		if wa.Available >= qty {
+			return wa.WarehouseID, true
+		}
+	}
+	return 0, false
+}
+
+// CheckOrder locks the reservations of the order until tx ends and returns
+// errs.ErrReservationExpired when one of the active ones expired.
+func (s *InventoryServiceConfig) CheckOrder(ctx context.Context, tx *sql.Tx, orderID int64) error {
+	reservations, err := s.InventoryRepo.ListByOrderForUpdate(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	return checkExpiry(reservations, time.Now())
+}
+
+// ConvertOrder deducts the active reservations of a paid order from stock. It
+// returns errs.ErrReservationExpired, before deducting anything, when one of
+// them expired: its units count as available again and may be held by another
+// checkout already, even though the sweeper did not release it yet.
+func (s *InventoryServiceConfig) ConvertOrder(ctx context.Context, tx *sql.Tx, orderID int64, actorID string) error {
+	reservations, err := s.InventoryRepo.ListByOrderForUpdate(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkExpiry(reservations, time.Now()); err != nil {
+		return err
+	}
+
+	for _, res := range reservations {
+		if ReservationStatus(res.Status) != StatusActive {
+			continue
+		}
+
+		// Converted first, the deduction must not count the order's own
+		// reservation as held stock
+		if err = s.InventoryRepo.UpdateStatus(ctx, tx, res.ID, StatusConverted); err != nil {
+			return fmt.Errorf("update reservation failed: %w", err)
+		}
+
+		change := products.NewStockChange(products.MovementOrder, res.WarehouseID, actorID, products.RefOrder, orderID)
+		ok, err := s.ProdSrv.DeductStock(ctx, tx, res.VariantID, res.Quantity, change)
+		if err != nil {
+			return fmt.Errorf("deduct product stock failed: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: product %d variant %d", errs.ErrProductOutOfStock, res.ProductID, res.VariantID)
+		}
+	}
+
+	return nil
+}
+
+// checkExpiry returns errs.ErrReservationExpired when an active reservation
+// expired at now.
+func checkExpiry(reservations []*Reservation, now time.Time) error {
+	for _, res := range reservations {
+		if ReservationStatus(res.Status) == StatusActive && !res.ExpiresAt.After(now) {
+			return fmt.Errorf("%w: product %d variant %d", errs.ErrReservationExpired, res.ProductID, res.VariantID)
+		}
+	}
+	return nil
+}
+
+// ReleaseOrder gives back the active reservations of the order. It returns the
+// variants that were only held, stock of the other order lines was already
+// deducted and has to be restored by the caller.
+func (s *InventoryServiceConfig) ReleaseOrder(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]bool, error) {
+	reservations, err := s.InventoryRepo.ListByOrderForUpdate(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	released := make(map[int64]bool)
+	for _, res := range reservations {
+		if ReservationStatus(res.Status) != StatusActive {
+			continue
+		}
+
+		if err = s.InventoryRepo.UpdateStatus(ctx, tx, res.ID, StatusReleased); err != nil {
+			return nil, fmt.Errorf("update reservation failed: %w", err)
+		}
+		released[res.VariantID] = true
+	}
+
+	return released, nil
+}
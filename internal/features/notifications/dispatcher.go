@@ -0,0 +1,31 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// dispatchBatchSize is how many notifications one dispatcher run sends at most.
+const dispatchBatchSize = 100
+
+// RunDispatcher sends pending notifications every interval until ctx is done.
+func RunDispatcher(ctx context.Context, srv INotificationService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := srv.DispatchPending(ctx, dispatchBatchSize)
+			if err != nil {
+				log.Printf("notification dispatcher: %v", err)
+			}
+			if n > 0 {
+				log.Printf("notification dispatcher: %d notifications sent", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package notifications
+
+type NotificationStatus string
+
+const (
+	// StatusPending waits for the dispatcher.
+	StatusPending NotificationStatus = "pending"
+	// StatusSent was handed to the sender.
+	StatusSent NotificationStatus = "sent"
+	// StatusFailed gave up after maxAttempts.
+	StatusFailed NotificationStatus = "failed"
+)
+
+const ChannelEmail = "email"
+
+// NotifyRequest queues a message to a user, the recipient is the user email.
+type NotifyRequest struct {
+	UserID  string
+	Subject string
+	Body    string
+	RefType string
+	RefID   int64
+}
+
+// Message is what a Sender delivers.
+type Message struct {
+	Channel   string
+	Recipient string
+	Subject   string
+	Body      string
+}
@@ -0,0 +1,19 @@
+package notifications
+
+import "time"
+
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    *string    `json:"user_id,omitempty"`
+	Channel   string     `json:"channel"`
+	Recipient string     `json:"recipient"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError *string    `json:"last_error,omitempty"`
+	RefType   *string    `json:"ref_type,omitempty"`
+	RefID     *int64     `json:"ref_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
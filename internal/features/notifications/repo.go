@@ -0,0 +1,142 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const (
+	selectNotificationQuery = `
+		SELECT id, user_id, channel, recipient, subject, body, status, attempts, last_error,
+			ref_type, ref_id, created_at, sent_at
+		FROM notifications
+	`
+)
+
+type INotificationRepository interface {
+	CreateForUser(ctx context.Context, exec database.DBExec, input *Notification) error
+	ListPending(ctx context.Context, limit int) ([]*Notification, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailedAttempt(ctx context.Context, id int64, reason string, maxAttempts int) error
+}
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) INotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// CreateForUser queues the notification to the email of input.UserID.
+func (r *notificationRepository) CreateForUser(ctx context.Context, exec database.DBExec, input *Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, channel, recipient, subject, body, ref_type, ref_id)
+		SELECT u.id, $2, u.email, $3, $4, $5, $6
+		FROM users u
+		WHERE u.id = $1
+		RETURNING id, recipient, status, attempts, created_at
+	`
+	err := exec.QueryRowContext(
+		ctx,
+		query,
+		input.UserID,
+		input.Channel,
+		input.Subject,
+		input.Body,
+		input.RefType,
+		input.RefID,
+	).Scan(
+		&input.ID,
+		&input.Recipient,
+		&input.Status,
+		&input.Attempts,
+		&input.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrUserNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) ListPending(ctx context.Context, limit int) ([]*Notification, error) {
+	query := selectNotificationQuery + " WHERE status = 'pending' ORDER BY created_at, id LIMIT $1"
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*Notification
+	for rows.Next() {
+		n := new(Notification)
+		err = rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Channel,
+			&n.Recipient,
+			&n.Subject,
+			&n.Body,
+			&n.Status,
+			&n.Attempts,
+			&n.LastError,
+			&n.RefType,
+			&n.RefID,
+			&n.CreatedAt,
+			&n.SentAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (r *notificationRepository) MarkSent(ctx context.Context, id int64) error {
+	query := `
+		UPDATE notifications SET status = 'sent', attempts = attempts + 1, sent_at = now()
+		WHERE id = $1
+	`
+	return r.execAffected(ctx, query, id)
+}
+
+// MarkFailedAttempt records a failed delivery, the notification is failed
+// once it reached maxAttempts and stays pending otherwise.
+func (r *notificationRepository) MarkFailedAttempt(ctx context.Context, id int64, reason string, maxAttempts int) error {
+	query := `
+		UPDATE notifications SET
+			attempts = attempts + 1,
+			last_error = $2,
+			status = CASE WHEN attempts + 1 >= $3 THEN 'failed'::notification_status ELSE status END
+		WHERE id = $1
+	`
+	return r.execAffected(ctx, query, id, reason, maxAttempts)
+}
+
+func (r *notificationRepository) execAffected(ctx context.Context, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrNotificationNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+const LogSenderName = "log"
+
+// Sender delivers messages, it is implemented by every delivery backend.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender returns the sender registered under name.
+func NewSender(name string) (Sender, error) {
+	switch name {
+	case LogSenderName:
+		return NewLogSender(), nil
+	}
+	return nil, fmt.Errorf("unknown notification sender %q", name)
+}
+
+// LogSender writes messages to the application log, for local development.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Name() string {
+	return LogSenderName
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("notification [%s] to=%s subject=%q body=%q", msg.Channel, msg.Recipient, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,96 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+)
+
+// maxAttempts is how many times the dispatcher tries to deliver a notification.
+const maxAttempts = 5
+
+// INotificationService queues notifications and delivers them in the
+// background, so a slow or failing sender never blocks the request that
+// triggered the notification.
+type INotificationService interface {
+	NotifyUser(ctx context.Context, exec database.DBExec, req *NotifyRequest) (*Notification, error)
+	DispatchPending(ctx context.Context, limit int) (int, error)
+}
+
+type NotificationServiceConfig struct {
+	NotificationRepo INotificationRepository `validate:"required"`
+	Sender           Sender                  `validate:"required"`
+	DB               database.DBExec         `validate:"required"`
+}
+
+func NewNotificationService(cfg *NotificationServiceConfig) (INotificationService, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("NotificationServiceConfig required all fields: %w", err)
+	}
+	return cfg, nil
+}
+
+// NotifyUser queues a message to the user. exec may be a transaction so the
+// notification is only sent when the change it is about is committed, nil
+// uses the database directly.
+func (s *NotificationServiceConfig) NotifyUser(ctx context.Context, exec database.DBExec, req *NotifyRequest) (*Notification, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if exec == nil {
+		exec = s.DB
+	}
+
+	n := &Notification{
+		UserID:  &req.UserID,
+		Channel: ChannelEmail,
+		Subject: req.Subject,
+		Body:    req.Body,
+	}
+	if req.RefType != "" {
+		n.RefType = &req.RefType
+		n.RefID = &req.RefID
+	}
+
+	if err := s.NotificationRepo.CreateForUser(ctx, exec, n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// DispatchPending sends up to limit pending notifications and returns how
+// many were delivered. Failed deliveries are retried on the next run.
+func (s *NotificationServiceConfig) DispatchPending(ctx context.Context, limit int) (int, error) {
+	pending, err := s.NotificationRepo.ListPending(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, n := range pending {
+		msg := &Message{
+			Channel:   n.Channel,
+			Recipient: n.Recipient,
+			Subject:   n.Subject,
+			Body:      n.Body,
+		}
+
+		if err = s.Sender.Send(ctx, msg); err != nil {
+			if err = s.NotificationRepo.MarkFailedAttempt(ctx, n.ID, err.Error(), maxAttempts); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err = s.NotificationRepo.MarkSent(ctx, n.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
@@ -1,5 +1,12 @@
 package orders
 
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
 type OrderStatus string
 
 const (
@@ -10,17 +17,94 @@ const (
 	StatusCancelled OrderStatus = "cancelled"
 )
 
+// orderStatusTransitions lists the statuses an order may move to from each status.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	StatusPending: {StatusPaid, StatusCancelled},
+	StatusPaid:    {StatusShipped, StatusCancelled},
+	StatusShipped: {StatusComplated},
+}
+
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusPaid, StatusShipped, StatusComplated, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type OrdersResponse struct {
-	OrderID    int64   `json:"order_id"`
-	Email      string  `json:"email"`
-	FullName   string  `json:"full_name"`
-	TotalPrice float64 `json:"total_price"`
-	Phone      string  `json:"phone"`
-	City       string  `json:"city"`
-	State      string  `json:"state"`
-	Status     string  `json:"status"`
-	CreatedAt  string  `json:"created_at"`
-	UpdatedAt  string  `json:"updated_at"`
+	OrderID    int64       `json:"order_id"`
+	Email      string      `json:"email"`
+	FullName   string      `json:"full_name"`
+	TotalPrice money.Money `json:"total_price"`
+	Phone      string      `json:"phone"`
+	City       string      `json:"city"`
+	State      string      `json:"state"`
+	Status     string      `json:"status"`
+	CreatedAt  string      `json:"created_at"`
+	UpdatedAt  string      `json:"updated_at"`
+}
+
+type OrderListResponse struct {
+	Orders []*OrdersResponse `json:"orders"`
+	Page   *cursor.Page      `json:"-"`
+}
+
+type OrderDetailResponse struct {
+	ID                 int64                `json:"id"`
+	UserID             string               `json:"user_id"`
+	Status             string               `json:"status"`
+	Currency           string               `json:"currency"`
+	CouponCode         *string              `json:"coupon_code,omitempty"`
+	DiscountAmount     money.Money          `json:"discount_amount"`
+	TaxTotal           money.Money          `json:"tax_total"`
+	ShippingMethodID   *int64               `json:"shipping_method_id,omitempty"`
+	ShippingMethodName *string              `json:"shipping_method_name,omitempty"`
+	ShippingFee        money.Money          `json:"shipping_fee"`
+	TotalPrice         money.Money          `json:"total_price"`
+	CancelledBy        *string              `json:"cancelled_by,omitempty"`
+	CancelReason       *string              `json:"cancel_reason,omitempty"`
+	CancelledAt        *time.Time           `json:"cancelled_at,omitempty"`
+	Items              []*OrderItemResponse `json:"items"`
+	Address            *OrderAddress        `json:"address"`
+	Totals             *OrderTotals         `json:"totals"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+}
+
+type OrderItemResponse struct {
+	ID           int64       `json:"id"`
+	ProductID    int64       `json:"product_id"`
+	ProductName  string      `json:"product_name"`
+	VariantID    int64       `json:"variant_id"`
+	SKU          *string     `json:"sku,omitempty"`
+	WarehouseID  *int64      `json:"warehouse_id,omitempty"`
+	Quantity     int         `json:"quantity"`
+	Price        money.Money `json:"price"`
+	SubTotal     money.Money `json:"sub_total"`
+	TaxName      *string     `json:"tax_name,omitempty"`
+	TaxRate      float64     `json:"tax_rate"`
+	TaxAmount    money.Money `json:"tax_amount"`
+	TaxInclusive bool        `json:"tax_inclusive"`
+	ExchangeRate float64     `json:"exchange_rate"`
+}
+
+type OrderTotals struct {
+	ItemCount int         `json:"item_count"`
+	SubTotal  money.Money `json:"sub_total"`
+	Discount  money.Money `json:"discount"`
+	Tax       money.Money `json:"tax"`
+	Shipping  money.Money `json:"shipping"`
+	Total     money.Money `json:"total"`
 }
 
 type OrderFilter struct {
@@ -28,17 +112,39 @@ type OrderFilter struct {
 	UserID *string
 	Limit  *int
 	Offset *int
+	Cursor *string
 }
 
 type OrderRequest struct {
-	AddressID string `json:"address_id"`
+	AddressID        string `json:"address_id"`
+	CouponCode       string `json:"coupon_code,omitempty"`
+	Currency         string `json:"currency,omitempty" validate:"omitempty,len=3,alpha"`
+	ShippingMethodID int64  `json:"shipping_method_id" validate:"required"`
+}
+
+type OrderCancelRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// OrderActor is the user performing an action on an order.
+type OrderActor struct {
+	UserID  string
+	IsStaff bool
+}
+
+// ID returns the user ID, empty for system actions.
+func (a *OrderActor) ID() string {
+	if a == nil {
+		return ""
+	}
+	return a.UserID
 }
 
 type OrderItemRequest struct {
-	OrderID   int64   `json:"order_id"`
-	ProductID int64   `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	OrderID   int64       `json:"order_id"`
+	ProductID int64       `json:"product_id"`
+	Quantity  int         `json:"quantity"`
+	Price     money.Money `json:"price"`
 }
 
 type OrderAddressRequest struct {
@@ -1,15 +1,19 @@
 package orders
 
 import (
+	"errors"
 	"log"
 
 	"github.com/codepnw/core-ecommerce-system/internal/middleware"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
 	"github.com/gofiber/fiber/v2"
 )
 
+const orderIDKey = "order_id"
+
 type orderHandler struct {
 	srv IOrderService
 }
@@ -33,13 +37,54 @@ func (h *orderHandler) CreateOrder(ctx *fiber.Ctx) error {
 		return response.BadRequest(ctx, err.Error())
 	}
 
-	if err := h.srv.CreateOrder(ctx.Context(), user.UserID, req.AddressID); err != nil {
+	if err := h.srv.CreateOrder(ctx.Context(), user.UserID, req); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrCouponNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrCouponInactive),
+			errors.Is(err, errs.ErrCouponUsageLimit),
+			errors.Is(err, errs.ErrCouponMinOrderValue),
+			errors.Is(err, errs.ErrCouponNotApplicable),
+			errors.Is(err, errs.ErrExchangeRateNotFound),
+			errors.Is(err, errs.ErrShippingMethodNotFound),
+			errors.Is(err, errs.ErrShippingMethodUnavailable),
+			errors.Is(err, errs.ErrVariantNotFound),
+			errors.Is(err, errs.ErrVariantRequired):
+			return response.BadRequest(ctx, err.Error())
+		case errors.Is(err, errs.ErrProductOutOfStock):
+			return response.Conflict(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
 	return response.Success(ctx, "order created", nil)
 }
 
+func (h *orderHandler) GetOrder(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.GetOrder(ctx.Context(), id, newOrderActor(user))
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrOrderNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrOrderForbidden):
+			return response.Forbidden(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
 func (h *orderHandler) ListOrders(ctx *fiber.Ctx) error {
 	filter := new(OrderFilter)
 
@@ -59,27 +104,116 @@ func (h *orderHandler) ListOrders(ctx *fiber.Ctx) error {
 		filter.Offset = &offset
 	}
 
+	if c := ctx.Query("cursor"); c != "" {
+		filter.Cursor = &c
+	}
+
 	log.Printf("%+v\n", filter)
 
 	res, err := h.srv.ListOrders(ctx.Context(), filter)
 	if err != nil {
+		if errors.Is(err, errs.ErrInvalidCursor) {
+			return response.BadRequest(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
-	return response.Success(ctx, "", res)
+	return response.Paginated(ctx, "", res.Orders, res.Page)
 }
 
 func (h *orderHandler) UpdateOrderStatus(ctx *fiber.Ctx) error {
-	status := ctx.Query("status")
-	id, err := commons.GetParamIDInt(ctx, "order_id")
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, orderIDKey)
 	if err != nil {
 		return response.BadRequest(ctx, err.Error())
 	}
 
-	s := OrderStatus(status)
-	if err = h.srv.UpdateOrderStatus(ctx.Context(), id, s); err != nil {
+	status := OrderStatus(ctx.Query("status"))
+	reason := ctx.Query("reason")
+
+	if err = h.srv.UpdateOrderStatus(ctx.Context(), id, status, newOrderActor(user), reason); err != nil {
+		var transErr *errs.InvalidTransitionError
+		switch {
+		case errors.Is(err, errs.ErrInvalidOrderStatus):
+			return response.BadRequest(ctx, err.Error())
+		case errors.Is(err, errs.ErrOrderNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrOrderCannotCancel), errors.As(err, &transErr):
+			return response.Conflict(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
 	return response.Success(ctx, "order status updated", nil)
 }
+
+func (h *orderHandler) GetStatusHistory(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.GetStatusHistory(ctx.Context(), id, newOrderActor(user))
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrOrderNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrOrderForbidden):
+			return response.Forbidden(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *orderHandler) CancelOrder(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(OrderCancelRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err = h.srv.CancelOrder(ctx.Context(), id, newOrderActor(user), req.Reason); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrOrderNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrOrderForbidden):
+			return response.Forbidden(ctx, err.Error())
+		case errors.Is(err, errs.ErrOrderCannotCancel):
+			return response.Conflict(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "order cancelled", nil)
+}
+
+func newOrderActor(user *middleware.UserContext) *OrderActor {
+	return &OrderActor{
+		UserID:  user.UserID,
+		IsStaff: user.Role == middleware.RoleAdmin || user.Role == middleware.RoleStaff,
+	}
+}
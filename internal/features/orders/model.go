@@ -1,26 +1,48 @@
 package orders
 
-import "time"
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
 
 type Order struct {
-	ID         int64     `json:"id"`
-	UserID     string    `json:"user_id"`
-	AddressID  string    `json:"address_id"`
-	TotalPrice int64     `json:"total_price"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                 int64       `json:"id"`
+	UserID             string      `json:"user_id"`
+	AddressID          string      `json:"address_id"`
+	Currency           string      `json:"currency"`
+	TotalPrice         money.Money `json:"total_price"`
+	CouponCode         *string     `json:"coupon_code,omitempty"`
+	DiscountAmount     money.Money `json:"discount_amount"`
+	TaxTotal           money.Money `json:"tax_total"`
+	ShippingMethodID   *int64      `json:"shipping_method_id,omitempty"`
+	ShippingMethodName *string     `json:"shipping_method_name,omitempty"`
+	ShippingFee        money.Money `json:"shipping_fee"`
+	Status             string      `json:"status"`
+	CancelledBy        *string     `json:"cancelled_by,omitempty"`
+	CancelReason       *string     `json:"cancel_reason,omitempty"`
+	CancelledAt        *time.Time  `json:"cancelled_at,omitempty"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
 }
 
 type OrderItem struct {
-	ID        int64     `json:"id"`
-	OrderID   int64     `json:"order_id"`
-	ProductID int64     `json:"product_id"`
-	Quantity  int       `json:"quantity"`
-	Price     int64     `json:"price"`
-	SubTotal  int64     `json:"sub_total"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int64       `json:"id"`
+	OrderID      int64       `json:"order_id"`
+	ProductID    int64       `json:"product_id"`
+	VariantID    int64       `json:"variant_id"`
+	SKU          string      `json:"sku"`                    // SKU the line was sold as
+	WarehouseID  *int64      `json:"warehouse_id,omitempty"` // warehouse fulfilling the line
+	Quantity     int         `json:"quantity"`
+	Price        money.Money `json:"price"`
+	SubTotal     money.Money `json:"sub_total"`
+	TaxName      *string     `json:"tax_name,omitempty"`
+	TaxRate      float64     `json:"tax_rate"`
+	TaxAmount    money.Money `json:"tax_amount"`
+	TaxInclusive bool        `json:"tax_inclusive"`
+	ExchangeRate float64     `json:"exchange_rate"` // product currency to order currency
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
 }
 
 type OrderAddress struct {
@@ -33,3 +55,13 @@ type OrderAddress struct {
 	PostalCode  string `json:"postal_code"`
 	Phone       string `json:"phone"`
 }
+
+type OrderStatusHistory struct {
+	ID         int64     `json:"id"`
+	OrderID    int64     `json:"order_id"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  *string   `json:"changed_by"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
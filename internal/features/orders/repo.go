@@ -3,23 +3,50 @@ package orders
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+const (
+	selectOrderQuery = `
+		SELECT id, user_id, address_id, currency, total_price, coupon_code, discount_amount, tax_total,
+			shipping_method_id, shipping_method_name, shipping_fee, status, created_at, updated_at
+		FROM orders
+	`
 )
 
 type IOrderRepository interface {
 	// Table orders
 	InsertOrder(ctx context.Context, tx *sql.Tx, input *Order) (int64, error)
-	ListOrders(ctx context.Context, filter *OrderFilter) ([]*OrdersResponse, error)
-	UpdateStatus(ctx context.Context, orderID int64, status string) error
+	GetOrderByID(ctx context.Context, orderID int64) (*Order, error)
+	GetOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (*Order, error)
+	GetOrderDetail(ctx context.Context, orderID int64) (*OrderDetailResponse, error)
+	ListOrders(ctx context.Context, filter *OrderFilter) ([]*OrdersResponse, *cursor.Page, error)
+	CountOrders(ctx context.Context, filter *OrderFilter) (int64, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error
+	CancelOrder(ctx context.Context, tx *sql.Tx, input *Order) error
+	HasShipments(ctx context.Context, tx *sql.Tx, orderID int64) (bool, error)
+	ListStalePending(ctx context.Context, createdBefore time.Time) ([]int64, error)
 
 	// Table order_items
 	InsertOrderItems(ctx context.Context, tx *sql.Tx, items []*OrderItem) error
+	GetOrderItems(ctx context.Context, exec database.DBExec, orderID int64) ([]*OrderItem, error)
+	GetOrderItemsDetail(ctx context.Context, orderID int64) ([]*OrderItemResponse, error)
 
 	// Table order_addresses
 	InsertOrderAddress(ctx context.Context, tx *sql.Tx, input *OrderAddress) error
+	GetOrderAddress(ctx context.Context, orderID int64) (*OrderAddress, error)
+
+	// Table order_status_history
+	InsertStatusHistory(ctx context.Context, exec database.DBExec, input *OrderStatusHistory) error
+	ListStatusHistory(ctx context.Context, orderID int64) ([]*OrderStatusHistory, error)
 }
 
 type orderRepository struct {
@@ -34,67 +61,183 @@ func NewOrderRepository(db *sql.DB) IOrderRepository {
 
 func (r *orderRepository) InsertOrder(ctx context.Context, tx *sql.Tx, input *Order) (int64, error) {
 	query := `
-		INSERT INTO orders (user_id, address_id, total_price, status)
-		VALUES ($1, $2, $3, $4) RETURNING id
+		INSERT INTO orders (user_id, address_id, currency, total_price, coupon_code, discount_amount,
+			tax_total, shipping_method_id, shipping_method_name, shipping_fee, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id
 	`
-	err := r.db.QueryRowContext(
+	err := tx.QueryRowContext(
 		ctx,
 		query,
 		input.UserID,
 		input.AddressID,
+		input.Currency,
 		input.TotalPrice,
+		input.CouponCode,
+		input.DiscountAmount,
+		input.TaxTotal,
+		input.ShippingMethodID,
+		input.ShippingMethodName,
+		input.ShippingFee,
 		input.Status,
 	).Scan(&input.ID)
 	return input.ID, err
 }
 
-func (r *orderRepository) ListOrders(ctx context.Context, filter *OrderFilter) ([]*OrdersResponse, error) {
+func (r *orderRepository) GetOrderByID(ctx context.Context, orderID int64) (*Order, error) {
+	query := fmt.Sprintf("%s WHERE id = $1", selectOrderQuery)
+	o := new(Order)
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&o.ID,
+		&o.UserID,
+		&o.AddressID,
+		&o.Currency,
+		&o.TotalPrice,
+		&o.CouponCode,
+		&o.DiscountAmount,
+		&o.TaxTotal,
+		&o.ShippingMethodID,
+		&o.ShippingMethodName,
+		&o.ShippingFee,
+		&o.Status,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	withCurrency(o.Currency, &o.TotalPrice, &o.DiscountAmount, &o.TaxTotal, &o.ShippingFee)
+
+	return o, nil
+}
+
+func (r *orderRepository) GetOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (*Order, error) {
+	query := fmt.Sprintf("%s WHERE id = $1 FOR UPDATE", selectOrderQuery)
+	o := new(Order)
+	err := tx.QueryRowContext(ctx, query, orderID).Scan(
+		&o.ID,
+		&o.UserID,
+		&o.AddressID,
+		&o.Currency,
+		&o.TotalPrice,
+		&o.CouponCode,
+		&o.DiscountAmount,
+		&o.TaxTotal,
+		&o.ShippingMethodID,
+		&o.ShippingMethodName,
+		&o.ShippingFee,
+		&o.Status,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	withCurrency(o.Currency, &o.TotalPrice, &o.DiscountAmount, &o.TaxTotal, &o.ShippingFee)
+
+	return o, nil
+}
+
+func (r *orderRepository) GetOrderDetail(ctx context.Context, orderID int64) (*OrderDetailResponse, error) {
+	query := `
+		SELECT id, user_id, status, currency, coupon_code, discount_amount, tax_total,
+			shipping_method_id, shipping_method_name, shipping_fee, total_price,
+			cancelled_by, cancel_reason, cancelled_at, created_at, updated_at
+		FROM orders
+		WHERE id = $1
+	`
+	o := new(OrderDetailResponse)
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&o.ID,
+		&o.UserID,
+		&o.Status,
+		&o.Currency,
+		&o.CouponCode,
+		&o.DiscountAmount,
+		&o.TaxTotal,
+		&o.ShippingMethodID,
+		&o.ShippingMethodName,
+		&o.ShippingFee,
+		&o.TotalPrice,
+		&o.CancelledBy,
+		&o.CancelReason,
+		&o.CancelledAt,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	withCurrency(o.Currency, &o.TotalPrice, &o.DiscountAmount, &o.TaxTotal, &o.ShippingFee)
+
+	return o, nil
+}
+
+// orderListColumns are the sort columns of ListOrders, newest orders first.
+var orderListColumns = map[string]bool{"created_at": true}
+
+// ListOrders returns a page of orders, after or before filter.Cursor when
+// given, otherwise at filter.Offset.
+func (r *orderRepository) ListOrders(ctx context.Context, filter *OrderFilter) ([]*OrdersResponse, *cursor.Page, error) {
+	keyset := &cursor.Keyset{Listing: "orders", Table: "o", Column: "created_at", Sort: "DESC"}
+	if filter.Cursor != nil {
+		if err := keyset.Continue(*filter.Cursor, orderListColumns); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	var sb strings.Builder
-	var args []any
 
-	sb.WriteString(`
-		SELECT o.id, u.email, u.full_name, o.total_price, a.phone, a.city, a.state, o.status, o.created_at, o.updated_at
+	sb.WriteString(fmt.Sprintf(`
+		SELECT o.id, u.email, u.full_name, o.currency, o.total_price, a.phone, a.city, a.state, o.status, o.created_at, o.updated_at, %s
 		FROM orders o
 		JOIN users u ON u.id = o.user_id
 		JOIN addresses a ON a.id = o.address_id
 		WHERE 1=1
-	`)
+	`, keyset.KeyColumns()))
 
-	if filter.Status != nil {
-		sb.WriteString(fmt.Sprintf(" AND o.status = $%d", len(args)+1))
-		args = append(args, *filter.Status)
-	}
+	where, args := orderListFilter(filter)
+	sb.WriteString(where)
 
-	if filter.UserID != nil {
-		sb.WriteString(fmt.Sprintf(" AND o.user_id = $%d", len(args)+1))
-		args = append(args, *filter.UserID)
+	after, args := keyset.Where(args)
+	if after != "" {
+		sb.WriteString(" AND " + after)
 	}
 
-	sb.WriteString(" ORDER BY o.created_at DESC")
-
-	if filter.Limit != nil {
-		sb.WriteString(fmt.Sprintf(" LIMIT $%d", len(args)+1))
-		args = append(args, *filter.Limit)
+	// Offset mode is kept for older clients, a cursor replaces it
+	offset := 0
+	if filter.Offset != nil && keyset.Cursor == nil {
+		offset = *filter.Offset
 	}
 
-	if filter.Offset != nil {
-		sb.WriteString(fmt.Sprintf(" OFFSET $%d", len(args)+1))
-		args = append(args, *filter.Offset)
-	}
+	// One extra row tells whether there is a next page
+	sb.WriteString(fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", keyset.OrderBy(), len(args)+1, len(args)+2))
+	args = append(args, *filter.Limit+1, offset)
 
 	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var os []*OrdersResponse
+	var keys []cursor.Key
 	for rows.Next() {
 		o := new(OrdersResponse)
+		var key cursor.Key
 		err = rows.Scan(
 			&o.OrderID,
 			&o.Email,
 			&o.FullName,
+			&o.TotalPrice.Currency,
 			&o.TotalPrice,
 			&o.Phone,
 			&o.City,
@@ -102,19 +245,95 @@ func (r *orderRepository) ListOrders(ctx context.Context, filter *OrderFilter) (
 			&o.Status,
 			&o.CreatedAt,
 			&o.UpdatedAt,
+			&key.Value,
+			&key.ID,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		os = append(os, o)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	os, page := cursor.Paginate(keyset, *filter.Limit, offset, os, keys)
+	return os, page, nil
+}
+
+// CountOrders returns how many orders match the filter.
+func (r *orderRepository) CountOrders(ctx context.Context, filter *OrderFilter) (int64, error) {
+	where, args := orderListFilter(filter)
+	query := `
+		SELECT COUNT(*)
+		FROM orders o
+		JOIN users u ON u.id = o.user_id
+		JOIN addresses a ON a.id = o.address_id
+		WHERE 1=1
+	` + where
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, err
 	}
 
-	return os, rows.Err()
+	return total, nil
 }
 
-func (r *orderRepository) UpdateStatus(ctx context.Context, orderID int64, status string) error {
+// orderListFilter returns the conditions of ListOrders over the orders
+// aliased o.
+func orderListFilter(filter *OrderFilter) (string, []any) {
+	var sb strings.Builder
+	var args []any
+
+	if filter.Status != nil {
+		sb.WriteString(fmt.Sprintf(" AND o.status = $%d", len(args)+1))
+		args = append(args, *filter.Status)
+	}
+
+	if filter.UserID != nil {
+		sb.WriteString(fmt.Sprintf(" AND o.user_id = $%d", len(args)+1))
+		args = append(args, *filter.UserID)
+	}
+
+	return sb.String(), args
+}
+
+func (r *orderRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error {
 	query := `UPDATE orders SET status = $1, updated_at = now() WHERE id = $2`
-	res, err := r.db.ExecContext(ctx, query, status, orderID)
+	res, err := tx.ExecContext(ctx, query, status, orderID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrOrderNotFound
+	}
+
+	return nil
+}
+
+func (r *orderRepository) CancelOrder(ctx context.Context, tx *sql.Tx, input *Order) error {
+	query := `
+		UPDATE orders
+		SET status = $1, cancelled_by = $2, cancel_reason = $3, cancelled_at = now(), updated_at = now()
+		WHERE id = $4
+	`
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		StatusCancelled,
+		input.CancelledBy,
+		input.CancelReason,
+		input.ID,
+	)
 	if err != nil {
 		return err
 	}
@@ -131,16 +350,68 @@ func (r *orderRepository) UpdateStatus(ctx context.Context, orderID int64, statu
 	return nil
 }
 
+// HasShipments tells whether any item of the order was shipped.
+func (r *orderRepository) HasShipments(ctx context.Context, tx *sql.Tx, orderID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM shipments WHERE order_id = $1)`
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, query, orderID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// ListStalePending returns pending orders created before createdBefore or
+// holding an expired stock reservation.
+func (r *orderRepository) ListStalePending(ctx context.Context, createdBefore time.Time) ([]int64, error) {
+	query := `
+		SELECT o.id
+		FROM orders o
+		WHERE o.status = 'pending'
+			AND (
+				o.created_at < $1
+				OR EXISTS (
+					SELECT 1 FROM stock_reservations sr
+					WHERE sr.order_id = o.id AND sr.status = 'active' AND sr.expires_at <= now()
+				)
+			)
+		ORDER BY o.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 // ------------ Table order_items ------------
 
 func (r *orderRepository) InsertOrderItems(ctx context.Context, tx *sql.Tx, items []*OrderItem) error {
 	var cols []string
 	var vals []any
-	query := `INSERT INTO order_items (order_id, product_id, quantity, price, sub_total) VALUES `
+	query := `
+		INSERT INTO order_items (order_id, product_id, variant_id, sku, warehouse_id, quantity, price, sub_total,
+			tax_name, tax_rate, tax_amount, tax_inclusive, exchange_rate)
+		VALUES `
 
+	const n = 13
 	for i, item := range items {
-		cols = append(cols, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5))
-		vals = append(vals, item.OrderID, item.ProductID, item.Quantity, item.Price, item.SubTotal)
+		cols = append(cols, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			i*n+1, i*n+2, i*n+3, i*n+4, i*n+5, i*n+6, i*n+7, i*n+8, i*n+9, i*n+10, i*n+11, i*n+12, i*n+13))
+		vals = append(vals, item.OrderID, item.ProductID, item.VariantID, item.SKU, item.WarehouseID, item.Quantity,
+			item.Price, item.SubTotal, item.TaxName, item.TaxRate, item.TaxAmount, item.TaxInclusive, item.ExchangeRate)
 	}
 
 	query += strings.Join(cols, ", ")
@@ -148,6 +419,85 @@ func (r *orderRepository) InsertOrderItems(ctx context.Context, tx *sql.Tx, item
 	return err
 }
 
+func (r *orderRepository) GetOrderItems(ctx context.Context, exec database.DBExec, orderID int64) ([]*OrderItem, error) {
+	query := `
+		SELECT id, order_id, product_id, variant_id, COALESCE(sku, ''), warehouse_id, quantity, created_at, updated_at
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := exec.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*OrderItem
+	for rows.Next() {
+		item := new(OrderItem)
+		err = rows.Scan(
+			&item.ID,
+			&item.OrderID,
+			&item.ProductID,
+			&item.VariantID,
+			&item.SKU,
+			&item.WarehouseID,
+			&item.Quantity,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *orderRepository) GetOrderItemsDetail(ctx context.Context, orderID int64) ([]*OrderItemResponse, error) {
+	query := `
+		SELECT oi.id, oi.product_id, p.name, oi.variant_id, oi.sku, oi.warehouse_id, oi.quantity, oi.price, COALESCE(oi.sub_total, oi.price * oi.quantity),
+			oi.tax_name, oi.tax_rate, oi.tax_amount, oi.tax_inclusive, oi.exchange_rate
+		FROM order_items oi
+		JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = $1
+		ORDER BY oi.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*OrderItemResponse
+	for rows.Next() {
+		item := new(OrderItemResponse)
+		err = rows.Scan(
+			&item.ID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.VariantID,
+			&item.SKU,
+			&item.WarehouseID,
+			&item.Quantity,
+			&item.Price,
+			&item.SubTotal,
+			&item.TaxName,
+			&item.TaxRate,
+			&item.TaxAmount,
+			&item.TaxInclusive,
+			&item.ExchangeRate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
 // ------------ Table order_addresses ------------
 
 func (r *orderRepository) InsertOrderAddress(ctx context.Context, tx *sql.Tx, input *OrderAddress) error {
@@ -155,7 +505,7 @@ func (r *orderRepository) InsertOrderAddress(ctx context.Context, tx *sql.Tx, in
 		INSERT INTO order_addresses (order_id, address_id, address_line, city, state, postal_code, phone)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
-	_, err := r.db.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		query,
 		input.OrderID,
@@ -168,3 +518,95 @@ func (r *orderRepository) InsertOrderAddress(ctx context.Context, tx *sql.Tx, in
 	)
 	return err
 }
+
+func (r *orderRepository) GetOrderAddress(ctx context.Context, orderID int64) (*OrderAddress, error) {
+	query := `
+		SELECT id, order_id, address_id, COALESCE(address_line, ''), COALESCE(city, ''),
+			COALESCE(state, ''), COALESCE(postal_code, ''), COALESCE(phone, '')
+		FROM order_addresses
+		WHERE order_id = $1
+		LIMIT 1
+	`
+	a := new(OrderAddress)
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&a.ID,
+		&a.OrderID,
+		&a.AddressID,
+		&a.AddressLine,
+		&a.City,
+		&a.State,
+		&a.PostalCode,
+		&a.Phone,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrAddressNotFound
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// ------------ Table order_status_history ------------
+
+func (r *orderRepository) InsertStatusHistory(ctx context.Context, exec database.DBExec, input *OrderStatusHistory) error {
+	query := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return exec.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.FromStatus,
+		input.ToStatus,
+		input.ChangedBy,
+		input.Reason,
+	).Scan(
+		&input.ID,
+		&input.CreatedAt,
+	)
+}
+
+func (r *orderRepository) ListStatusHistory(ctx context.Context, orderID int64) ([]*OrderStatusHistory, error) {
+	query := `
+		SELECT id, order_id, from_status, to_status, changed_by, COALESCE(reason, ''), created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*OrderStatusHistory
+	for rows.Next() {
+		h := new(OrderStatusHistory)
+		err = rows.Scan(
+			&h.ID,
+			&h.OrderID,
+			&h.FromStatus,
+			&h.ToStatus,
+			&h.ChangedBy,
+			&h.Reason,
+			&h.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
+
+// withCurrency relabels amounts scanned from NUMERIC columns with the order currency.
+func withCurrency(currency string, amounts ...*money.Money) {
+	for _, m := range amounts {
+		*m = m.WithCurrency(currency)
+	}
+}
@@ -5,27 +5,47 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/codepnw/core-ecommerce-system/internal/database"
 	"github.com/codepnw/core-ecommerce-system/internal/features/addresses"
 	"github.com/codepnw/core-ecommerce-system/internal/features/carts"
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/features/inventory"
 	"github.com/codepnw/core-ecommerce-system/internal/features/products"
+	"github.com/codepnw/core-ecommerce-system/internal/features/promotions"
+	"github.com/codepnw/core-ecommerce-system/internal/features/shipping"
+	"github.com/codepnw/core-ecommerce-system/internal/features/tax"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
 )
 
 type IOrderService interface {
-	CreateOrder(ctx context.Context, userID, addressID string) error
-	ListOrders(ctx context.Context, filter *OrderFilter) ([]*OrdersResponse, error)
-	UpdateOrderStatus(ctx context.Context, id int64, status OrderStatus) error
+	CreateOrder(ctx context.Context, userID string, req *OrderRequest) error
+	GetOrder(ctx context.Context, id int64, actor *OrderActor) (*OrderDetailResponse, error)
+	ListOrders(ctx context.Context, filter *OrderFilter) (*OrderListResponse, error)
+	UpdateOrderStatus(ctx context.Context, id int64, status OrderStatus, actor *OrderActor, reason string) error
+	UpdateOrderStatusTx(ctx context.Context, tx *sql.Tx, id int64, status OrderStatus, actor *OrderActor, reason string) error
+	CheckPayableTx(ctx context.Context, tx *sql.Tx, id int64) error
+	CancelOrder(ctx context.Context, id int64, actor *OrderActor, reason string) error
+	GetStatusHistory(ctx context.Context, id int64, actor *OrderActor) ([]*OrderStatusHistory, error)
+	ExpirePendingOrders(ctx context.Context, olderThan time.Duration) (int, error)
 }
 
 type OrderServiceConfig struct {
-	OrderRepo IOrderRepository          `validate:"required"`
-	CartSrv   carts.ICartService        `validate:"required"`
-	ProdSrv   products.IProductService  `validate:"required"`
-	AddrSrv   addresses.IAddressServide `validate:"required"`
-	Tx        *database.TxManager       `validate:"required"`
+	OrderRepo IOrderRepository                `validate:"required"`
+	CartSrv   carts.ICartService              `validate:"required"`
+	ProdSrv   products.IProductService        `validate:"required"`
+	AddrSrv   addresses.IAddressServide       `validate:"required"`
+	PromoSrv  promotions.IPromotionService    `validate:"required"`
+	RateSrv   currencies.IExchangeRateService `validate:"required"`
+	TaxSrv    tax.ITaxService                 `validate:"required"`
+	ShipSrv   shipping.IShippingService       `validate:"required"`
+	InvSrv    inventory.IInventoryService     `validate:"required"`
+	Tx        *database.TxManager             `validate:"required"`
 }
 
 func NewOrderService(cfg *OrderServiceConfig) (IOrderService, error) {
@@ -35,42 +55,125 @@ func NewOrderService(cfg *OrderServiceConfig) (IOrderService, error) {
 	return cfg, nil
 }
 
-func (s *OrderServiceConfig) CreateOrder(ctx context.Context, userID, addressID string) error {
+func (s *OrderServiceConfig) CreateOrder(ctx context.Context, userID string, req *OrderRequest) error {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	// CART TOTAL PRICE
-	var total int64
-	products, err := s.CartSrv.GetCart(ctx, userID)
+	products, err := s.CartSrv.GetCart(ctx, userID, "")
 	if err != nil {
 		return fmt.Errorf("get cart failed: %w", err)
 	}
 	if len(products) == 0 {
 		return errors.New("cart is empty")
 	}
+
+	// LOCK CURRENCY AND RATES
+	// Prices are converted once here and stored on the order items with the
+	// rate from their product currency, so later rate changes never affect
+	// existing orders.
+	conv := s.RateSrv.NewConverter(req.Currency)
+
+	// CART TOTAL PRICE
+	subtotal := money.New(0, conv.Currency())
+	var weight int64
+	prices := make([]money.Money, 0, len(products))
+	rates := make([]float64, 0, len(products))
+	lines := make([]*promotions.CouponLine, 0, len(products))
 	for _, product := range products {
-		total += int64(product.ProductPrice) * product.ProductQuantity
+		rate, err := conv.Rate(ctx, product.ProductPrice.CurrencyCode())
+		if err != nil {
+			return fmt.Errorf("get exchange rate failed: %w", err)
+		}
+		price := product.ProductPrice.Convert(conv.Currency(), rate)
+		prices = append(prices, price)
+		rates = append(rates, rate)
+		subtotal = subtotal.Add(price.Mul(product.ProductQuantity))
+		weight += int64(product.ProductWeight) * product.ProductQuantity
+
+		lines = append(lines, &promotions.CouponLine{
+			ProductID: product.ProductID,
+			Price:     price,
+			Quantity:  product.ProductQuantity,
+		})
 	}
 
 	// GET USER ADDRESS
-	addr, err := s.AddrSrv.GetAddressByID(ctx, addressID)
+	addr, err := s.AddrSrv.GetAddressByID(ctx, req.AddressID)
 	if err != nil {
 		return fmt.Errorf("get address failed: %w", err)
 	}
 
 	// TRANSACTION
 	err = s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
-		// CREATE ORDER
-		orderID, err := s.OrderRepo.InsertOrder(ctx, tx, &Order{
-			UserID:     userID,
-			AddressID:  addr.ID,
-			TotalPrice: total,
-			Status:     string(StatusPending),
+		// APPLY COUPON
+		var coupon *promotions.CouponResult
+		order := &Order{
+			UserID:         userID,
+			AddressID:      addr.ID,
+			Currency:       conv.Currency(),
+			DiscountAmount: money.New(0, conv.Currency()),
+			Status:         string(StatusPending),
+		}
+		if req.CouponCode != "" {
+			// The discount comes in the order currency
+			c, err := s.PromoSrv.ApplyCouponTx(ctx, tx, req.CouponCode, userID, lines, conv)
+			if err != nil {
+				return err
+			}
+			coupon = c
+			order.CouponCode = &coupon.Code
+			order.DiscountAmount = coupon.Discount
+		}
+
+		// CALCULATE TAX
+		taxLines := make([]*tax.TaxLine, 0, len(products))
+		for i, product := range products {
+			taxLines = append(taxLines, &tax.TaxLine{
+				ProductID: product.ProductID,
+				Amount:    prices[i].Mul(product.ProductQuantity),
+			})
+		}
+		taxes, err := s.TaxSrv.Calculate(ctx, &tax.TaxAddress{
+			State:      addr.State,
+			PostalCode: addr.PostalCode,
+		}, taxLines, order.DiscountAmount)
+		if err != nil {
+			return fmt.Errorf("calculate tax failed: %w", err)
+		}
+		order.TaxTotal = taxes.Total
+
+		// SHIPPING FEE
+		option, err := s.ShipSrv.QuoteMethod(ctx, req.ShippingMethodID, &shipping.ShippingQuote{
+			State:       addr.State,
+			PostalCode:  addr.PostalCode,
+			Subtotal:    subtotal.Sub(order.DiscountAmount),
+			WeightGrams: weight,
 		})
+		if err != nil {
+			return err
+		}
+		order.ShippingMethodID = &option.MethodID
+		order.ShippingMethodName = &option.Name
+		order.ShippingFee = option.Fee
+
+		order.TotalPrice = subtotal.
+			Sub(order.DiscountAmount).
+			Add(taxes.Exclusive).
+			Add(order.ShippingFee)
+
+		// CREATE ORDER
+		orderID, err := s.OrderRepo.InsertOrder(ctx, tx, order)
 		if err != nil {
 			return fmt.Errorf("insert order failed: %w", err)
 		}
 
+		// REDEEM COUPON
+		if coupon != nil {
+			if err = s.PromoSrv.RedeemCouponTx(ctx, tx, coupon, userID, orderID); err != nil {
+				return err
+			}
+		}
+
 		// CREATE ORDER ADDRESS
 		err = s.OrderRepo.InsertOrderAddress(ctx, tx, &OrderAddress{
 			OrderID:     orderID,
@@ -86,22 +189,32 @@ func (s *OrderServiceConfig) CreateOrder(ctx context.Context, userID, addressID
 		}
 
 		// CREATE ORDER ITEMS
+		// Stock is only held here, in the warehouse fulfilling the line, it is
+		// deducted when the order is paid
 		var items []*OrderItem
-		for _, product := range products {
-			ok, err := s.ProdSrv.DeductStock(ctx, tx, product.ProductID, int(product.ProductQuantity))
+		for i, product := range products {
+			warehouseID, ok, err := s.InvSrv.Reserve(ctx, tx, orderID, product.ProductID, product.VariantID, int(product.ProductQuantity))
 			if err != nil {
-				return fmt.Errorf("deduct product stock failed: %w", err)
+				return fmt.Errorf("reserve product stock failed: %w", err)
 			}
 			if !ok {
-				return fmt.Errorf("product %v out of stock", product.ProductName)
+				return fmt.Errorf("%w: %v (%s)", errs.ErrProductOutOfStock, product.ProductName, product.SKU)
 			}
 
 			item := &OrderItem{
-				OrderID:   orderID,
-				ProductID: product.ProductID,
-				Quantity:  int(product.ProductQuantity),
-				Price:     int64(product.ProductPrice),
-				SubTotal:  int64(product.ProductPrice) * product.ProductQuantity,
+				OrderID:      orderID,
+				ProductID:    product.ProductID,
+				VariantID:    product.VariantID,
+				SKU:          product.SKU,
+				WarehouseID:  &warehouseID,
+				Quantity:     int(product.ProductQuantity),
+				Price:        prices[i],
+				SubTotal:     prices[i].Mul(product.ProductQuantity),
+				TaxName:      taxes.Lines[i].Name,
+				TaxRate:      taxes.Lines[i].Rate,
+				TaxAmount:    taxes.Lines[i].Amount,
+				TaxInclusive: taxes.Lines[i].IsInclusive,
+				ExchangeRate: rates[i],
 			}
 			items = append(items, item)
 		}
@@ -115,26 +228,297 @@ func (s *OrderServiceConfig) CreateOrder(ctx context.Context, userID, addressID
 			return fmt.Errorf("clear cart failed: %w", err)
 		}
 
-		return nil
+		return s.recordStatus(ctx, tx, orderID, nil, StatusPending, &OrderActor{UserID: userID}, "order created")
 	})
 	return err
 }
 
-func (s *OrderServiceConfig) ListOrders(ctx context.Context, filter *OrderFilter) ([]*OrdersResponse, error) {
+func (s *OrderServiceConfig) GetOrder(ctx context.Context, id int64, actor *OrderActor) (*OrderDetailResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	order, err := s.OrderRepo.GetOrderDetail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.IsStaff && order.UserID != actor.UserID {
+		return nil, errs.ErrOrderForbidden
+	}
+
+	order.Items, err = s.OrderRepo.GetOrderItemsDetail(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get order_items failed: %w", err)
+	}
+
+	// Address snapshot taken at checkout, not the live address
+	order.Address, err = s.OrderRepo.GetOrderAddress(ctx, id)
+	if err != nil && !errors.Is(err, errs.ErrAddressNotFound) {
+		return nil, fmt.Errorf("get order_address failed: %w", err)
+	}
+
+	totals := &OrderTotals{
+		SubTotal: money.New(0, order.Currency),
+		Discount: order.DiscountAmount,
+		Tax:      order.TaxTotal,
+		Shipping: order.ShippingFee,
+		Total:    order.TotalPrice,
+	}
+	for _, item := range order.Items {
+		withCurrency(order.Currency, &item.Price, &item.SubTotal, &item.TaxAmount)
+		totals.ItemCount += item.Quantity
+		totals.SubTotal = totals.SubTotal.Add(item.SubTotal)
+	}
+	order.Totals = totals
+
+	return order, nil
+}
+
+func (s *OrderServiceConfig) ListOrders(ctx context.Context, filter *OrderFilter) (*OrderListResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	res, err := s.OrderRepo.ListOrders(ctx, filter)
+	if filter.Limit == nil || *filter.Limit <= 0 {
+		limit := 20
+		filter.Limit = &limit
+	}
+
+	res, page, err := s.OrderRepo.ListOrders(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	return res, nil
+	if page.Total, err = s.OrderRepo.CountOrders(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	return &OrderListResponse{Orders: res, Page: page}, nil
 }
 
-func (s *OrderServiceConfig) UpdateOrderStatus(ctx context.Context, id int64, status OrderStatus) error {
+func (s *OrderServiceConfig) UpdateOrderStatus(ctx context.Context, id int64, status OrderStatus, actor *OrderActor, reason string) error {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	return s.OrderRepo.UpdateStatus(ctx, id, string(status))
+	return s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		return s.UpdateOrderStatusTx(ctx, tx, id, status, actor, reason)
+	})
+}
+
+func (s *OrderServiceConfig) UpdateOrderStatusTx(ctx context.Context, tx *sql.Tx, id int64, status OrderStatus, actor *OrderActor, reason string) error {
+	if !status.IsValid() {
+		return errs.ErrInvalidOrderStatus
+	}
+
+	order, err := s.OrderRepo.GetOrderForUpdate(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Cancellation also gives the stock back
+	if status == StatusCancelled {
+		return s.cancelOrderTx(ctx, tx, order, actor, reason)
+	}
+
+	from := OrderStatus(order.Status)
+	if !from.CanTransitionTo(status) {
+		return &errs.InvalidTransitionError{From: string(from), To: string(status)}
+	}
+
+	// Paid orders keep their stock for good
+	if status == StatusPaid {
+		if err = s.InvSrv.ConvertOrder(ctx, tx, order.ID, actor.ID()); err != nil {
+			return fmt.Errorf("convert stock reservations failed: %w", err)
+		}
+	}
+
+	if err = s.OrderRepo.UpdateStatus(ctx, tx, order.ID, string(status)); err != nil {
+		return fmt.Errorf("update order status failed: %w", err)
+	}
+
+	return s.recordStatus(ctx, tx, order.ID, &from, status, actor, reason)
+}
+
+// CheckPayableTx locks the order and its stock reservations until tx ends and
+// checks it can still take a payment. It returns errs.ErrOrderNotPayable when
+// the order is no longer pending and errs.ErrReservationExpired when its stock
+// is not held anymore.
+func (s *OrderServiceConfig) CheckPayableTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	order, err := s.OrderRepo.GetOrderForUpdate(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if OrderStatus(order.Status) != StatusPending {
+		return errs.ErrOrderNotPayable
+	}
+
+	return s.InvSrv.CheckOrder(ctx, tx, order.ID)
+}
+
+func (s *OrderServiceConfig) CancelOrder(ctx context.Context, id int64, actor *OrderActor, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		order, err := s.OrderRepo.GetOrderForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Customers can cancel only their own pending orders
+		if !actor.IsStaff {
+			if order.UserID != actor.UserID {
+				return errs.ErrOrderForbidden
+			}
+			if OrderStatus(order.Status) != StatusPending {
+				return errs.ErrOrderCannotCancel
+			}
+		}
+
+		return s.cancelOrderTx(ctx, tx, order, actor, reason)
+	})
+}
+
+func (s *OrderServiceConfig) GetStatusHistory(ctx context.Context, id int64, actor *OrderActor) ([]*OrderStatusHistory, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	order, err := s.OrderRepo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.IsStaff && order.UserID != actor.UserID {
+		return nil, errs.ErrOrderForbidden
+	}
+
+	return s.OrderRepo.ListStatusHistory(ctx, id)
+}
+
+// ExpirePendingOrders cancels unpaid orders whose stock reservation expired,
+// or that were created more than olderThan ago, and releases their stock.
+// Every order has its own timeout and transaction, one that fails is logged
+// and left for the next run so it does not hold back the others.
+func (s *OrderServiceConfig) ExpirePendingOrders(ctx context.Context, olderThan time.Duration) (int, error) {
+	listCtx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	ids, err := s.OrderRepo.ListStalePending(listCtx, time.Now().Add(-olderThan))
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+
+		cancelled, err := s.expireOrder(ctx, id)
+		if err != nil {
+			log.Printf("expire order %d failed: %v", id, err)
+			continue
+		}
+		if cancelled {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// expireOrder cancels the order if it is still pending, cancelled reports
+// whether it was once the transaction committed.
+func (s *OrderServiceConfig) expireOrder(ctx context.Context, id int64) (cancelled bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Not tied to a user
+	system := &OrderActor{}
+
+	err = s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		order, err := s.OrderRepo.GetOrderForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		// Paid or cancelled since it was listed
+		if OrderStatus(order.Status) != StatusPending {
+			return nil
+		}
+		cancelled = true
+		return s.cancelOrderTx(ctx, tx, order, system, "payment not received in time")
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return cancelled, nil
+}
+
+func (s *OrderServiceConfig) cancelOrderTx(ctx context.Context, tx *sql.Tx, order *Order, actor *OrderActor, reason string) error {
+	from := OrderStatus(order.Status)
+	if !from.CanTransitionTo(StatusCancelled) {
+		return errs.ErrOrderCannotCancel
+	}
+
+	// A partially shipped order is still paid, but units have left the
+	// warehouse. Shipments lock the order first, so none can start meanwhile
+	shipped, err := s.OrderRepo.HasShipments(ctx, tx, order.ID)
+	if err != nil {
+		return fmt.Errorf("check shipments failed: %w", err)
+	}
+	if shipped {
+		return fmt.Errorf("%w: items were already shipped", errs.ErrOrderCannotCancel)
+	}
+
+	// RELEASE RESERVATIONS AND RESTORE PRODUCT STOCK
+	// Lines that were only reserved have nothing to restore
+	released, err := s.InvSrv.ReleaseOrder(ctx, tx, order.ID)
+	if err != nil {
+		return fmt.Errorf("release stock reservations failed: %w", err)
+	}
+
+	items, err := s.OrderRepo.GetOrderItems(ctx, tx, order.ID)
+	if err != nil {
+		return fmt.Errorf("get order_items failed: %w", err)
+	}
+	for _, item := range items {
+		if released[item.VariantID] {
+			continue
+		}
+		change := products.NewStockChange(products.MovementCancellation, item.WarehouseID, actor.ID(), products.RefOrder, order.ID)
+		if err = s.ProdSrv.RestoreStock(ctx, tx, item.VariantID, item.Quantity, change); err != nil {
+			return fmt.Errorf("restore product stock failed: %w", err)
+		}
+	}
+
+	// CANCEL ORDER
+	if actor.UserID != "" {
+		order.CancelledBy = &actor.UserID
+	}
+	order.CancelReason = &reason
+	if err = s.OrderRepo.CancelOrder(ctx, tx, order); err != nil {
+		return fmt.Errorf("cancel order failed: %w", err)
+	}
+
+	return s.recordStatus(ctx, tx, order.ID, &from, StatusCancelled, actor, reason)
+}
+
+func (s *OrderServiceConfig) recordStatus(ctx context.Context, tx *sql.Tx, orderID int64, from *OrderStatus, to OrderStatus, actor *OrderActor, reason string) error {
+	h := &OrderStatusHistory{
+		OrderID:  orderID,
+		ToStatus: string(to),
+		Reason:   reason,
+	}
+	if from != nil {
+		fromStr := string(*from)
+		h.FromStatus = &fromStr
+	}
+	if actor != nil && actor.UserID != "" {
+		h.ChangedBy = &actor.UserID
+	}
+
+	if err := s.OrderRepo.InsertStatusHistory(ctx, tx, h); err != nil {
+		return fmt.Errorf("insert order_status_history failed: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,145 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/inventory"
+	"github.com/codepnw/core-ecommerce-system/internal/features/products"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+// fakeOrderRepo keeps one order with its items, the methods a test does not
+// override panic through the nil interface.
+type fakeOrderRepo struct {
+	IOrderRepository
+
+	order   *Order
+	items   []*OrderItem
+	shipped bool
+	history []*OrderStatusHistory
+}
+
+func (r *fakeOrderRepo) GetOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (*Order, error) {
+	if r.order == nil || r.order.ID != orderID {
+		return nil, errs.ErrOrderNotFound
+	}
+	return r.order, nil
+}
+
+func (r *fakeOrderRepo) HasShipments(ctx context.Context, tx *sql.Tx, orderID int64) (bool, error) {
+	return r.shipped, nil
+}
+
+func (r *fakeOrderRepo) GetOrderItems(ctx context.Context, exec database.DBExec, orderID int64) ([]*OrderItem, error) {
+	return r.items, nil
+}
+
+func (r *fakeOrderRepo) CancelOrder(ctx context.Context, tx *sql.Tx, input *Order) error {
+	input.Status = string(StatusCancelled)
+	return nil
+}
+
+func (r *fakeOrderRepo) InsertStatusHistory(ctx context.Context, exec database.DBExec, input *OrderStatusHistory) error {
+	r.history = append(r.history, input)
+	return nil
+}
+
+// fakeInventory has nothing reserved, every line of a paid order was deducted.
+type fakeInventory struct {
+	inventory.IInventoryService
+
+	released bool
+}
+
+func (s *fakeInventory) ReleaseOrder(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]bool, error) {
+	s.released = true
+	return map[int64]bool{}, nil
+}
+
+// fakeStock records the stock given back per variant.
+type fakeStock struct {
+	products.IProductService
+
+	restored map[int64]int
+}
+
+func (s *fakeStock) RestoreStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *products.StockChange) error {
+	s.restored[variantID] += qty
+	return nil
+}
+
+func TestCancelPaidOrder(t *testing.T) {
+	staff := &OrderActor{UserID: "staff", IsStaff: true}
+
+	tests := []struct {
+		name         string
+		status       OrderStatus
+		shipped      bool
+		wantErr      error
+		wantRestored map[int64]int
+	}{
+		{
+			name:         "paid, nothing shipped",
+			status:       StatusPaid,
+			wantRestored: map[int64]int{10: 2, 11: 1},
+		},
+		{
+			name:         "paid, partially shipped",
+			status:       StatusPaid,
+			shipped:      true,
+			wantErr:      errs.ErrOrderCannotCancel,
+			wantRestored: map[int64]int{},
+		},
+		{
+			name:         "shipped",
+			status:       StatusShipped,
+			shipped:      true,
+			wantErr:      errs.ErrOrderCannotCancel,
+			wantRestored: map[int64]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOrderRepo{
+				order: &Order{ID: 1, Status: string(tt.status)},
+				items: []*OrderItem{
+					{ID: 1, OrderID: 1, VariantID: 10, Quantity: 2},
+					{ID: 2, OrderID: 1, VariantID: 11, Quantity: 1},
+				},
+				shipped: tt.shipped,
+			}
+			inv := &fakeInventory{}
+			stock := &fakeStock{restored: map[int64]int{}}
+			s := &OrderServiceConfig{OrderRepo: repo, InvSrv: inv, ProdSrv: stock}
+
+			err := s.UpdateOrderStatusTx(context.Background(), nil, 1, StatusCancelled, staff, "customer asked")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cancel error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(stock.restored) != len(tt.wantRestored) {
+				t.Fatalf("restored = %v, want %v", stock.restored, tt.wantRestored)
+			}
+			for variant, qty := range tt.wantRestored {
+				if stock.restored[variant] != qty {
+					t.Errorf("restored variant %d = %d, want %d", variant, stock.restored[variant], qty)
+				}
+			}
+
+			if tt.wantErr != nil {
+				if inv.released || len(repo.history) != 0 {
+					t.Errorf("rejected cancellation touched the order: released %v, history %d", inv.released, len(repo.history))
+				}
+				return
+			}
+			if OrderStatus(repo.order.Status) != StatusCancelled || len(repo.history) != 1 {
+				t.Errorf("order status = %s with %d history rows, want cancelled with 1", repo.order.Status, len(repo.history))
+			}
+		})
+	}
+}
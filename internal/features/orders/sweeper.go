@@ -0,0 +1,29 @@
+package orders
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunReservationSweeper cancels unpaid orders once their stock reservation
+// expired, every interval until ctx is done.
+func RunReservationSweeper(ctx context.Context, srv IOrderService, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := srv.ExpirePendingOrders(ctx, ttl)
+			if err != nil {
+				log.Printf("reservation sweeper: %v", err)
+			}
+			if n > 0 {
+				log.Printf("reservation sweeper: %d pending orders expired", n)
+			}
+		}
+	}
+}
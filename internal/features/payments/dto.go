@@ -0,0 +1,36 @@
+package payments
+
+type PaymentStatus string
+
+const (
+	StatusPending  PaymentStatus = "pending"
+	StatusCaptured PaymentStatus = "captured"
+	StatusFailed   PaymentStatus = "failed"
+	StatusRefunded PaymentStatus = "refunded"
+)
+
+// Headers sent by providers with every webhook call.
+const (
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+type PaymentCreate struct {
+	OrderID  int64  `json:"order_id" validate:"required"`
+	Provider string `json:"provider" validate:"required"`
+}
+
+type PaymentCapture struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+}
+
+type PaymentRefund struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type WebhookRequest struct {
+	Provider  string
+	Timestamp string
+	Signature string
+	Payload   []byte
+}
@@ -0,0 +1,78 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const FakeProviderName = "fake"
+
+// Payment methods the fake provider declines, everything else is captured.
+const (
+	FakeMethodDeclined          = "fake_card_declined"
+	FakeMethodInsufficientFunds = "fake_insufficient_funds"
+)
+
+// FakeProvider is a deterministic in-process gateway for development and tests.
+type FakeProvider struct {
+	seq atomic.Int64
+
+	mu      sync.Mutex
+	refunds map[string]*RefundResult
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{refunds: make(map[string]*RefundResult)}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, req *IntentRequest) (*Intent, error) {
+	id := fmt.Sprintf("fake_pi_%d_%d", req.OrderID, p.seq.Add(1))
+	return &Intent{ID: id}, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, req *CaptureRequest) (*CaptureResult, error) {
+	switch req.PaymentMethod {
+	case FakeMethodDeclined:
+		return &CaptureResult{FailureReason: "card declined"}, nil
+	case FakeMethodInsufficientFunds:
+		return &CaptureResult{FailureReason: "insufficient funds"}, nil
+	}
+	return &CaptureResult{Captured: true}, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if res, ok := p.refunds[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return res, nil
+	}
+
+	res := &RefundResult{RefundID: fmt.Sprintf("fake_re_%s_%d", req.IntentID, p.seq.Add(1))}
+	if req.IdempotencyKey != "" {
+		p.refunds[req.IdempotencyKey] = res
+	}
+	return res, nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte) (*WebhookEvent, error) {
+	event := new(WebhookEvent)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrWebhookInvalidPayload, err)
+	}
+
+	if event.ID == "" || event.IntentID == "" {
+		return nil, fmt.Errorf("%w: id and intent_id are required", errs.ErrWebhookInvalidPayload)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,178 @@
+package payments
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	paymentIDKey = "payment_id"
+	orderIDKey   = "order_id"
+)
+
+type paymentHandler struct {
+	srv IPaymentService
+}
+
+func NewPaymentHandler(srv IPaymentService) *paymentHandler {
+	return &paymentHandler{srv: srv}
+}
+
+func (h *paymentHandler) CreatePayment(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	req := new(PaymentCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.CreatePayment(ctx.Context(), req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "payment created", res)
+}
+
+func (h *paymentHandler) CapturePayment(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, paymentIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(PaymentCapture)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.CapturePayment(ctx.Context(), id, req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "payment captured", res)
+}
+
+func (h *paymentHandler) RefundPayment(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, paymentIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(PaymentRefund)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.RefundPayment(ctx.Context(), id, req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "payment refunded", res)
+}
+
+func (h *paymentHandler) ListByOrder(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	orderID, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.ListByOrder(ctx.Context(), orderID, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *paymentHandler) HandleWebhook(ctx *fiber.Ctx) error {
+	provider, err := commons.GetParamIDStr(ctx, "provider")
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := &WebhookRequest{
+		Provider:  provider,
+		Timestamp: ctx.Get(HeaderWebhookTimestamp),
+		Signature: ctx.Get(HeaderWebhookSignature),
+		Payload:   ctx.Body(),
+	}
+
+	if err = h.srv.HandleWebhook(ctx.Context(), req); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrWebhookDuplicate):
+			return response.Success(ctx, err.Error(), nil)
+		case errors.Is(err, errs.ErrWebhookInvalidSignature), errors.Is(err, errs.ErrWebhookInvalidTimestamp):
+			return response.Unauthorized(ctx, err.Error())
+		}
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "webhook processed", nil)
+}
+
+func (h *paymentHandler) handleError(ctx *fiber.Ctx, err error) error {
+	var transErr *errs.InvalidTransitionError
+	switch {
+	case errors.Is(err, errs.ErrPaymentNotFound), errors.Is(err, errs.ErrOrderNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrOrderForbidden):
+		return response.Forbidden(ctx, err.Error())
+	case errors.Is(err, errs.ErrPaymentProviderNotFound),
+		errors.Is(err, errs.ErrPaymentDeclined),
+		errors.Is(err, errs.ErrWebhookInvalidPayload):
+		return response.BadRequest(ctx, err.Error())
+	case errors.Is(err, errs.ErrOrderNotPayable),
+		errors.Is(err, errs.ErrPaymentNotPending),
+		errors.Is(err, errs.ErrPaymentNotCaptured),
+		errors.Is(err, errs.ErrReservationExpired),
+		errors.As(err, &transErr):
+		return response.Conflict(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
+
+func newActor(user *middleware.UserContext) *orders.OrderActor {
+	return &orders.OrderActor{
+		UserID:  user.UserID,
+		IsStaff: user.Role == middleware.RoleAdmin || user.Role == middleware.RoleStaff,
+	}
+}
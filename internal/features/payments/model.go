@@ -0,0 +1,21 @@
+package payments
+
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type Payment struct {
+	ID             int64       `json:"id"`
+	OrderID        int64       `json:"order_id"`
+	Provider       string      `json:"provider"`
+	ProviderRef    string      `json:"provider_ref"`
+	Amount         money.Money `json:"amount"`
+	RefundedAmount money.Money `json:"refunded_amount"`
+	Status         string      `json:"status"`
+	FailureReason  *string     `json:"failure_reason,omitempty"`
+	CapturedAt     *time.Time  `json:"captured_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
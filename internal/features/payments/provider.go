@@ -0,0 +1,85 @@
+package payments
+
+import (
+	"context"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+// PaymentProvider is implemented by every payment gateway the store can charge through.
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(ctx context.Context, req *IntentRequest) (*Intent, error)
+	Capture(ctx context.Context, req *CaptureRequest) (*CaptureResult, error)
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+	ParseWebhook(payload []byte) (*WebhookEvent, error)
+}
+
+type IntentRequest struct {
+	OrderID int64
+	Amount  money.Money
+}
+
+type Intent struct {
+	ID string
+}
+
+type CaptureRequest struct {
+	IntentID      string
+	PaymentMethod string
+}
+
+// CaptureResult reports a declined capture with Captured false and a FailureReason,
+// errors are kept for gateway or network failures.
+type CaptureResult struct {
+	Captured      bool
+	FailureReason string
+}
+
+// RefundRequest with an IdempotencyKey returns the refund made earlier with
+// the same key instead of paying again.
+type RefundRequest struct {
+	IntentID       string
+	Amount         money.Money
+	Reason         string
+	IdempotencyKey string
+}
+
+type RefundResult struct {
+	RefundID string
+}
+
+type WebhookEventType string
+
+const (
+	EventPaymentCaptured WebhookEventType = "payment.captured"
+	EventPaymentFailed   WebhookEventType = "payment.failed"
+	EventPaymentRefunded WebhookEventType = "payment.refunded"
+)
+
+type WebhookEvent struct {
+	ID            string           `json:"id"`
+	Type          WebhookEventType `json:"type"`
+	IntentID      string           `json:"intent_id"`
+	FailureReason string           `json:"failure_reason,omitempty"`
+}
+
+// Providers holds the registered payment providers by name.
+type Providers map[string]PaymentProvider
+
+func NewProviders(providers ...PaymentProvider) Providers {
+	ps := make(Providers, len(providers))
+	for _, p := range providers {
+		ps[p.Name()] = p
+	}
+	return ps
+}
+
+func (ps Providers) Get(name string) (PaymentProvider, error) {
+	p, ok := ps[name]
+	if !ok {
+		return nil, errs.ErrPaymentProviderNotFound
+	}
+	return p, nil
+}
@@ -0,0 +1,249 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+const (
+	selectPaymentQuery = `
+		SELECT id, order_id, provider, provider_ref, currency, amount, refunded_amount, status, failure_reason, captured_at, created_at, updated_at
+		FROM payments
+	`
+)
+
+type IPaymentRepository interface {
+	Create(ctx context.Context, input *Payment) error
+	GetByID(ctx context.Context, id int64) (*Payment, error)
+	ListByOrder(ctx context.Context, orderID int64) ([]*Payment, error)
+	GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Payment, error)
+	GetByProviderRefForUpdate(ctx context.Context, tx *sql.Tx, provider, ref string) (*Payment, error)
+	GetCapturedByOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (*Payment, error)
+	UpdateStatus(ctx context.Context, exec database.DBExec, id int64, status PaymentStatus, failureReason *string) error
+	AddRefund(ctx context.Context, exec database.DBExec, id int64, amount money.Money) error
+
+	// Webhook events
+	InsertWebhookEvent(ctx context.Context, tx *sql.Tx, provider string, event *WebhookEvent, payload []byte) (bool, error)
+}
+
+type paymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) IPaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(ctx context.Context, input *Payment) error {
+	query := `
+		INSERT INTO payments (order_id, provider, provider_ref, currency, amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.Provider,
+		input.ProviderRef,
+		input.Amount.CurrencyCode(),
+		input.Amount,
+		input.Status,
+	).Scan(
+		&input.ID,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*Payment, error) {
+	query := fmt.Sprintf("%s WHERE id = $1", selectPaymentQuery)
+
+	p, err := scanPayment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *paymentRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Payment, error) {
+	query := fmt.Sprintf("%s WHERE id = $1 FOR UPDATE", selectPaymentQuery)
+
+	p, err := scanPayment(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *paymentRepository) GetByProviderRefForUpdate(ctx context.Context, tx *sql.Tx, provider, ref string) (*Payment, error) {
+	query := fmt.Sprintf("%s WHERE provider = $1 AND provider_ref = $2 FOR UPDATE", selectPaymentQuery)
+
+	p, err := scanPayment(tx.QueryRowContext(ctx, query, provider, ref))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetCapturedByOrderForUpdate returns the latest captured payment of the order.
+func (r *paymentRepository) GetCapturedByOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (*Payment, error) {
+	query := fmt.Sprintf(
+		"%s WHERE order_id = $1 AND status = 'captured' ORDER BY captured_at DESC LIMIT 1 FOR UPDATE",
+		selectPaymentQuery,
+	)
+
+	p, err := scanPayment(tx.QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *paymentRepository) ListByOrder(ctx context.Context, orderID int64) ([]*Payment, error) {
+	query := fmt.Sprintf("%s WHERE order_id = $1 ORDER BY created_at DESC", selectPaymentQuery)
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *paymentRepository) UpdateStatus(ctx context.Context, exec database.DBExec, id int64, status PaymentStatus, failureReason *string) error {
+	query := `
+		UPDATE payments
+		SET status = $1::payment_status,
+			failure_reason = $2,
+			captured_at = CASE WHEN $1::payment_status = 'captured' THEN now() ELSE captured_at END,
+			refunded_amount = CASE WHEN $1::payment_status = 'refunded' THEN amount ELSE refunded_amount END,
+			updated_at = now()
+		WHERE id = $3
+	`
+	res, err := exec.ExecContext(ctx, query, status, failureReason, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrPaymentNotFound
+	}
+
+	return nil
+}
+
+// AddRefund adds amount to the refunded total, the payment becomes refunded
+// once the whole captured amount is given back.
+func (r *paymentRepository) AddRefund(ctx context.Context, exec database.DBExec, id int64, amount money.Money) error {
+	query := `
+		UPDATE payments
+		SET refunded_amount = refunded_amount + $1,
+			status = CASE WHEN refunded_amount + $1 >= amount THEN 'refunded'::payment_status ELSE status END,
+			updated_at = now()
+		WHERE id = $2
+	`
+	res, err := exec.ExecContext(ctx, query, amount, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrPaymentNotFound
+	}
+
+	return nil
+}
+
+// ------------ Table payment_webhook_events ------------
+
+// InsertWebhookEvent returns false when the provider event was already stored.
+func (r *paymentRepository) InsertWebhookEvent(ctx context.Context, tx *sql.Tx, provider string, event *WebhookEvent, payload []byte) (bool, error) {
+	query := `
+		INSERT INTO payment_webhook_events (provider, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`
+	res, err := tx.ExecContext(ctx, query, provider, event.ID, event.Type, payload)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	p := new(Payment)
+	err := row.Scan(
+		&p.ID,
+		&p.OrderID,
+		&p.Provider,
+		&p.ProviderRef,
+		&p.Amount.Currency,
+		&p.Amount,
+		&p.RefundedAmount,
+		&p.Status,
+		&p.FailureReason,
+		&p.CapturedAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// refunded_amount is read with the default currency, relabel it
+	p.RefundedAmount = p.RefundedAmount.WithCurrency(p.Amount.CurrencyCode())
+
+	return p, nil
+}
@@ -0,0 +1,412 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/codepnw/core-ecommerce-system/config"
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/security"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+)
+
+type IPaymentService interface {
+	CreatePayment(ctx context.Context, req *PaymentCreate, actor *orders.OrderActor) (*Payment, error)
+	CapturePayment(ctx context.Context, id int64, req *PaymentCapture, actor *orders.OrderActor) (*Payment, error)
+	RefundPayment(ctx context.Context, id int64, req *PaymentRefund, actor *orders.OrderActor) (*Payment, error)
+	ListByOrder(ctx context.Context, orderID int64, actor *orders.OrderActor) ([]*Payment, error)
+	RefundOrderTx(ctx context.Context, tx *sql.Tx, orderID int64, amount money.Money, reason, key string) (*Payment, *RefundResult, error)
+	HandleWebhook(ctx context.Context, req *WebhookRequest) error
+}
+
+type PaymentServiceConfig struct {
+	PaymentRepo IPaymentRepository   `validate:"required"`
+	OrderSrv    orders.IOrderService `validate:"required"`
+	Providers   Providers            `validate:"required,min=1"`
+	Tx          *database.TxManager  `validate:"required"`
+	Webhook     config.PaymentConfig
+}
+
+func NewPaymentService(cfg *PaymentServiceConfig) (IPaymentService, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("PaymentServiceConfig required all fields: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *PaymentServiceConfig) CreatePayment(ctx context.Context, req *PaymentCreate, actor *orders.OrderActor) (*Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	provider, err := s.Providers.Get(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.OrderSrv.GetOrder(ctx, req.OrderID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if orders.OrderStatus(order.Status) != orders.StatusPending {
+		return nil, errs.ErrOrderNotPayable
+	}
+
+	intent, err := provider.CreateIntent(ctx, &IntentRequest{
+		OrderID: order.ID,
+		Amount:  order.TotalPrice,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create payment intent failed: %w", err)
+	}
+
+	payment := &Payment{
+		OrderID:     order.ID,
+		Provider:    provider.Name(),
+		ProviderRef: intent.ID,
+		Amount:      order.TotalPrice,
+		Status:      string(StatusPending),
+	}
+	if err = s.PaymentRepo.Create(ctx, payment); err != nil {
+		return nil, fmt.Errorf("insert payment failed: %w", err)
+	}
+
+	return payment, nil
+}
+
+func (s *PaymentServiceConfig) CapturePayment(ctx context.Context, id int64, req *PaymentCapture, actor *orders.OrderActor) (*Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Checks the payment exists and belongs to the actor
+	if _, err := s.getPayment(ctx, id, actor); err != nil {
+		return nil, err
+	}
+
+	var (
+		payment  *Payment
+		captured bool
+		refunded bool
+	)
+	// The payment and its order stay locked until the capture is recorded, so
+	// the order cannot expire or be cancelled while the provider takes the money
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		payment, err = s.PaymentRepo.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if PaymentStatus(payment.Status) != StatusPending {
+			return errs.ErrPaymentNotPending
+		}
+
+		if err = s.OrderSrv.CheckPayableTx(ctx, tx, payment.OrderID); err != nil {
+			return err
+		}
+
+		provider, err := s.Providers.Get(payment.Provider)
+		if err != nil {
+			return err
+		}
+
+		result, err := provider.Capture(ctx, &CaptureRequest{
+			IntentID:      payment.ProviderRef,
+			PaymentMethod: req.PaymentMethod,
+		})
+		if err != nil {
+			return fmt.Errorf("capture payment failed: %w", err)
+		}
+
+		// Declined payments keep the order pending
+		if !result.Captured {
+			return s.markFailed(ctx, tx, payment, result.FailureReason)
+		}
+		captured = true
+
+		if err = s.PaymentRepo.UpdateStatus(ctx, tx, payment.ID, StatusCaptured, nil); err != nil {
+			return fmt.Errorf("update payment failed: %w", err)
+		}
+
+		refunded, err = s.payOrder(ctx, tx, payment, actor, "payment captured")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !captured:
+		return payment, fmt.Errorf("%w: %s", errs.ErrPaymentDeclined, *payment.FailureReason)
+	case refunded:
+		payment.RefundedAmount = payment.Amount
+		payment.Status = string(StatusRefunded)
+		return payment, fmt.Errorf("%w, payment refunded", errs.ErrOrderNotPayable)
+	}
+
+	payment.Status = string(StatusCaptured)
+	return payment, nil
+}
+
+func (s *PaymentServiceConfig) RefundPayment(ctx context.Context, id int64, req *PaymentRefund, actor *orders.OrderActor) (*Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Checks the payment exists and belongs to the actor
+	if _, err := s.getPayment(ctx, id, actor); err != nil {
+		return nil, err
+	}
+
+	// Locked like in RefundOrderTx, a return refund or another refund of the
+	// same payment waits and then sees what is left
+	var payment *Payment
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		payment, err = s.PaymentRepo.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if PaymentStatus(payment.Status) != StatusCaptured {
+			return errs.ErrPaymentNotCaptured
+		}
+
+		provider, err := s.Providers.Get(payment.Provider)
+		if err != nil {
+			return err
+		}
+
+		// Only what is left after partial refunds (returns) is given back
+		remaining := payment.Amount.Sub(payment.RefundedAmount)
+		if err = s.PaymentRepo.AddRefund(ctx, tx, payment.ID, remaining); err != nil {
+			return fmt.Errorf("update payment failed: %w", err)
+		}
+
+		_, err = provider.Refund(ctx, &RefundRequest{
+			IntentID:       payment.ProviderRef,
+			Amount:         remaining,
+			Reason:         req.Reason,
+			IdempotencyKey: fmt.Sprintf("payment-%d", payment.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("refund payment failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment.RefundedAmount = payment.Amount
+	payment.Status = string(StatusRefunded)
+
+	return payment, nil
+}
+
+func (s *PaymentServiceConfig) ListByOrder(ctx context.Context, orderID int64, actor *orders.OrderActor) ([]*Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Checks the order exists and belongs to the actor
+	if _, err := s.OrderSrv.GetOrder(ctx, orderID, actor); err != nil {
+		return nil, err
+	}
+
+	return s.PaymentRepo.ListByOrder(ctx, orderID)
+}
+
+// RefundOrderTx refunds part of the captured payment of an order within the
+// caller's transaction. It returns errs.ErrPaymentNotFound when the order has
+// no captured payment. The provider is called last with key as idempotency
+// key, so retrying after tx rolled back does not pay the customer twice.
+func (s *PaymentServiceConfig) RefundOrderTx(ctx context.Context, tx *sql.Tx, orderID int64, amount money.Money, reason, key string) (*Payment, *RefundResult, error) {
+	payment, err := s.PaymentRepo.GetCapturedByOrderForUpdate(ctx, tx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if amount.Amount > payment.Amount.Sub(payment.RefundedAmount).Amount {
+		return nil, nil, errs.ErrRefundExceedsPayment
+	}
+
+	provider, err := s.Providers.Get(payment.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = s.PaymentRepo.AddRefund(ctx, tx, payment.ID, amount); err != nil {
+		return nil, nil, fmt.Errorf("update payment failed: %w", err)
+	}
+
+	result, err := provider.Refund(ctx, &RefundRequest{
+		IntentID:       payment.ProviderRef,
+		Amount:         amount,
+		Reason:         reason,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("refund payment failed: %w", err)
+	}
+
+	payment.RefundedAmount = payment.RefundedAmount.Add(amount)
+	if payment.RefundedAmount.Amount >= payment.Amount.Amount {
+		payment.Status = string(StatusRefunded)
+	}
+
+	return payment, result, nil
+}
+
+func (s *PaymentServiceConfig) HandleWebhook(ctx context.Context, req *WebhookRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := security.VerifyWebhook(
+		s.Webhook.WebhookSecret,
+		req.Timestamp,
+		req.Signature,
+		req.Payload,
+		s.Webhook.WebhookTolerance,
+	)
+	if err != nil {
+		return err
+	}
+
+	provider, err := s.Providers.Get(req.Provider)
+	if err != nil {
+		return err
+	}
+
+	event, err := provider.ParseWebhook(req.Payload)
+	if err != nil {
+		return err
+	}
+
+	// The event row is rolled back with the rest when processing fails,
+	// so the provider retry is handled again
+	return s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		inserted, err := s.PaymentRepo.InsertWebhookEvent(ctx, tx, provider.Name(), event, req.Payload)
+		if err != nil {
+			return fmt.Errorf("insert webhook event failed: %w", err)
+		}
+		if !inserted {
+			return errs.ErrWebhookDuplicate
+		}
+
+		payment, err := s.PaymentRepo.GetByProviderRefForUpdate(ctx, tx, provider.Name(), event.IntentID)
+		if err != nil {
+			return err
+		}
+
+		return s.applyEvent(ctx, tx, payment, event)
+	})
+}
+
+func (s *PaymentServiceConfig) applyEvent(ctx context.Context, tx *sql.Tx, payment *Payment, event *WebhookEvent) error {
+	status := PaymentStatus(payment.Status)
+	// Provider callbacks are not tied to a user
+	system := &orders.OrderActor{}
+
+	switch event.Type {
+	case EventPaymentCaptured:
+		if status != StatusPending && status != StatusFailed {
+			return nil
+		}
+		if err := s.PaymentRepo.UpdateStatus(ctx, tx, payment.ID, StatusCaptured, nil); err != nil {
+			return fmt.Errorf("update payment failed: %w", err)
+		}
+		// A late payment is refunded, the event is still processed
+		_, err := s.payOrder(ctx, tx, payment, system, "payment captured by provider")
+		return err
+
+	case EventPaymentFailed:
+		if status != StatusPending {
+			return nil
+		}
+		reason := event.FailureReason
+		if err := s.PaymentRepo.UpdateStatus(ctx, tx, payment.ID, StatusFailed, &reason); err != nil {
+			return fmt.Errorf("update payment failed: %w", err)
+		}
+
+	case EventPaymentRefunded:
+		if status != StatusCaptured {
+			return nil
+		}
+		// Refunded at the provider, what was left after partial refunds
+		remaining := payment.Amount.Sub(payment.RefundedAmount)
+		if err := s.PaymentRepo.AddRefund(ctx, tx, payment.ID, remaining); err != nil {
+			return fmt.Errorf("update payment failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *PaymentServiceConfig) getPayment(ctx context.Context, id int64, actor *orders.OrderActor) (*Payment, error) {
+	payment, err := s.PaymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.OrderSrv.GetOrder(ctx, payment.OrderID, actor); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// payOrder marks the order of a captured payment paid. An order that cannot
+// take the payment anymore gets it refunded in full, refunded then reports it:
+// one cancelled or paid meanwhile, by the sweeper or by staff, and one whose
+// stock reservation expired, which is cancelled here since its stock may be
+// held by another checkout already.
+func (s *PaymentServiceConfig) payOrder(ctx context.Context, tx *sql.Tx, payment *Payment, actor *orders.OrderActor, reason string) (bool, error) {
+	checkErr := s.OrderSrv.CheckPayableTx(ctx, tx, payment.OrderID)
+	switch {
+	case checkErr == nil:
+		return false, s.OrderSrv.UpdateOrderStatusTx(ctx, tx, payment.OrderID, orders.StatusPaid, actor, reason)
+	case errors.Is(checkErr, errs.ErrReservationExpired):
+		err := s.OrderSrv.UpdateOrderStatusTx(ctx, tx, payment.OrderID, orders.StatusCancelled, actor, "stock reservation expired before payment")
+		if err != nil {
+			return false, err
+		}
+	case !errors.Is(checkErr, errs.ErrOrderNotPayable):
+		return false, checkErr
+	}
+
+	provider, err := s.Providers.Get(payment.Provider)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = provider.Refund(ctx, &RefundRequest{
+		IntentID: payment.ProviderRef,
+		Amount:   payment.Amount,
+		Reason:   checkErr.Error(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("refund payment failed: %w", err)
+	}
+
+	if err = s.PaymentRepo.AddRefund(ctx, tx, payment.ID, payment.Amount); err != nil {
+		return false, fmt.Errorf("update payment failed: %w", err)
+	}
+
+	return true, nil
+}
+
+func (s *PaymentServiceConfig) markFailed(ctx context.Context, exec database.DBExec, payment *Payment, reason string) error {
+	err := s.PaymentRepo.UpdateStatus(ctx, exec, payment.ID, StatusFailed, &reason)
+	if err != nil {
+		return fmt.Errorf("update payment failed: %w", err)
+	}
+
+	payment.Status = string(StatusFailed)
+	payment.FailureReason = &reason
+	return nil
+}
@@ -0,0 +1,423 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/config"
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/database/dbtest"
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/security"
+)
+
+const testWebhookSecret = "whsec_test"
+
+type fakePaymentRepo struct {
+	payments map[int64]*Payment
+	events   map[string]bool
+}
+
+func (r *fakePaymentRepo) get(id int64) (*Payment, error) {
+	p, ok := r.payments[id]
+	if !ok {
+		return nil, errs.ErrPaymentNotFound
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (r *fakePaymentRepo) Create(ctx context.Context, input *Payment) error {
+	input.ID = int64(len(r.payments) + 1)
+	cp := *input
+	r.payments[input.ID] = &cp
+	return nil
+}
+
+func (r *fakePaymentRepo) GetByID(ctx context.Context, id int64) (*Payment, error) {
+	return r.get(id)
+}
+
+func (r *fakePaymentRepo) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Payment, error) {
+	return r.get(id)
+}
+
+func (r *fakePaymentRepo) ListByOrder(ctx context.Context, orderID int64) ([]*Payment, error) {
+	var list []*Payment
+	for id, p := range r.payments {
+		if p.OrderID == orderID {
+			cp, _ := r.get(id)
+			list = append(list, cp)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakePaymentRepo) GetByProviderRefForUpdate(ctx context.Context, tx *sql.Tx, provider, ref string) (*Payment, error) {
+	for id, p := range r.payments {
+		if p.Provider == provider && p.ProviderRef == ref {
+			return r.get(id)
+		}
+	}
+	return nil, errs.ErrPaymentNotFound
+}
+
+func (r *fakePaymentRepo) GetCapturedByOrderForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (*Payment, error) {
+	for id, p := range r.payments {
+		if p.OrderID == orderID && PaymentStatus(p.Status) == StatusCaptured {
+			return r.get(id)
+		}
+	}
+	return nil, errs.ErrPaymentNotFound
+}
+
+func (r *fakePaymentRepo) UpdateStatus(ctx context.Context, exec database.DBExec, id int64, status PaymentStatus, failureReason *string) error {
+	p, ok := r.payments[id]
+	if !ok {
+		return errs.ErrPaymentNotFound
+	}
+	p.Status = string(status)
+	p.FailureReason = failureReason
+	if status == StatusRefunded {
+		p.RefundedAmount = p.Amount
+	}
+	return nil
+}
+
+func (r *fakePaymentRepo) AddRefund(ctx context.Context, exec database.DBExec, id int64, amount money.Money) error {
+	p, ok := r.payments[id]
+	if !ok {
+		return errs.ErrPaymentNotFound
+	}
+	p.RefundedAmount = p.RefundedAmount.Add(amount)
+	if p.RefundedAmount.Amount >= p.Amount.Amount {
+		p.Status = string(StatusRefunded)
+	}
+	return nil
+}
+
+func (r *fakePaymentRepo) InsertWebhookEvent(ctx context.Context, tx *sql.Tx, provider string, event *WebhookEvent, payload []byte) (bool, error) {
+	key := provider + "/" + event.ID
+	if r.events[key] {
+		return false, nil
+	}
+	r.events[key] = true
+	return true, nil
+}
+
+// fakeOrderService holds one order, expired tells its stock reservations
+// expired without the sweeper cancelling it yet.
+type fakeOrderService struct {
+	orders.IOrderService
+
+	status  orders.OrderStatus
+	expired bool
+}
+
+func (s *fakeOrderService) GetOrder(ctx context.Context, id int64, actor *orders.OrderActor) (*orders.OrderDetailResponse, error) {
+	return &orders.OrderDetailResponse{ID: id, Status: string(s.status)}, nil
+}
+
+func (s *fakeOrderService) CheckPayableTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	if s.status != orders.StatusPending {
+		return errs.ErrOrderNotPayable
+	}
+	if s.expired {
+		return errs.ErrReservationExpired
+	}
+	return nil
+}
+
+func (s *fakeOrderService) UpdateOrderStatusTx(ctx context.Context, tx *sql.Tx, id int64, status orders.OrderStatus, actor *orders.OrderActor, reason string) error {
+	if status == orders.StatusPaid && s.expired {
+		return errs.ErrReservationExpired
+	}
+	if !s.status.CanTransitionTo(status) {
+		return &errs.InvalidTransitionError{From: string(s.status), To: string(status)}
+	}
+	s.status = status
+	return nil
+}
+
+// countingProvider records the calls made to the fake gateway.
+type countingProvider struct {
+	*FakeProvider
+
+	captures int
+	refunds  []money.Money
+}
+
+func (p *countingProvider) Capture(ctx context.Context, req *CaptureRequest) (*CaptureResult, error) {
+	p.captures++
+	return p.FakeProvider.Capture(ctx, req)
+}
+
+func (p *countingProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	p.refunds = append(p.refunds, req.Amount)
+	return p.FakeProvider.Refund(ctx, req)
+}
+
+type paymentFixture struct {
+	srv      IPaymentService
+	repo     *fakePaymentRepo
+	orders   *fakeOrderService
+	provider *countingProvider
+	payment  *Payment
+}
+
+// newPaymentFixture sets up the service with one payment of the order in
+// the given state.
+func newPaymentFixture(t *testing.T, status PaymentStatus, orderStatus orders.OrderStatus, expired bool) *paymentFixture {
+	t.Helper()
+
+	f := &paymentFixture{
+		repo:     &fakePaymentRepo{payments: map[int64]*Payment{}, events: map[string]bool{}},
+		orders:   &fakeOrderService{status: orderStatus, expired: expired},
+		provider: &countingProvider{FakeProvider: NewFakeProvider()},
+	}
+
+	var err error
+	f.srv, err = NewPaymentService(&PaymentServiceConfig{
+		PaymentRepo: f.repo,
+		OrderSrv:    f.orders,
+		Providers:   NewProviders(f.provider),
+		Tx:          database.NewTxManager(dbtest.Open(t)),
+		Webhook: config.PaymentConfig{
+			WebhookSecret:    testWebhookSecret,
+			WebhookTolerance: time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.payment = &Payment{
+		OrderID:        1,
+		Provider:       FakeProviderName,
+		ProviderRef:    "fake_pi_1",
+		Amount:         money.New(150000, "THB"),
+		RefundedAmount: money.New(0, "THB"),
+		Status:         string(status),
+	}
+	if err = f.repo.Create(context.Background(), f.payment); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func (f *paymentFixture) stored(t *testing.T) *Payment {
+	t.Helper()
+	p, err := f.repo.get(f.payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func (f *paymentFixture) webhook(t *testing.T, id string, typ WebhookEventType) error {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"id":%q,"type":%q,"intent_id":%q}`, id, typ, f.payment.ProviderRef))
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	return f.srv.HandleWebhook(context.Background(), &WebhookRequest{
+		Provider:  FakeProviderName,
+		Timestamp: ts,
+		Signature: security.SignWebhook(testWebhookSecret, ts, payload),
+		Payload:   payload,
+	})
+}
+
+func TestCapturePayment(t *testing.T) {
+	staff := &orders.OrderActor{UserID: "staff", IsStaff: true}
+
+	tests := []struct {
+		name         string
+		orderStatus  orders.OrderStatus
+		expired      bool
+		method       string
+		wantErr      error
+		wantCaptures int
+		wantStatus   PaymentStatus
+		wantOrder    orders.OrderStatus
+	}{
+		{
+			name:         "pending order",
+			orderStatus:  orders.StatusPending,
+			method:       "card",
+			wantCaptures: 1,
+			wantStatus:   StatusCaptured,
+			wantOrder:    orders.StatusPaid,
+		},
+		{
+			name:        "after the sweeper cancelled the order",
+			orderStatus: orders.StatusCancelled,
+			method:      "card",
+			wantErr:     errs.ErrOrderNotPayable,
+			wantStatus:  StatusPending,
+			wantOrder:   orders.StatusCancelled,
+		},
+		{
+			name:        "reservation expired before the sweeper ran",
+			orderStatus: orders.StatusPending,
+			expired:     true,
+			method:      "card",
+			wantErr:     errs.ErrReservationExpired,
+			wantStatus:  StatusPending,
+			wantOrder:   orders.StatusPending,
+		},
+		{
+			name:         "declined",
+			orderStatus:  orders.StatusPending,
+			method:       FakeMethodDeclined,
+			wantErr:      errs.ErrPaymentDeclined,
+			wantCaptures: 1,
+			wantStatus:   StatusFailed,
+			wantOrder:    orders.StatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPaymentFixture(t, StatusPending, tt.orderStatus, tt.expired)
+
+			_, err := f.srv.CapturePayment(context.Background(), f.payment.ID, &PaymentCapture{PaymentMethod: tt.method}, staff)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CapturePayment error = %v, want %v", err, tt.wantErr)
+			}
+
+			if f.provider.captures != tt.wantCaptures {
+				t.Errorf("provider captures = %d, want %d", f.provider.captures, tt.wantCaptures)
+			}
+			if got := PaymentStatus(f.stored(t).Status); got != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", got, tt.wantStatus)
+			}
+			if f.orders.status != tt.wantOrder {
+				t.Errorf("order status = %s, want %s", f.orders.status, tt.wantOrder)
+			}
+			if len(f.provider.refunds) != 0 {
+				t.Errorf("refunds = %v, want none", f.provider.refunds)
+			}
+		})
+	}
+}
+
+func TestWebhookCaptured(t *testing.T) {
+	tests := []struct {
+		name        string
+		orderStatus orders.OrderStatus
+		expired     bool
+		wantStatus  PaymentStatus
+		wantOrder   orders.OrderStatus
+		wantRefund  bool
+	}{
+		{
+			name:        "pending order",
+			orderStatus: orders.StatusPending,
+			wantStatus:  StatusCaptured,
+			wantOrder:   orders.StatusPaid,
+		},
+		{
+			name:        "after the sweeper cancelled the order",
+			orderStatus: orders.StatusCancelled,
+			wantStatus:  StatusRefunded,
+			wantOrder:   orders.StatusCancelled,
+			wantRefund:  true,
+		},
+		{
+			name:        "reservation expired before the sweeper ran",
+			orderStatus: orders.StatusPending,
+			expired:     true,
+			wantStatus:  StatusRefunded,
+			wantOrder:   orders.StatusCancelled,
+			wantRefund:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPaymentFixture(t, StatusPending, tt.orderStatus, tt.expired)
+
+			// The event is acknowledged, a provider retry is a duplicate
+			if err := f.webhook(t, "evt_1", EventPaymentCaptured); err != nil {
+				t.Fatalf("HandleWebhook error = %v", err)
+			}
+			if err := f.webhook(t, "evt_1", EventPaymentCaptured); !errors.Is(err, errs.ErrWebhookDuplicate) {
+				t.Fatalf("HandleWebhook retry error = %v, want ErrWebhookDuplicate", err)
+			}
+
+			p := f.stored(t)
+			if PaymentStatus(p.Status) != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if f.orders.status != tt.wantOrder {
+				t.Errorf("order status = %s, want %s", f.orders.status, tt.wantOrder)
+			}
+
+			if !tt.wantRefund {
+				if len(f.provider.refunds) != 0 {
+					t.Errorf("refunds = %v, want none", f.provider.refunds)
+				}
+				return
+			}
+			if len(f.provider.refunds) != 1 || f.provider.refunds[0] != p.Amount {
+				t.Errorf("refunds = %v, want one of %v", f.provider.refunds, p.Amount)
+			}
+			if p.RefundedAmount != p.Amount {
+				t.Errorf("refunded_amount = %v, want %v", p.RefundedAmount, p.Amount)
+			}
+		})
+	}
+}
+
+func TestRefundPayment(t *testing.T) {
+	staff := &orders.OrderActor{UserID: "staff", IsStaff: true}
+
+	f := newPaymentFixture(t, StatusCaptured, orders.StatusPaid, false)
+	// A return already gave back part of the payment
+	f.repo.payments[f.payment.ID].RefundedAmount = money.New(50000, "THB")
+
+	p, err := f.srv.RefundPayment(context.Background(), f.payment.ID, &PaymentRefund{Reason: "customer asked"}, staff)
+	if err != nil {
+		t.Fatalf("RefundPayment error = %v", err)
+	}
+	if p.RefundedAmount != p.Amount || PaymentStatus(p.Status) != StatusRefunded {
+		t.Errorf("payment = %s refunded %v, want refunded %v", p.Status, p.RefundedAmount, p.Amount)
+	}
+
+	// Only what was left goes back to the customer, and only once
+	if _, err = f.srv.RefundPayment(context.Background(), f.payment.ID, &PaymentRefund{Reason: "again"}, staff); !errors.Is(err, errs.ErrPaymentNotCaptured) {
+		t.Fatalf("second RefundPayment error = %v, want ErrPaymentNotCaptured", err)
+	}
+	if want := money.New(100000, "THB"); len(f.provider.refunds) != 1 || f.provider.refunds[0] != want {
+		t.Errorf("refunds = %v, want one of %v", f.provider.refunds, want)
+	}
+	if stored := f.stored(t); stored.RefundedAmount != stored.Amount {
+		t.Errorf("refunded_amount = %v, want %v", stored.RefundedAmount, stored.Amount)
+	}
+}
+
+func TestWebhookRefunded(t *testing.T) {
+	f := newPaymentFixture(t, StatusCaptured, orders.StatusPaid, false)
+	f.repo.payments[f.payment.ID].RefundedAmount = money.New(50000, "THB")
+
+	if err := f.webhook(t, "evt_1", EventPaymentRefunded); err != nil {
+		t.Fatalf("HandleWebhook error = %v", err)
+	}
+
+	p := f.stored(t)
+	if PaymentStatus(p.Status) != StatusRefunded || p.RefundedAmount != p.Amount {
+		t.Errorf("payment = %s refunded %v, want refunded %v", p.Status, p.RefundedAmount, p.Amount)
+	}
+	// Refunded at the provider, nothing is sent back to it
+	if len(f.provider.refunds) != 0 {
+		t.Errorf("refunds = %v, want none", f.provider.refunds)
+	}
+}
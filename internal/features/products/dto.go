@@ -1,25 +1,95 @@
 package products
 
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type MovementReason string
+
+const (
+	MovementInitial      MovementReason = "initial"
+	MovementAdjustment   MovementReason = "adjustment"
+	MovementOrder        MovementReason = "order"
+	MovementCancellation MovementReason = "cancellation"
+	MovementReturn       MovementReason = "return"
+)
+
+// Reference types of a stock movement.
+const (
+	RefOrder  = "order"
+	RefReturn = "return"
+)
+
+// StockChange describes why a product stock changes, it is written to the
+// inventory_movements ledger together with the change.
+type StockChange struct {
+	Reason      MovementReason
+	WarehouseID *int64 // nil for the default warehouse
+	ActorID     *string
+	RefType     *string
+	RefID       *int64
+	Note        *string
+}
+
+// NewStockChange returns a change in the warehouse made by actorID (empty for
+// the system) about the referenced record.
+func NewStockChange(reason MovementReason, warehouseID *int64, actorID, refType string, refID int64) *StockChange {
+	return &StockChange{
+		Reason:      reason,
+		WarehouseID: warehouseID,
+		ActorID:     actorRef(actorID),
+		RefType:     &refType,
+		RefID:       &refID,
+	}
+}
+
+func actorRef(actorID string) *string {
+	if actorID == "" {
+		return nil
+	}
+	return &actorID
+}
+
 type ProductCreate struct {
-	CategoryID  int64   `json:"category_id" validate:"required"`
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description,omitempty" validate:"omitempty"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Stock       int     `json:"stock,omitempty" validate:"omitempty"`
-	ImageURL    string  `json:"image_url,omitempty" validate:"omitempty"`
+	CategoryID  int64       `json:"category_id" validate:"required"`
+	Name        string      `json:"name" validate:"required"`
+	Slug        string      `json:"slug,omitempty" validate:"omitempty,max=80"`
+	Description string      `json:"description,omitempty" validate:"omitempty"`
+	Price       money.Money `json:"price" validate:"required,gt=0"`
+	Stock       int         `json:"stock,omitempty" validate:"omitempty"`
+	WeightGrams int         `json:"weight_grams,omitempty" validate:"omitempty,gte=0"`
+	ImageURL    string      `json:"image_url,omitempty" validate:"omitempty"`
+
+	LowStockThreshold int `json:"low_stock_threshold,omitempty" validate:"omitempty,gte=0"`
+
+	// SKU of the single variant the product starts with, generated when empty
+	SKU string `json:"sku,omitempty" validate:"omitempty,max=64"`
 }
 
 type ProductUpdate struct {
-	CategoryID  *int64   `json:"category_id,omitempty" validate:"omitempty"`
-	Name        *string  `json:"name,omitempty" validate:"omitempty"`
-	Description *string  `json:"description,omitempty" validate:"omitempty"`
-	Price       *float64 `json:"price,omitempty" validate:"omitempty,gt=0"`
-	Stock       *int     `json:"stock,omitempty" validate:"omitempty"`
-	ImageURL    *string  `json:"image_url,omitempty" validate:"omitempty"`
+	CategoryID  *int64       `json:"category_id,omitempty" validate:"omitempty"`
+	Name        *string      `json:"name,omitempty" validate:"omitempty"`
+	Slug        *string      `json:"slug,omitempty" validate:"omitempty,max=80"`
+	Description *string      `json:"description,omitempty" validate:"omitempty"`
+	Price       *money.Money `json:"price,omitempty" validate:"omitempty,gt=0"`
+	Stock       *int         `json:"stock,omitempty" validate:"omitempty"`
+	WeightGrams *int         `json:"weight_grams,omitempty" validate:"omitempty,gte=0"`
+	ImageURL    *string      `json:"image_url,omitempty" validate:"omitempty"`
+
+	LowStockThreshold *int `json:"low_stock_threshold,omitempty" validate:"omitempty,gte=0"`
 }
 
+// ProductUpdateStock adds Quantity (negative to remove) to the stock of a
+// variant in a warehouse, the default warehouse when WarehouseID is not set.
+// VariantID is only optional for single-variant products.
 type ProductUpdateStock struct {
-	Quantity int `json:"quantity" validate:"required,gt=0"`
+	Quantity    int     `json:"quantity" validate:"required,ne=0"`
+	VariantID   *int64  `json:"variant_id,omitempty"`
+	WarehouseID *int64  `json:"warehouse_id,omitempty"`
+	Note        *string `json:"note,omitempty"`
 }
 
 type ProductFilter struct {
@@ -28,6 +98,26 @@ type ProductFilter struct {
 	Sort       *string `json:"sort,omitempty"`
 	Limit      *int    `json:"limit,omitempty"`
 	Offset     *int    `json:"offset,omitempty"`
+	Cursor     *string `json:"cursor,omitempty"`
+	Currency   *string `json:"currency,omitempty"`
+
+	// Facet filters, prices are in Currency
+	PriceMin   *string            `json:"price_min,omitempty"`
+	PriceMax   *string            `json:"price_max,omitempty"`
+	InStock    bool               `json:"in_stock,omitempty"`
+	Attributes []*AttributeFilter `json:"attributes,omitempty"`
+
+	// IncludeDescendants matches CategoryID and every category below it
+	IncludeDescendants bool `json:"include_descendants,omitempty"`
+}
+
+// AttributeFilter matches products whose attribute has one of Values, or for
+// numeric attributes a value within Min and Max.
+type AttributeFilter struct {
+	Code   string
+	Values []string
+	Min    *float64
+	Max    *float64
 }
 
 // ProductListParams For Repository
@@ -37,9 +127,95 @@ type ProductListParams struct {
 	Sort       *string
 	Limit      int
 	Offset     int
+	Cursor     string
+
+	// PriceMin and PriceMax are in PriceCurrency
+	PriceMin      *string
+	PriceMax      *string
+	PriceCurrency string
+	InStock       bool
+	Attributes    []*AttributeFilter
+
+	IncludeDescendants bool
+}
+
+type ProductSearchFilter struct {
+	Query      string
+	CategoryID int64
+	InStock    bool
+	Limit      int
+	Offset     int
+	Currency   string
+}
+
+// ProductSearchParams For Repository, TSQuery is the prefix query built from
+// the words of Text.
+type ProductSearchParams struct {
+	TSQuery string
+	Text    string
+	Filter  *ProductListParams
+}
+
+// ProductBySlugResponse is a product found by slug, RedirectSlug is its
+// current slug when an old one was asked for.
+type ProductBySlugResponse struct {
+	*Product
+	RedirectSlug string `json:"redirect_slug,omitempty"`
+}
+
+type ProductListResponse struct {
+	Products []*Product     `json:"products"`
+	Facets   *ProductFacets `json:"facets"`
+	Page     *cursor.Page   `json:"-"`
+}
+
+// ProductAttributesSet sets attribute values by attribute code, a null value
+// removes the attribute from the product.
+type ProductAttributesSet struct {
+	Attributes map[string]any `json:"attributes" validate:"required,min=1"`
 }
 
 type ProductCategoryRequest struct {
 	ProductID   int64   `json:"product_id"`
 	CategoryIDs []int64 `json:"category_ids"`
 }
+
+type MovementFilter struct {
+	ProductID   int64
+	VariantID   *int64
+	WarehouseID *int64
+	Reason      *string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+}
+
+type LowStockFilter struct {
+	Limit  int
+	Offset int
+}
+
+type OptionCreate struct {
+	Name     string   `json:"name" validate:"required,max=50"`
+	Values   []string `json:"values" validate:"required,min=1,dive,required,max=50"`
+	Position int      `json:"position"`
+}
+
+// VariantCreate adds a variant with one value for every product option,
+// e.g. {"size": "M", "colour": "Red"}. Stock is added with the stock endpoint.
+type VariantCreate struct {
+	SKU      string            `json:"sku" validate:"required,max=64"`
+	Price    *money.Money      `json:"price,omitempty" validate:"omitempty,gt=0"`
+	ImageURL *string           `json:"image_url,omitempty" validate:"omitempty"`
+	Options  map[string]string `json:"options,omitempty" validate:"omitempty"`
+}
+
+// VariantUpdate sets ClearPrice to go back to the product price.
+type VariantUpdate struct {
+	SKU        *string      `json:"sku,omitempty" validate:"omitempty,max=64"`
+	Price      *money.Money `json:"price,omitempty" validate:"omitempty,gt=0"`
+	ClearPrice bool         `json:"clear_price,omitempty"`
+	ImageURL   *string      `json:"image_url,omitempty" validate:"omitempty"`
+	IsActive   *bool        `json:"is_active,omitempty" validate:"omitempty"`
+}
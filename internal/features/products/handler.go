@@ -2,7 +2,12 @@ package products
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
@@ -12,7 +17,10 @@ import (
 
 const (
 	productIDKey  = "product_id"
+	slugKey       = "slug"
 	categoryIDKey = "category_id"
+	optionIDKey   = "option_id"
+	variantIDKey  = "variant_id"
 )
 
 type productHandler struct {
@@ -24,6 +32,11 @@ func NewProductHandler(srv IProductService) *productHandler {
 }
 
 func (h *productHandler) CreateProduct(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
 	req := new(ProductCreate)
 	if err := ctx.BodyParser(req); err != nil {
 		return response.BadRequest(ctx, err.Error())
@@ -33,10 +46,16 @@ func (h *productHandler) CreateProduct(ctx *fiber.Ctx) error {
 		return response.BadRequest(ctx, err.Error())
 	}
 
-	created, err := h.srv.Create(ctx.Context(), req)
+	created, err := h.srv.Create(ctx.Context(), req, user.UserID)
 	if err != nil {
-		if errors.Is(err, errs.ErrProductNotFound) {
+		switch {
+		case errors.Is(err, errs.ErrProductNotFound):
 			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrVariantSKUExists),
+			errors.Is(err, errs.ErrSlugExists):
+			return response.Conflict(ctx, err.Error())
+		case errors.Is(err, errs.ErrSlugInvalid):
+			return response.BadRequest(ctx, err.Error())
 		}
 		return response.InternalServerError(ctx, err)
 	}
@@ -61,12 +80,30 @@ func (h *productHandler) GetProduct(ctx *fiber.Ctx) error {
 	return response.Success(ctx, "", product)
 }
 
+// GetProductBySlug also resolves the old slugs of a product, redirect_slug
+// then tells the slug to link to instead.
+func (h *productHandler) GetProductBySlug(ctx *fiber.Ctx) error {
+	product, err := h.srv.GetBySlug(ctx.Context(), ctx.Params(slugKey))
+	if err != nil {
+		if errors.Is(err, errs.ErrProductNotFound) {
+			return response.NotFound(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	if product.RedirectSlug != "" {
+		return response.Success(ctx, "slug moved", product)
+	}
+	return response.Success(ctx, "", product)
+}
+
 func (h *productHandler) GetProducts(ctx *fiber.Ctx) error {
 	categoryID := int64(ctx.QueryInt(categoryIDKey))
 	orderBy := ctx.Query("order_by")
 	sort := ctx.Query("sort")
 	limit := ctx.QueryInt("limit")
 	offset := ctx.QueryInt("offset")
+	currency := ctx.Query("currency")
 
 	filter := &ProductFilter{
 		CategoryID: &categoryID,
@@ -74,17 +111,85 @@ func (h *productHandler) GetProducts(ctx *fiber.Ctx) error {
 		Sort:       &sort,
 		Limit:      &limit,
 		Offset:     &offset,
+		Currency:   &currency,
+		InStock:    ctx.QueryBool("in_stock"),
+
+		IncludeDescendants: ctx.QueryBool("include_descendants"),
+	}
+
+	if c := ctx.Query("cursor"); c != "" {
+		filter.Cursor = &c
+	}
+
+	if priceMin := ctx.Query("price_min"); priceMin != "" {
+		filter.PriceMin = &priceMin
+	}
+
+	if priceMax := ctx.Query("price_max"); priceMax != "" {
+		filter.PriceMax = &priceMax
 	}
 
+	attrs, err := parseAttributeFilters(ctx)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+	filter.Attributes = attrs
+
 	products, err := h.srv.List(ctx.Context(), filter)
 	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrExchangeRateNotFound),
+			errors.Is(err, errs.ErrInvalidPriceFilter),
+			errors.Is(err, errs.ErrInvalidCursor):
+			return response.BadRequest(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
-	return response.Success(ctx, "", products)
+	return response.Paginated(ctx, "", products, products.Page)
+}
+
+func (h *productHandler) SearchProducts(ctx *fiber.Ctx) error {
+	filter := &ProductSearchFilter{
+		Query:      ctx.Query("q"),
+		CategoryID: int64(ctx.QueryInt(categoryIDKey)),
+		InStock:    ctx.QueryBool("in_stock"),
+		Limit:      ctx.QueryInt("limit"),
+		Offset:     ctx.QueryInt("offset"),
+		Currency:   ctx.Query("currency"),
+	}
+
+	results, err := h.srv.Search(ctx.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrSearchQueryRequired),
+			errors.Is(err, errs.ErrExchangeRateNotFound):
+			return response.BadRequest(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", results)
+}
+
+func (h *productHandler) SuggestProducts(ctx *fiber.Ctx) error {
+	suggestions, err := h.srv.Suggest(ctx.Context(), ctx.Query("q"), ctx.QueryInt("limit"))
+	if err != nil {
+		if errors.Is(err, errs.ErrSearchQueryRequired) {
+			return response.BadRequest(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", suggestions)
 }
 
 func (h *productHandler) UpdateStock(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
 	id, err := commons.GetParamIDInt(ctx, productIDKey)
 	if err != nil {
 		return response.BadRequest(ctx, err.Error())
@@ -99,8 +204,18 @@ func (h *productHandler) UpdateStock(ctx *fiber.Ctx) error {
 		return response.BadRequest(ctx, err.Error())
 	}
 
-	err = h.srv.UpdateStock(ctx.Context(), id, req.Quantity)
+	err = h.srv.UpdateStock(ctx.Context(), id, req, user.UserID)
 	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrProductNotFound),
+			errors.Is(err, errs.ErrVariantNotFound),
+			errors.Is(err, errs.ErrWarehouseNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrVariantRequired):
+			return response.BadRequest(ctx, err.Error())
+		case errors.Is(err, errs.ErrProductOutOfStock):
+			return response.Conflict(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
@@ -108,6 +223,11 @@ func (h *productHandler) UpdateStock(ctx *fiber.Ctx) error {
 }
 
 func (h *productHandler) UpdateProduct(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
 	id, err := commons.GetParamIDInt(ctx, productIDKey)
 	if err != nil {
 		return response.BadRequest(ctx, err.Error())
@@ -122,13 +242,120 @@ func (h *productHandler) UpdateProduct(ctx *fiber.Ctx) error {
 		return response.BadRequest(ctx, err.Error())
 	}
 
-	if err = h.srv.Update(ctx.Context(), id, req); err != nil {
+	if err = h.srv.Update(ctx.Context(), id, req, user.UserID); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrProductNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrVariantRequired),
+			errors.Is(err, errs.ErrSlugInvalid):
+			return response.BadRequest(ctx, err.Error())
+		case errors.Is(err, errs.ErrSlugExists),
+			errors.Is(err, errs.ErrProductOutOfStock):
+			return response.Conflict(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
 	return response.Success(ctx, "product updated", nil)
 }
 
+func (h *productHandler) ListMovements(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	filter := &MovementFilter{
+		ProductID: id,
+		Limit:     ctx.QueryInt("limit"),
+		Offset:    ctx.QueryInt("offset"),
+	}
+
+	if variantID := ctx.QueryInt(variantIDKey); variantID > 0 {
+		id := int64(variantID)
+		filter.VariantID = &id
+	}
+
+	if warehouseID := ctx.QueryInt("warehouse_id"); warehouseID > 0 {
+		id := int64(warehouseID)
+		filter.WarehouseID = &id
+	}
+
+	if reason := ctx.Query("reason"); reason != "" {
+		filter.Reason = &reason
+	}
+
+	if from := ctx.Query("from"); from != "" {
+		t, _, err := parseDate(from)
+		if err != nil {
+			return response.BadRequest(ctx, err.Error())
+		}
+		filter.From = &t
+	}
+
+	if to := ctx.Query("to"); to != "" {
+		t, dateOnly, err := parseDate(to)
+		if err != nil {
+			return response.BadRequest(ctx, err.Error())
+		}
+		// A plain date includes the whole day
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = &t
+	}
+
+	movements, err := h.srv.ListMovements(ctx.Context(), filter)
+	if err != nil {
+		if errors.Is(err, errs.ErrProductNotFound) {
+			return response.NotFound(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", movements)
+}
+
+func (h *productHandler) ListLowStock(ctx *fiber.Ctx) error {
+	filter := &LowStockFilter{
+		Limit:  ctx.QueryInt("limit"),
+		Offset: ctx.QueryInt("offset"),
+	}
+
+	products, err := h.srv.ListLowStock(ctx.Context(), filter)
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", products)
+}
+
+func (h *productHandler) NotifyMe(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	sub, err := h.srv.Subscribe(ctx.Context(), id, user.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrProductNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrProductInStock),
+			errors.Is(err, errs.ErrStockSubscriptionExists):
+			return response.Conflict(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Created(ctx, "you will be notified when the product is back in stock", sub)
+}
+
 func (h *productHandler) DeleteProduct(ctx *fiber.Ctx) error {
 	id, err := commons.GetParamIDInt(ctx, productIDKey)
 	if err != nil {
@@ -196,3 +423,230 @@ func (h *productHandler) DelCategoryByProduct(ctx *fiber.Ctx) error {
 
 	return response.Success(ctx, "delete category product success", nil)
 }
+
+func (h *productHandler) SetAttributes(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(ProductAttributesSet)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	attrs, err := h.srv.SetAttributes(ctx.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrProductNotFound),
+			errors.Is(err, errs.ErrAttributeNotFound):
+			return response.NotFound(ctx, err.Error())
+		case errors.Is(err, errs.ErrAttributeValueInvalid):
+			return response.BadRequest(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "product attributes updated", attrs)
+}
+
+func (h *productHandler) CreateOption(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(OptionCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	option, err := h.srv.CreateOption(ctx.Context(), id, req)
+	if err != nil {
+		return handleVariantError(ctx, err)
+	}
+
+	return response.Created(ctx, "product option added", option)
+}
+
+func (h *productHandler) DeleteOption(ctx *fiber.Ctx) error {
+	pID, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	oID, err := commons.GetParamIDInt(ctx, optionIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err = h.srv.DeleteOption(ctx.Context(), pID, oID); err != nil {
+		return handleVariantError(ctx, err)
+	}
+
+	return response.Success(ctx, "product option deleted", nil)
+}
+
+func (h *productHandler) ListVariants(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	variants, err := h.srv.ListVariants(ctx.Context(), id)
+	if err != nil {
+		return handleVariantError(ctx, err)
+	}
+
+	return response.Success(ctx, "", variants)
+}
+
+func (h *productHandler) CreateVariant(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(VariantCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	variant, err := h.srv.CreateVariant(ctx.Context(), id, req)
+	if err != nil {
+		return handleVariantError(ctx, err)
+	}
+
+	return response.Created(ctx, "product variant added", variant)
+}
+
+func (h *productHandler) UpdateVariant(ctx *fiber.Ctx) error {
+	pID, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	vID, err := commons.GetParamIDInt(ctx, variantIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(VariantUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err = h.srv.UpdateVariant(ctx.Context(), pID, vID, req); err != nil {
+		return handleVariantError(ctx, err)
+	}
+
+	return response.Success(ctx, "product variant updated", nil)
+}
+
+func (h *productHandler) DeleteVariant(ctx *fiber.Ctx) error {
+	pID, err := commons.GetParamIDInt(ctx, productIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	vID, err := commons.GetParamIDInt(ctx, variantIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err = h.srv.DeleteVariant(ctx.Context(), pID, vID); err != nil {
+		return handleVariantError(ctx, err)
+	}
+
+	return response.Success(ctx, "product variant deleted", nil)
+}
+
+func handleVariantError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrProductNotFound),
+		errors.Is(err, errs.ErrVariantNotFound),
+		errors.Is(err, errs.ErrOptionNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrVariantOptionsInvalid),
+		errors.Is(err, errs.ErrVariantCurrency),
+		errors.Is(err, errs.ErrNoFieldUpdate):
+		return response.BadRequest(ctx, err.Error())
+	case errors.Is(err, errs.ErrVariantSKUExists),
+		errors.Is(err, errs.ErrVariantExists),
+		errors.Is(err, errs.ErrVariantInUse),
+		errors.Is(err, errs.ErrOptionExists),
+		errors.Is(err, errs.ErrOptionInUse):
+		return response.Conflict(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
+
+// parseAttributeFilters reads attr.<code>=v1,v2 for text and boolean
+// attributes and attr.<code>.min= / attr.<code>.max= for numeric ones.
+func parseAttributeFilters(ctx *fiber.Ctx) ([]*AttributeFilter, error) {
+	var filters []*AttributeFilter
+	byCode := make(map[string]*AttributeFilter)
+	var err error
+
+	ctx.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		k, ok := strings.CutPrefix(string(key), "attr.")
+		if !ok || err != nil || len(value) == 0 {
+			return
+		}
+
+		code, bound, _ := strings.Cut(k, ".")
+		f, ok := byCode[code]
+		if !ok {
+			f = &AttributeFilter{Code: code}
+			byCode[code] = f
+			filters = append(filters, f)
+		}
+
+		switch bound {
+		case "":
+			f.Values = append(f.Values, strings.Split(string(value), ",")...)
+		case "min", "max":
+			n, parseErr := strconv.ParseFloat(string(value), 64)
+			if parseErr != nil {
+				err = fmt.Errorf("invalid number %q for %s", value, key)
+				return
+			}
+			if bound == "min" {
+				f.Min = &n
+			} else {
+				f.Max = &n
+			}
+		default:
+			err = fmt.Errorf("unknown attribute filter %s", key)
+		}
+	})
+
+	return filters, err
+}
+
+// parseDate accepts 2006-01-02 or RFC 3339, dateOnly reports the first form.
+func parseDate(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+	if t, err = time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC 3339", s)
+}
@@ -1,15 +1,169 @@
 package products
 
-import "time"
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
 
 type Product struct {
-	ID          int64     `json:"id"`
-	CategoryID  int64     `json:"category_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
-	ImageURL    string    `json:"image_url"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                int64       `json:"id"`
+	CategoryID        int64       `json:"category_id"`
+	Name              string      `json:"name"`
+	Slug              string      `json:"slug"`
+	CustomSlug        bool        `json:"custom_slug"`
+	Description       string      `json:"description"`
+	Price             money.Money `json:"price"`
+	Stock             int         `json:"stock"`
+	Available         int         `json:"available"`           // stock of active warehouses minus unexpired reservations
+	LowStockThreshold int         `json:"low_stock_threshold"` // 0 disables low-stock alerts
+	WeightGrams       int         `json:"weight_grams"`
+	ImageURL          string      `json:"image_url"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+
+	Attributes []*ProductAttribute      `json:"attributes,omitempty"`
+	Options    []*ProductOption         `json:"options,omitempty"`
+	Variants   []*ProductVariant        `json:"variants,omitempty"`
+	Warehouses []*ProductWarehouseStock `json:"warehouses,omitempty"`
+}
+
+// ProductAttribute is the value of an attribute for a product, Value is a
+// string, number or bool following Type.
+type ProductAttribute struct {
+	AttributeID int64   `json:"attribute_id"`
+	Code        string  `json:"code"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Unit        *string `json:"unit,omitempty"`
+	Value       any     `json:"value"`
+}
+
+// ProductSearchResult is a product matching a search, the highlights mark the
+// matched words with <mark></mark>.
+type ProductSearchResult struct {
+	*Product
+	Rank                 float64 `json:"rank"`
+	NameHighlight        string  `json:"name_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
+
+// Suggestions are the autocomplete entries for a typed prefix.
+type Suggestions struct {
+	Products   []*ProductSuggestion  `json:"products"`
+	Categories []*CategorySuggestion `json:"categories"`
+	Queries    []string              `json:"queries"`
+}
+
+type ProductSuggestion struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+}
+
+type CategorySuggestion struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// ProductFacets describes the current result set of a product listing, for
+// building filter sidebars.
+type ProductFacets struct {
+	Attributes []*AttributeFacet `json:"attributes"`
+	Prices     []*PriceFacet     `json:"prices"`
+	InStock    int               `json:"in_stock"`
+}
+
+// AttributeFacet lists the values of a filterable attribute with the number
+// of products having them, numeric attributes also have their range.
+type AttributeFacet struct {
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Unit   *string       `json:"unit,omitempty"`
+	Values []*FacetValue `json:"values"`
+	Min    *float64      `json:"min,omitempty"`
+	Max    *float64      `json:"max,omitempty"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceFacet is the price range of the products in one currency.
+type PriceFacet struct {
+	Currency string      `json:"currency"`
+	Min      money.Money `json:"min"`
+	Max      money.Money `json:"max"`
+	Count    int         `json:"count"`
+}
+
+// ProductOption is a dimension the product is sold in, e.g. size or colour.
+type ProductOption struct {
+	ID        int64                 `json:"id"`
+	ProductID int64                 `json:"product_id"`
+	Name      string                `json:"name"`
+	Position  int                   `json:"position"`
+	Values    []*ProductOptionValue `json:"values"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+type ProductOptionValue struct {
+	ID       int64  `json:"id"`
+	OptionID int64  `json:"option_id"`
+	Value    string `json:"value"`
+	Position int    `json:"position"`
+}
+
+// ProductVariant is the unit that is stocked and sold. A simple product has a
+// single variant without options. Price overrides the product price when set.
+type ProductVariant struct {
+	ID        int64             `json:"id"`
+	ProductID int64             `json:"product_id"`
+	SKU       string            `json:"sku"`
+	Price     *money.Money      `json:"price,omitempty"`
+	Stock     int               `json:"stock"`
+	Available int               `json:"available"`
+	ImageURL  *string           `json:"image_url,omitempty"`
+	IsActive  bool              `json:"is_active"`
+	Options   map[string]string `json:"options"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type ProductWarehouseStock struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Stock       int    `json:"stock"`
+	Available   int    `json:"available"`
+}
+
+// InventoryMovement is one change of a product stock. Balance is the total
+// stock right after the change, WarehouseBalance the stock of the warehouse.
+type InventoryMovement struct {
+	ID               int64     `json:"id"`
+	ProductID        int64     `json:"product_id"`
+	VariantID        *int64    `json:"variant_id,omitempty"`
+	WarehouseID      *int64    `json:"warehouse_id,omitempty"`
+	Delta            int       `json:"delta"`
+	Balance          int       `json:"balance"`
+	WarehouseBalance *int      `json:"warehouse_balance,omitempty"`
+	Reason           string    `json:"reason"`
+	ActorID          *string   `json:"actor_id,omitempty"`
+	RefType          *string   `json:"ref_type,omitempty"`
+	RefID            *int64    `json:"ref_id,omitempty"`
+	Note             *string   `json:"note,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// StockSubscription asks to notify the user once the product is back in stock.
+type StockSubscription struct {
+	ID         int64      `json:"id"`
+	ProductID  int64      `json:"product_id"`
+	UserID     string     `json:"user_id"`
+	Status     string     `json:"status"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
@@ -3,6 +3,7 @@ package products
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -10,30 +11,108 @@ import (
 
 	"github.com/codepnw/core-ecommerce-system/internal/database"
 	"github.com/codepnw/core-ecommerce-system/internal/features/categories"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/lib/pq"
 )
 
 const (
 	selectProductQuery = `
-		SELECT id, category_id, name, description, price, stock, image_url, created_at, updated_at
+		SELECT id, COALESCE(category_id, 0) AS category_id, name, slug, custom_slug, description, currency, price, stock,
+			COALESCE((
+				SELECT SUM(ws.stock) FROM warehouse_stock ws
+				JOIN warehouses w ON w.id = ws.warehouse_id
+				WHERE ws.product_id = products.id AND w.is_active
+			), 0) - COALESCE((
+				SELECT SUM(sr.quantity) FROM stock_reservations sr
+				WHERE sr.product_id = products.id AND sr.status = 'active' AND sr.expires_at > now()
+			), 0) AS available,
+			low_stock_threshold, weight_grams, image_url, created_at, updated_at
 		FROM products
 	`
+
+	selectVariantQuery = `
+		SELECT v.id, v.product_id, v.sku, p.currency, v.price, v.stock,
+			COALESCE((
+				SELECT SUM(ws.stock) FROM warehouse_stock ws
+				JOIN warehouses w ON w.id = ws.warehouse_id
+				WHERE ws.variant_id = v.id AND w.is_active
+			), 0) - COALESCE((
+				SELECT SUM(sr.quantity) FROM stock_reservations sr
+				WHERE sr.variant_id = v.id AND sr.status = 'active' AND sr.expires_at > now()
+			), 0) AS available,
+			v.image_url, v.is_active,
+			COALESCE((
+				SELECT json_object_agg(o.name, ov.value)
+				FROM variant_option_values vov
+				JOIN product_option_values ov ON ov.id = vov.option_value_id
+				JOIN product_options o ON o.id = ov.option_id
+				WHERE vov.variant_id = v.id
+			), '{}') AS options,
+			v.created_at, v.updated_at
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+	`
+
+	// attributeValueExpr is the value of a product attribute as text, as
+	// used in filters and facets.
+	attributeValueExpr = `COALESCE(av.value_text, av.value_boolean::text, av.value_number::text)`
+
+	// defaultWarehouseQuery picks the warehouse used when a stock change
+	// does not name one.
+	defaultWarehouseQuery = `(SELECT id FROM warehouses WHERE is_active ORDER BY priority DESC, id LIMIT 1)`
 )
 
 type IProductRepository interface {
 	// Products
-	Create(ctx context.Context, input *Product) (*Product, error)
+	Create(ctx context.Context, input *Product, sku string, change *StockChange) (*Product, error)
 	GetByID(ctx context.Context, id int64) (*Product, error)
-	List(ctx context.Context, filter *ProductListParams) ([]*Product, error)
-	UpdateStock(ctx context.Context, id int64, stock int) error
-	DeductStock(ctx context.Context, exec database.DBExec, productID int64, qty int) (bool, error)
-	Update(ctx context.Context, id int64, input *ProductUpdate) error
+	GetWarehouseStock(ctx context.Context, productID int64) ([]*ProductWarehouseStock, error)
+	FindSlug(ctx context.Context, slug string) (id int64, current bool, err error)
+	SlugTaken(ctx context.Context, slug string, excludeID int64) (bool, error)
+	LockSlug(ctx context.Context, exec database.DBExec, id int64) (custom bool, err error)
+	SetSlug(ctx context.Context, exec database.DBExec, id int64, slug string, custom bool) error
+	List(ctx context.Context, filter *ProductListParams) ([]*Product, *cursor.Page, error)
+	Count(ctx context.Context, filter *ProductListParams) (int64, error)
+	Search(ctx context.Context, search *ProductSearchParams) ([]*ProductSearchResult, error)
+	Facets(ctx context.Context, filter *ProductListParams) (*ProductFacets, error)
+	Suggest(ctx context.Context, prefix string, limit int) (*Suggestions, error)
+	LogQueries(ctx context.Context, counts map[string]int) error
+	UpdateStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error
+	DeductStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) (bool, error)
+	RestoreStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error
+	Update(ctx context.Context, exec database.DBExec, id int64, input *ProductUpdate) error
 	Delete(ctx context.Context, id int64) error
 
 	// Product Categories
 	AssignCategory(ctx context.Context, productID, categoryID int64) error
 	GetCategoriesByProduct(ctx context.Context, productID int64) ([]*categories.Category, error)
 	DelCategoryByProduct(ctx context.Context, productID, categoryID int64) error
+
+	// Inventory Movements
+	ListMovements(ctx context.Context, filter *MovementFilter) ([]*InventoryMovement, error)
+
+	// Stock Alerts
+	ListLowStock(ctx context.Context, filter *LowStockFilter) ([]*Product, error)
+	CreateSubscription(ctx context.Context, input *StockSubscription) error
+	ClaimSubscriptions(ctx context.Context, tx *sql.Tx, productID int64) ([]*StockSubscription, error)
+
+	// Attributes
+	GetAttributeDefs(ctx context.Context, codes []string) (map[string]*ProductAttribute, error)
+	ListAttributes(ctx context.Context, productID int64) ([]*ProductAttribute, error)
+	SetAttributes(ctx context.Context, productID int64, values []*ProductAttribute, removeIDs []int64) error
+
+	// Options & Variants
+	CreateOption(ctx context.Context, input *ProductOption) error
+	ListOptions(ctx context.Context, productID int64) ([]*ProductOption, error)
+	DeleteOption(ctx context.Context, productID, optionID int64) error
+	CreateVariant(ctx context.Context, input *ProductVariant, optionValueIDs []int64) error
+	ConvertVariant(ctx context.Context, input *ProductVariant, optionValueIDs []int64) error
+	GetVariant(ctx context.Context, id int64) (*ProductVariant, error)
+	ListVariants(ctx context.Context, productID int64, activeOnly bool) ([]*ProductVariant, error)
+	UpdateVariant(ctx context.Context, id int64, input *VariantUpdate) error
+	DeleteVariant(ctx context.Context, id int64) error
 }
 
 type productRepository struct {
@@ -44,27 +123,61 @@ func NewProductRepository(db *sql.DB) IProductRepository {
 	return &productRepository{db: db}
 }
 
-func (r *productRepository) Create(ctx context.Context, input *Product) (*Product, error) {
-	query := `
-		INSERT INTO products (category_id, name, description, price, stock, image_url)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
-	`
+func (r *productRepository) Create(ctx context.Context, input *Product, sku string, change *StockChange) (*Product, error) {
+	// The product starts as a single variant, its opening stock goes to the
+	// warehouse and is the first entry of the product ledger
+	query := fmt.Sprintf(`
+		WITH p AS (
+			INSERT INTO products (category_id, name, description, currency, price, stock, weight_grams, image_url, low_stock_threshold, slug, custom_slug)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $13, $15, $16)
+			RETURNING id, stock, created_at, updated_at
+		), v AS (
+			INSERT INTO product_variants (product_id, sku, stock)
+			SELECT id, COALESCE(NULLIF($14, ''), 'SKU-' || id), stock FROM p
+			RETURNING id, product_id
+		), ws AS (
+			INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, stock)
+			SELECT w.id, p.id, v.id, p.stock FROM p, v, (SELECT COALESCE($12::bigint, %s) AS id) w
+			WHERE w.id IS NOT NULL
+			RETURNING warehouse_id, stock
+		), m AS (
+			INSERT INTO inventory_movements (product_id, variant_id, warehouse_id, delta, balance, warehouse_balance, reason, actor_id, note)
+			SELECT p.id, v.id, ws.warehouse_id, p.stock, p.stock, ws.stock, $9::movement_reason, $10::uuid, $11::text
+			FROM p CROSS JOIN v LEFT JOIN ws ON true
+		)
+		SELECT id, created_at, updated_at FROM p
+	`, defaultWarehouseQuery)
 	err := r.db.QueryRowContext(
 		ctx,
 		query,
 		input.CategoryID,
 		input.Name,
 		input.Description,
+		input.Price.CurrencyCode(),
 		input.Price,
 		input.Stock,
+		input.WeightGrams,
 		input.ImageURL,
+		change.Reason,
+		change.ActorID,
+		change.Note,
+		change.WarehouseID,
+		input.LowStockThreshold,
+		sku,
+		input.Slug,
+		input.CustomSlug,
 	).Scan(
 		&input.ID,
 		&input.CreatedAt,
 		&input.UpdatedAt,
 	)
 	if err != nil {
+		if strings.Contains(err.Error(), "product_variants_sku_key") {
+			return nil, errs.ErrVariantSKUExists
+		}
+		if strings.Contains(err.Error(), "products_slug_key") {
+			return nil, errs.ErrSlugExists
+		}
 		return nil, err
 	}
 
@@ -75,17 +188,7 @@ func (r *productRepository) GetByID(ctx context.Context, id int64) (*Product, er
 	p := new(Product)
 	query := fmt.Sprintf("%s WHERE id = $1", selectProductQuery)
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID,
-		&p.CategoryID,
-		&p.Name,
-		&p.Description,
-		&p.Price,
-		&p.Stock,
-		&p.ImageURL,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-	)
+	err := scanProduct(r.db.QueryRowContext(ctx, query, id), p)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errs.ErrProductNotFound
@@ -96,7 +199,93 @@ func (r *productRepository) GetByID(ctx context.Context, id int64) (*Product, er
 	return p, nil
 }
 
-func (r *productRepository) List(ctx context.Context, filter *ProductListParams) ([]*Product, error) {
+// FindSlug returns the product with the slug, current is false when it is a
+// slug the product had before.
+func (r *productRepository) FindSlug(ctx context.Context, slug string) (id int64, current bool, err error) {
+	query := `
+		SELECT id, true FROM products WHERE slug = $1
+		UNION ALL
+		SELECT entity_id, false FROM slug_history WHERE entity_type = 'product' AND slug = $1
+		ORDER BY 2 DESC
+		LIMIT 1
+	`
+	err = r.db.QueryRowContext(ctx, query, slug).Scan(&id, &current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, errs.ErrProductNotFound
+		}
+		return 0, false, err
+	}
+
+	return id, current, nil
+}
+
+func (r *productRepository) SlugTaken(ctx context.Context, slug string, excludeID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM products WHERE slug = $1 AND id <> $2)`
+
+	var taken bool
+	if err := r.db.QueryRowContext(ctx, query, slug, excludeID).Scan(&taken); err != nil {
+		return false, err
+	}
+
+	return taken, nil
+}
+
+// LockSlug locks the product row for a slug change, custom tells whether its
+// slug was set by hand.
+func (r *productRepository) LockSlug(ctx context.Context, exec database.DBExec, id int64) (custom bool, err error) {
+	query := `SELECT custom_slug FROM products WHERE id = $1 FOR UPDATE`
+	if err = exec.QueryRowContext(ctx, query, id).Scan(&custom); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, errs.ErrProductNotFound
+		}
+		return false, err
+	}
+
+	return custom, nil
+}
+
+// SetSlug changes the slug of the product and keeps the old one in the slug
+// history, the new slug stops redirecting to whatever had it before. custom
+// marks a slug set by hand.
+func (r *productRepository) SetSlug(ctx context.Context, exec database.DBExec, id int64, slug string, custom bool) error {
+	query := `
+		WITH old AS (
+			SELECT id, slug FROM products WHERE id = $1
+		), h AS (
+			INSERT INTO slug_history (entity_type, slug, entity_id)
+			SELECT 'product', old.slug, old.id FROM old WHERE old.slug <> $2
+			ON CONFLICT (entity_type, slug) DO UPDATE SET
+				entity_id = EXCLUDED.entity_id,
+				created_at = now()
+		), d AS (
+			DELETE FROM slug_history WHERE entity_type = 'product' AND slug = $2
+		)
+		UPDATE products SET slug = $2, custom_slug = $3, updated_at = now() WHERE id = $1
+	`
+	res, err := exec.ExecContext(ctx, query, id, slug, custom)
+	if err != nil {
+		if strings.Contains(err.Error(), "products_slug_key") {
+			return errs.ErrSlugExists
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrProductNotFound
+	}
+
+	return nil
+}
+
+// List returns a page of products, after or before filter.Cursor when given,
+// otherwise at filter.Offset.
+func (r *productRepository) List(ctx context.Context, filter *ProductListParams) ([]*Product, *cursor.Page, error) {
 	var validateOrderByField = map[string]bool{
 		"id":         true,
 		"name":       true,
@@ -121,83 +310,526 @@ func (r *productRepository) List(ctx context.Context, filter *ProductListParams)
 		sort = *filter.Sort
 	}
 
-	var rows *sql.Rows
-	var err error
+	keyset := &cursor.Keyset{Listing: "products", Table: "p", Column: col, Sort: sort}
+	if err := keyset.Continue(filter.Cursor, validateOrderByField); err != nil {
+		return nil, nil, err
+	}
 
-	if filter.CategoryID != 0 {
-		query := fmt.Sprintf(`%s WHERE category_id = $1 ORDER BY %s %s LIMIT $2 OFFSET $3`, selectProductQuery, col, sort)
-		rows, err = r.db.QueryContext(ctx, query, filter.CategoryID, filter.Limit, filter.Offset)
-	} else {
-		query := fmt.Sprintf(`%s ORDER BY %s %s LIMIT $1 OFFSET $2`, selectProductQuery, col, sort)
-		rows, err = r.db.QueryContext(ctx, query, filter.Limit, filter.Offset)
+	where, args := buildListFilter(filter, nil)
+	after, args := keyset.Where(args)
+	if after != "" {
+		where += " AND " + after
 	}
 
+	// Offset mode is kept for older clients, a cursor replaces it
+	offset := filter.Offset
+	if keyset.Cursor != nil {
+		offset = 0
+	}
+
+	// One extra row tells whether there is a next page
+	query := fmt.Sprintf(
+		`SELECT p.*, %s FROM (%s) p %s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		keyset.KeyColumns(), selectProductQuery, where, keyset.OrderBy(), len(args)+1, len(args)+2,
+	)
+	args = append(args, filter.Limit+1, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var products []*Product
+	var keys []cursor.Key
 
 	for rows.Next() {
 		p := new(Product)
+		var key cursor.Key
+		if err = scanProduct(rows, p, &key.Value, &key.ID); err != nil {
+			return nil, nil, err
+		}
+		products = append(products, p)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	products, page := cursor.Paginate(keyset, filter.Limit, offset, products, keys)
+	return products, page, nil
+}
+
+// Count returns how many products match the filter.
+func (r *productRepository) Count(ctx context.Context, filter *ProductListParams) (int64, error) {
+	where, args := buildListFilter(filter, nil)
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM (%s) p %s`, selectProductQuery, where)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// buildListFilter returns the WHERE clause over the product query aliased p,
+// its placeholders follow the given args.
+func buildListFilter(filter *ProductListParams, args []any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("WHERE true")
+
+	// The primary category or any assigned one, with IncludeDescendants
+	// also any category below it
+	if filter.CategoryID != 0 {
+		args = append(args, filter.CategoryID)
+		cats := fmt.Sprintf("$%d", len(args))
+		if filter.IncludeDescendants {
+			cats = fmt.Sprintf(`SELECT d.id FROM categories d
+				JOIN categories c ON d.path LIKE c.path || '%%'
+				WHERE c.id = $%d`, len(args))
+		}
+		sb.WriteString(fmt.Sprintf(` AND (p.category_id IN (%s) OR EXISTS (
+			SELECT 1 FROM product_categories pc WHERE pc.product_id = p.id AND pc.category_id IN (%s)
+		))`, cats, cats))
+	}
+
+	// Prices are compared in PriceCurrency, converted at the stored rate or
+	// the inverse of the opposite pair like the displayed prices. Products
+	// without a rate to it never match a price bound
+	if filter.PriceMin != nil || filter.PriceMax != nil {
+		args = append(args, filter.PriceCurrency, money.Exponent(filter.PriceCurrency))
+		price := fmt.Sprintf(`ROUND(p.price * CASE WHEN p.currency = $%[1]d THEN 1 ELSE COALESCE(
+			(SELECT r.rate FROM exchange_rates r WHERE r.base_currency = p.currency AND r.quote_currency = $%[1]d),
+			(SELECT 1 / r.rate FROM exchange_rates r WHERE r.base_currency = $%[1]d AND r.quote_currency = p.currency)
+		) END, $%[2]d)`, len(args)-1, len(args))
+
+		if filter.PriceMin != nil {
+			args = append(args, *filter.PriceMin)
+			sb.WriteString(fmt.Sprintf(" AND %s >= $%d::numeric", price, len(args)))
+		}
+		if filter.PriceMax != nil {
+			args = append(args, *filter.PriceMax)
+			sb.WriteString(fmt.Sprintf(" AND %s <= $%d::numeric", price, len(args)))
+		}
+	}
+
+	if filter.InStock {
+		sb.WriteString(" AND p.available > 0")
+	}
+
+	// Values of one attribute are alternatives, attributes all have to match
+	for _, attr := range filter.Attributes {
+		args = append(args, attr.Code)
+		sb.WriteString(fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM product_attribute_values av
+			JOIN attributes a ON a.id = av.attribute_id
+			WHERE av.product_id = p.id AND a.code = $%d`, len(args)))
+
+		if len(attr.Values) > 0 {
+			args = append(args, pq.Array(attr.Values))
+			sb.WriteString(fmt.Sprintf(" AND %s = ANY($%d::text[])", attributeValueExpr, len(args)))
+		}
+		if attr.Min != nil {
+			args = append(args, *attr.Min)
+			sb.WriteString(fmt.Sprintf(" AND av.value_number >= $%d", len(args)))
+		}
+		if attr.Max != nil {
+			args = append(args, *attr.Max)
+			sb.WriteString(fmt.Sprintf(" AND av.value_number <= $%d", len(args)))
+		}
+		sb.WriteString(")")
+	}
+
+	return sb.String(), args
+}
+
+// Search matches the full-text query against name and description, or the
+// raw text against the name by trigram similarity so misspellings still
+// match. Results are ranked by both, title matches weigh more.
+func (r *productRepository) Search(ctx context.Context, search *ProductSearchParams) ([]*ProductSearchResult, error) {
+	args := []any{search.TSQuery, search.Text}
+	where, args := buildListFilter(search.Filter, args)
+
+	query := fmt.Sprintf(`
+		WITH q AS (
+			SELECT to_tsquery('simple', $1) AS query
+		), matches AS (
+			SELECT pr.id,
+				ts_rank_cd(pr.search_vector, q.query) + word_similarity($2, pr.name) AS rank
+			FROM products pr, q
+			WHERE pr.search_vector @@ q.query OR $2 <%% pr.name
+		)
+		SELECT p.*, m.rank,
+			ts_headline('simple', p.name, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+			ts_headline('simple', COALESCE(p.description, ''), q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10')
+		FROM (%s) p
+		JOIN matches m ON m.id = p.id
+		CROSS JOIN q
+		%s
+		ORDER BY m.rank DESC, p.id
+		LIMIT $%d OFFSET $%d
+	`, selectProductQuery, where, len(args)+1, len(args)+2)
+	args = append(args, search.Filter.Limit, search.Filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*ProductSearchResult
+	for rows.Next() {
+		res := &ProductSearchResult{Product: new(Product)}
+		p := res.Product
 		err = rows.Scan(
 			&p.ID,
 			&p.CategoryID,
 			&p.Name,
+			&p.Slug,
+			&p.CustomSlug,
 			&p.Description,
+			&p.Price.Currency,
 			&p.Price,
 			&p.Stock,
+			&p.Available,
+			&p.LowStockThreshold,
+			&p.WeightGrams,
 			&p.ImageURL,
 			&p.CreatedAt,
 			&p.UpdatedAt,
+			&res.Rank,
+			&res.NameHighlight,
+			&res.DescriptionHighlight,
 		)
 		if err != nil {
 			return nil, err
 		}
-		products = append(products, p)
+		results = append(results, res)
+	}
+
+	return results, rows.Err()
+}
+
+// Suggest lists product and category names starting with the prefix, or with
+// a word starting with it, close misspellings of product names, and popular
+// past queries. Closer matches come first.
+func (r *productRepository) Suggest(ctx context.Context, prefix string, limit int) (*Suggestions, error) {
+	escaped := likeEscaper.Replace(prefix)
+	start, word := escaped+"%", "% "+escaped+"%"
+	out := &Suggestions{
+		Products:   []*ProductSuggestion{},
+		Categories: []*CategorySuggestion{},
+		Queries:    []string{},
+	}
+
+	productQuery := `
+		SELECT id, name, COALESCE(image_url, '')
+		FROM products
+		WHERE name ILIKE $1 OR name ILIKE $2 OR $3 <% name
+		ORDER BY name ILIKE $1 DESC, word_similarity($3, name) DESC, name, id
+		LIMIT $4
+	`
+	rows, err := r.db.QueryContext(ctx, productQuery, start, word, prefix, limit)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
+	for rows.Next() {
+		s := new(ProductSuggestion)
+		if err = rows.Scan(&s.ID, &s.Name, &s.ImageURL); err != nil {
+			return nil, err
+		}
+		out.Products = append(out.Products, s)
+	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return products, nil
+	categoryQuery := `
+		SELECT id, name
+		FROM categories
+		WHERE name ILIKE $1 OR name ILIKE $2
+		ORDER BY name ILIKE $1 DESC, name, id
+		LIMIT $3
+	`
+	catRows, err := r.db.QueryContext(ctx, categoryQuery, start, word, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer catRows.Close()
+
+	for catRows.Next() {
+		s := new(CategorySuggestion)
+		if err = catRows.Scan(&s.ID, &s.Name); err != nil {
+			return nil, err
+		}
+		out.Categories = append(out.Categories, s)
+	}
+	if err = catRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Logged queries are normalized, so a case sensitive prefix match can
+	// use the text_pattern_ops index
+	queryRows, err := r.db.QueryContext(ctx, `
+		SELECT query FROM search_queries
+		WHERE query LIKE $1
+		ORDER BY count DESC, query
+		LIMIT $2
+	`, start, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer queryRows.Close()
+
+	for queryRows.Next() {
+		var q string
+		if err = queryRows.Scan(&q); err != nil {
+			return nil, err
+		}
+		out.Queries = append(out.Queries, q)
+	}
+
+	return out, queryRows.Err()
 }
 
-func (r *productRepository) UpdateStock(ctx context.Context, id int64, stock int) error {
+// likeEscaper escapes the LIKE wildcards of user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// LogQueries adds the counts to the searched queries in one statement.
+func (r *productRepository) LogQueries(ctx context.Context, counts map[string]int) error {
+	queries := make([]string, 0, len(counts))
+	values := make([]int64, 0, len(counts))
+	for q, n := range counts {
+		queries = append(queries, q)
+		values = append(values, int64(n))
+	}
+
 	query := `
-		UPDATE products SET stock = stock + $1 
-		WHERE id = $2 AND stock + $1 >= 0
+		INSERT INTO search_queries (query, count, last_searched_at)
+		SELECT q, n, now() FROM unnest($1::text[], $2::bigint[]) AS t(q, n)
+		ON CONFLICT (query) DO UPDATE SET
+			count = search_queries.count + EXCLUDED.count,
+			last_searched_at = EXCLUDED.last_searched_at
 	`
-	res, err := r.db.ExecContext(ctx, query, stock, id)
+	_, err := r.db.ExecContext(ctx, query, pq.Array(queries), pq.Array(values))
+	return err
+}
+
+// Facets counts the attribute values, price ranges and available products of
+// every product matching the filter, pagination is ignored.
+func (r *productRepository) Facets(ctx context.Context, filter *ProductListParams) (*ProductFacets, error) {
+	where, args := buildListFilter(filter, nil)
+	facets := &ProductFacets{Attributes: []*AttributeFacet{}, Prices: []*PriceFacet{}}
+
+	query := fmt.Sprintf(`
+		SELECT p.currency, p.currency, MIN(p.price), p.currency, MAX(p.price), COUNT(*), COUNT(*) FILTER (WHERE p.available > 0)
+		FROM (%s) p %s
+		GROUP BY p.currency
+		ORDER BY p.currency
+	`, selectProductQuery, where)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		pf := new(PriceFacet)
+		var inStock int
+		err = rows.Scan(
+			&pf.Currency,
+			&pf.Min.Currency,
+			&pf.Min,
+			&pf.Max.Currency,
+			&pf.Max,
+			&pf.Count,
+			&inStock,
+		)
+		if err != nil {
+			return nil, err
+		}
+		facets.Prices = append(facets.Prices, pf)
+		facets.InStock += inStock
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = fmt.Sprintf(`
+		SELECT a.code, a.name, a.type, a.unit, %s AS value, av.value_number, COUNT(*)
+		FROM product_attribute_values av
+		JOIN attributes a ON a.id = av.attribute_id
+		WHERE a.is_filterable AND av.product_id IN (SELECT p.id FROM (%s) p %s)
+		GROUP BY a.id, value, av.value_number
+		ORDER BY a.position, a.code, COUNT(*) DESC, value
+	`, attributeValueExpr, selectProductQuery, where)
+	attrRows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer attrRows.Close()
+
+	for attrRows.Next() {
+		af := new(AttributeFacet)
+		fv := new(FacetValue)
+		var number sql.NullFloat64
+		if err = attrRows.Scan(&af.Code, &af.Name, &af.Type, &af.Unit, &fv.Value, &number, &fv.Count); err != nil {
+			return nil, err
+		}
+
+		if n := len(facets.Attributes); n > 0 && facets.Attributes[n-1].Code == af.Code {
+			af = facets.Attributes[n-1]
+		} else {
+			facets.Attributes = append(facets.Attributes, af)
+		}
+		af.Values = append(af.Values, fv)
+
+		if number.Valid {
+			if af.Min == nil || number.Float64 < *af.Min {
+				af.Min = &number.Float64
+			}
+			if af.Max == nil || number.Float64 > *af.Max {
+				af.Max = &number.Float64
+			}
+		}
+	}
+
+	return facets, attrRows.Err()
+}
+
+// UpdateStock adds qty, which may be negative, to the variant stock.
+func (r *productRepository) UpdateStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error {
+	ok, err := r.changeStock(ctx, exec, variantID, qty, change)
 	if err != nil {
 		return err
 	}
 
-	rows, err := res.RowsAffected()
+	if !ok {
+		if qty < 0 {
+			return errs.ErrProductOutOfStock
+		}
+		return errs.ErrVariantNotFound
+	}
+
+	return nil
+}
+
+func (r *productRepository) DeductStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) (bool, error) {
+	return r.changeStock(ctx, exec, variantID, -qty, change)
+}
+
+func (r *productRepository) RestoreStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error {
+	ok, err := r.changeStock(ctx, exec, variantID, qty, change)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errs.ErrProductNotFound
+	if !ok {
+		return errs.ErrVariantNotFound
 	}
 
 	return nil
 }
 
-func (r *productRepository) DeductStock(ctx context.Context, exec database.DBExec, productID int64, qty int) (bool, error) {
-	query := `
-		UPDATE products SET stock = stock - $1, updated_at = NOW()
-		WHERE id = $2 AND stock >= $1
-	`
-	res, err := exec.ExecContext(ctx, query, qty, productID)
+// changeStock adds delta to the stock of the variant in the warehouse of the
+// change (the default warehouse when not set), keeps the variant and product
+// stock as totals and writes the movement in one statement. It returns
+// false when the variant is not found or the warehouse has not enough stock
+// left after its active reservations. exec should be a transaction when
+// delta is negative, the warehouse row stays locked until it ends.
+func (r *productRepository) changeStock(ctx context.Context, exec database.DBExec, variantID int64, delta int, change *StockChange) (bool, error) {
+	if change.WarehouseID != nil {
+		var exists bool
+		err := exec.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM warehouses WHERE id = $1)`, *change.WarehouseID).Scan(&exists)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, errs.ErrWarehouseNotFound
+		}
+	}
+
+	warehouse := fmt.Sprintf("COALESCE($3::bigint, %s)", defaultWarehouseQuery)
+
+	var warehouseStmt string
+	if delta >= 0 {
+		warehouseStmt = fmt.Sprintf(`
+			INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, stock)
+			SELECT %s, v.product_id, v.id, $1::int
+			FROM product_variants v
+			WHERE v.id = $2 AND %s IS NOT NULL
+			ON CONFLICT (warehouse_id, variant_id)
+			DO UPDATE SET stock = warehouse_stock.stock + EXCLUDED.stock, updated_at = now()
+			RETURNING warehouse_id, product_id, variant_id, stock
+		`, warehouse, warehouse)
+	} else {
+		// Locked first, the guard below then counts every reservation made
+		// before the lock was taken
+		lock := fmt.Sprintf(`
+			SELECT 1 FROM warehouse_stock
+			WHERE warehouse_id = COALESCE($1::bigint, %s) AND variant_id = $2
+			FOR UPDATE
+		`, defaultWarehouseQuery)
+		if _, err := exec.ExecContext(ctx, lock, change.WarehouseID, variantID); err != nil {
+			return false, err
+		}
+
+		// Stock held by active reservations cannot be taken out
+		warehouseStmt = fmt.Sprintf(`
+			UPDATE warehouse_stock SET stock = stock + $1::int, updated_at = now()
+			WHERE warehouse_id = %s AND variant_id = $2
+				AND stock + $1::int - COALESCE((
+					SELECT SUM(sr.quantity) FROM stock_reservations sr
+					WHERE sr.variant_id = $2 AND sr.warehouse_id = warehouse_stock.warehouse_id
+						AND sr.status = 'active' AND sr.expires_at > now()
+				), 0) >= 0
+			RETURNING warehouse_id, product_id, variant_id, stock
+		`, warehouse)
+	}
+
+	query := fmt.Sprintf(`
+		WITH ws AS (%s),
+		variant AS (
+			UPDATE product_variants SET stock = stock + $1::int, updated_at = now()
+			WHERE id IN (SELECT variant_id FROM ws)
+		),
+		changed AS (
+			UPDATE products SET stock = stock + $1::int, updated_at = NOW()
+			WHERE id IN (SELECT product_id FROM ws)
+			RETURNING id, stock
+		)
+		INSERT INTO inventory_movements (product_id, variant_id, warehouse_id, delta, balance, warehouse_balance, reason, actor_id, ref_type, ref_id, note)
+		SELECT c.id, ws.variant_id, ws.warehouse_id, $1::int, c.stock, ws.stock, $4::movement_reason, $5::uuid, $6::varchar, $7::bigint, $8::text
+		FROM changed c
+		JOIN ws ON ws.product_id = c.id
+	`, warehouseStmt)
+
+	res, err := exec.ExecContext(
+		ctx,
+		query,
+		delta,
+		variantID,
+		change.WarehouseID,
+		change.Reason,
+		change.ActorID,
+		change.RefType,
+		change.RefID,
+		change.Note,
+	)
 	if err != nil {
 		return false, err
 	}
-	n, _ := res.RowsAffected()
-	return n > 0, nil
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
 }
 
 func (r *productRepository) Delete(ctx context.Context, id int64) error {
@@ -218,14 +850,14 @@ func (r *productRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (r *productRepository) Update(ctx context.Context, id int64, input *ProductUpdate) error {
+func (r *productRepository) Update(ctx context.Context, exec database.DBExec, id int64, input *ProductUpdate) error {
 	query, args, err := r.buildUpdateQuery(id, input)
 	if err != nil {
 		return err
 	}
 	log.Println(query)
 
-	res, err := r.db.ExecContext(ctx, query, args...)
+	res, err := exec.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -266,14 +898,14 @@ func (r *productRepository) buildUpdateQuery(id int64, p *ProductUpdate) (string
 	}
 
 	if p.Price != nil {
-		columns = append(columns, fmt.Sprintf("price = $%d", idx))
-		args = append(args, p.Price)
-		idx++
+		columns = append(columns, fmt.Sprintf("price = $%d, currency = $%d", idx, idx+1))
+		args = append(args, p.Price, p.Price.CurrencyCode())
+		idx += 2
 	}
 
-	if p.Stock != nil {
-		columns = append(columns, fmt.Sprintf("stock = $%d", idx))
-		args = append(args, p.Stock)
+	if p.WeightGrams != nil {
+		columns = append(columns, fmt.Sprintf("weight_grams = $%d", idx))
+		args = append(args, p.WeightGrams)
 		idx++
 	}
 
@@ -283,6 +915,12 @@ func (r *productRepository) buildUpdateQuery(id int64, p *ProductUpdate) (string
 		idx++
 	}
 
+	if p.LowStockThreshold != nil {
+		columns = append(columns, fmt.Sprintf("low_stock_threshold = $%d", idx))
+		args = append(args, p.LowStockThreshold)
+		idx++
+	}
+
 	if len(columns) == 0 {
 		return "", nil, errs.ErrNoFieldUpdate
 	}
@@ -296,7 +934,7 @@ func (r *productRepository) buildUpdateQuery(id int64, p *ProductUpdate) (string
 
 func (r *productRepository) GetCategoriesByProduct(ctx context.Context, productID int64) ([]*categories.Category, error) {
 	query := `
-		SELECT c.id, c.name, c.description
+		SELECT c.id, c.parent_id, c.path, c.name, c.slug, c.custom_slug, COALESCE(c.description, '')
 		FROM categories c
 		JOIN product_categories pc ON pc.category_id = c.id
 		WHERE pc.product_id = $1
@@ -312,7 +950,11 @@ func (r *productRepository) GetCategoriesByProduct(ctx context.Context, productI
 		c := new(categories.Category)
 		err = rows.Scan(
 			&c.ID,
+			&c.ParentID,
+			&c.Path,
 			&c.Name,
+			&c.Slug,
+			&c.CustomSlug,
 			&c.Description,
 		)
 		if err != nil {
@@ -364,3 +1006,704 @@ func (r *productRepository) DelCategoryByProduct(ctx context.Context, productID,
 
 	return nil
 }
+
+// GetWarehouseStock returns the stock of the product in every active warehouse.
+func (r *productRepository) GetWarehouseStock(ctx context.Context, productID int64) ([]*ProductWarehouseStock, error) {
+	query := `
+		SELECT w.id, w.code, w.name, SUM(ws.stock),
+			SUM(ws.stock) - COALESCE((
+				SELECT SUM(sr.quantity) FROM stock_reservations sr
+				WHERE sr.product_id = $1 AND sr.warehouse_id = w.id
+					AND sr.status = 'active' AND sr.expires_at > now()
+			), 0)
+		FROM warehouse_stock ws
+		JOIN warehouses w ON w.id = ws.warehouse_id
+		WHERE ws.product_id = $1 AND w.is_active
+		GROUP BY w.id
+		ORDER BY w.priority DESC, w.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stock []*ProductWarehouseStock
+	for rows.Next() {
+		ws := new(ProductWarehouseStock)
+		if err = rows.Scan(&ws.WarehouseID, &ws.Code, &ws.Name, &ws.Stock, &ws.Available); err != nil {
+			return nil, err
+		}
+		stock = append(stock, ws)
+	}
+
+	return stock, rows.Err()
+}
+
+// scanProduct scans a selectProductQuery row, extra holds the destinations of
+// columns selected after it.
+func scanProduct(row rowScanner, p *Product, extra ...any) error {
+	dest := []any{
+		&p.ID,
+		&p.CategoryID,
+		&p.Name,
+		&p.Slug,
+		&p.CustomSlug,
+		&p.Description,
+		&p.Price.Currency,
+		&p.Price,
+		&p.Stock,
+		&p.Available,
+		&p.LowStockThreshold,
+		&p.WeightGrams,
+		&p.ImageURL,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// ------------ Inventory Movements ------------
+
+func (r *productRepository) ListMovements(ctx context.Context, filter *MovementFilter) ([]*InventoryMovement, error) {
+	var sb strings.Builder
+	args := []any{filter.ProductID}
+
+	sb.WriteString(`
+		SELECT id, product_id, variant_id, warehouse_id, delta, balance, warehouse_balance, reason, actor_id, ref_type, ref_id, note, created_at
+		FROM inventory_movements
+		WHERE product_id = $1
+	`)
+
+	if filter.VariantID != nil {
+		sb.WriteString(fmt.Sprintf(" AND variant_id = $%d", len(args)+1))
+		args = append(args, *filter.VariantID)
+	}
+
+	if filter.WarehouseID != nil {
+		sb.WriteString(fmt.Sprintf(" AND warehouse_id = $%d", len(args)+1))
+		args = append(args, *filter.WarehouseID)
+	}
+
+	if filter.Reason != nil {
+		sb.WriteString(fmt.Sprintf(" AND reason = $%d", len(args)+1))
+		args = append(args, *filter.Reason)
+	}
+
+	if filter.From != nil {
+		sb.WriteString(fmt.Sprintf(" AND created_at >= $%d", len(args)+1))
+		args = append(args, *filter.From)
+	}
+
+	if filter.To != nil {
+		sb.WriteString(fmt.Sprintf(" AND created_at < $%d", len(args)+1))
+		args = append(args, *filter.To)
+	}
+
+	sb.WriteString(fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2))
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []*InventoryMovement
+	for rows.Next() {
+		m := new(InventoryMovement)
+		err = rows.Scan(
+			&m.ID,
+			&m.ProductID,
+			&m.VariantID,
+			&m.WarehouseID,
+			&m.Delta,
+			&m.Balance,
+			&m.WarehouseBalance,
+			&m.Reason,
+			&m.ActorID,
+			&m.RefType,
+			&m.RefID,
+			&m.Note,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
+
+// ------------ Stock Alerts ------------
+
+// ListLowStock returns products whose available stock is at or below their
+// low-stock threshold, the emptiest first. A threshold of 0 disables alerts.
+func (r *productRepository) ListLowStock(ctx context.Context, filter *LowStockFilter) ([]*Product, error) {
+	query := fmt.Sprintf(`
+		SELECT * FROM (%s) p
+		WHERE p.low_stock_threshold > 0 AND p.available <= p.low_stock_threshold
+		ORDER BY p.available, p.id
+		LIMIT $1 OFFSET $2
+	`, selectProductQuery)
+	rows, err := r.db.QueryContext(ctx, query, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*Product
+	for rows.Next() {
+		p := new(Product)
+		if err = scanProduct(rows, p); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
+func (r *productRepository) CreateSubscription(ctx context.Context, input *StockSubscription) error {
+	query := `
+		INSERT INTO stock_subscriptions (product_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (product_id, user_id) WHERE status = 'pending' DO NOTHING
+		RETURNING id, status, created_at
+	`
+	err := r.db.QueryRowContext(ctx, query, input.ProductID, input.UserID).Scan(
+		&input.ID,
+		&input.Status,
+		&input.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrStockSubscriptionExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ClaimSubscriptions marks the pending subscriptions of the product as
+// notified and returns them, so each subscriber is notified once. The claim
+// holds only if the notifications are queued in the same transaction.
+func (r *productRepository) ClaimSubscriptions(ctx context.Context, tx *sql.Tx, productID int64) ([]*StockSubscription, error) {
+	query := `
+		UPDATE stock_subscriptions SET status = 'notified', notified_at = now()
+		WHERE product_id = $1 AND status = 'pending'
+		RETURNING id, product_id, user_id, status, notified_at, created_at
+	`
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*StockSubscription
+	for rows.Next() {
+		sub := new(StockSubscription)
+		err = rows.Scan(
+			&sub.ID,
+			&sub.ProductID,
+			&sub.UserID,
+			&sub.Status,
+			&sub.NotifiedAt,
+			&sub.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+// ------------ Options & Variants ------------
+
+// CreateOption inserts the option with its values, the order of Values is
+// their position.
+func (r *productRepository) CreateOption(ctx context.Context, input *ProductOption) error {
+	values := make([]string, len(input.Values))
+	for i, v := range input.Values {
+		values[i] = v.Value
+	}
+
+	query := `
+		WITH o AS (
+			INSERT INTO product_options (product_id, name, position)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at
+		), v AS (
+			INSERT INTO product_option_values (option_id, value, position)
+			SELECT o.id, t.value, t.ord - 1
+			FROM o, unnest($4::text[]) WITH ORDINALITY AS t(value, ord)
+			RETURNING id, value
+		)
+		SELECT o.id, o.created_at, v.id, v.value FROM o, v
+	`
+	rows, err := r.db.QueryContext(ctx, query, input.ProductID, input.Name, input.Position, pq.Array(values))
+	if err != nil {
+		return optionError(err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int64, len(values))
+	for rows.Next() {
+		var valueID int64
+		var value string
+		if err = rows.Scan(&input.ID, &input.CreatedAt, &valueID, &value); err != nil {
+			return optionError(err)
+		}
+		ids[value] = valueID
+	}
+	if err = rows.Err(); err != nil {
+		return optionError(err)
+	}
+
+	for _, v := range input.Values {
+		v.ID = ids[v.Value]
+		v.OptionID = input.ID
+	}
+
+	return nil
+}
+
+func optionError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "product_options_product_id_fkey"):
+		return errs.ErrProductNotFound
+	case strings.Contains(err.Error(), "product_options_product_id_name_key"):
+		return errs.ErrOptionExists
+	}
+	return err
+}
+
+func (r *productRepository) ListOptions(ctx context.Context, productID int64) ([]*ProductOption, error) {
+	query := `
+		SELECT o.id, o.product_id, o.name, o.position, o.created_at, v.id, v.value, v.position
+		FROM product_options o
+		JOIN product_option_values v ON v.option_id = o.id
+		WHERE o.product_id = $1
+		ORDER BY o.position, o.id, v.position, v.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []*ProductOption
+	for rows.Next() {
+		o := new(ProductOption)
+		v := new(ProductOptionValue)
+		err = rows.Scan(&o.ID, &o.ProductID, &o.Name, &o.Position, &o.CreatedAt, &v.ID, &v.Value, &v.Position)
+		if err != nil {
+			return nil, err
+		}
+		v.OptionID = o.ID
+
+		if n := len(options); n > 0 && options[n-1].ID == o.ID {
+			options[n-1].Values = append(options[n-1].Values, v)
+			continue
+		}
+		o.Values = []*ProductOptionValue{v}
+		options = append(options, o)
+	}
+
+	return options, rows.Err()
+}
+
+// DeleteOption removes an option that no variant uses.
+func (r *productRepository) DeleteOption(ctx context.Context, productID, optionID int64) error {
+	var exists, inUse bool
+	query := `
+		SELECT true, EXISTS (
+			SELECT 1 FROM variant_option_values vov
+			JOIN product_option_values ov ON ov.id = vov.option_value_id
+			WHERE ov.option_id = o.id
+		)
+		FROM product_options o
+		WHERE o.id = $1 AND o.product_id = $2
+	`
+	err := r.db.QueryRowContext(ctx, query, optionID, productID).Scan(&exists, &inUse)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrOptionNotFound
+		}
+		return err
+	}
+
+	if inUse {
+		return errs.ErrOptionInUse
+	}
+
+	_, err = r.db.ExecContext(ctx, "DELETE FROM product_options WHERE id = $1", optionID)
+	if err != nil && strings.Contains(err.Error(), "variant_option_values_option_value_id_fkey") {
+		return errs.ErrOptionInUse
+	}
+	return err
+}
+
+func (r *productRepository) CreateVariant(ctx context.Context, input *ProductVariant, optionValueIDs []int64) error {
+	query := `
+		WITH v AS (
+			INSERT INTO product_variants (product_id, sku, price, image_url)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, stock, is_active, created_at, updated_at
+		), o AS (
+			INSERT INTO variant_option_values (variant_id, option_value_id)
+			SELECT v.id, unnest($5::bigint[]) FROM v
+		)
+		SELECT id, stock, is_active, created_at, updated_at FROM v
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		input.ProductID,
+		input.SKU,
+		input.Price,
+		input.ImageURL,
+		pq.Array(optionValueIDs),
+	).Scan(
+		&input.ID,
+		&input.Stock,
+		&input.IsActive,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "product_variants_product_id_fkey") {
+			return errs.ErrProductNotFound
+		}
+		return variantError(err)
+	}
+
+	return nil
+}
+
+// ConvertVariant gives the variant input.ID, which has no option values yet,
+// the option values and the sku, price and image of input. It keeps its stock.
+func (r *productRepository) ConvertVariant(ctx context.Context, input *ProductVariant, optionValueIDs []int64) error {
+	query := `
+		WITH v AS (
+			UPDATE product_variants SET
+				sku = $2, price = $3, image_url = $4, is_active = true, updated_at = now()
+			WHERE id = $1
+				AND NOT EXISTS (SELECT 1 FROM variant_option_values WHERE variant_id = $1)
+			RETURNING id, stock, is_active, created_at, updated_at
+		), o AS (
+			INSERT INTO variant_option_values (variant_id, option_value_id)
+			SELECT v.id, unnest($5::bigint[]) FROM v
+		)
+		SELECT stock, is_active, created_at, updated_at FROM v
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		input.ID,
+		input.SKU,
+		input.Price,
+		input.ImageURL,
+		pq.Array(optionValueIDs),
+	).Scan(
+		&input.Stock,
+		&input.IsActive,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrVariantNotFound
+		}
+		return variantError(err)
+	}
+
+	return nil
+}
+
+func variantError(err error) error {
+	if strings.Contains(err.Error(), "product_variants_sku_key") {
+		return errs.ErrVariantSKUExists
+	}
+	return err
+}
+
+func (r *productRepository) GetVariant(ctx context.Context, id int64) (*ProductVariant, error) {
+	v := new(ProductVariant)
+	err := scanVariant(r.db.QueryRowContext(ctx, selectVariantQuery+" WHERE v.id = $1", id), v)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrVariantNotFound
+		}
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (r *productRepository) ListVariants(ctx context.Context, productID int64, activeOnly bool) ([]*ProductVariant, error) {
+	query := selectVariantQuery + " WHERE v.product_id = $1"
+	if activeOnly {
+		query += " AND v.is_active"
+	}
+	query += " ORDER BY v.id"
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []*ProductVariant
+	for rows.Next() {
+		v := new(ProductVariant)
+		if err = scanVariant(rows, v); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+func (r *productRepository) UpdateVariant(ctx context.Context, id int64, input *VariantUpdate) error {
+	var columns []string
+	var args []any
+
+	if input.SKU != nil {
+		args = append(args, *input.SKU)
+		columns = append(columns, fmt.Sprintf("sku = $%d", len(args)))
+	}
+
+	if input.ClearPrice {
+		columns = append(columns, "price = NULL")
+	} else if input.Price != nil {
+		args = append(args, input.Price)
+		columns = append(columns, fmt.Sprintf("price = $%d", len(args)))
+	}
+
+	if input.ImageURL != nil {
+		args = append(args, *input.ImageURL)
+		columns = append(columns, fmt.Sprintf("image_url = $%d", len(args)))
+	}
+
+	if input.IsActive != nil {
+		args = append(args, *input.IsActive)
+		columns = append(columns, fmt.Sprintf("is_active = $%d", len(args)))
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf(
+		"UPDATE product_variants SET %s, updated_at = NOW() WHERE id = $%d",
+		strings.Join(columns, ", "),
+		len(args),
+	)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return variantError(err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrVariantNotFound
+	}
+
+	return nil
+}
+
+// DeleteVariant removes a variant without stock that was never ordered.
+func (r *productRepository) DeleteVariant(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM product_variants WHERE id = $1 AND stock = 0", id)
+	if err != nil {
+		if strings.Contains(err.Error(), "order_items_variant_id_fkey") {
+			return errs.ErrVariantInUse
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		if _, err = r.GetVariant(ctx, id); err != nil {
+			return err
+		}
+		return errs.ErrVariantInUse
+	}
+
+	return nil
+}
+
+func scanVariant(row rowScanner, v *ProductVariant) error {
+	var currency string
+	var price sql.NullString
+	var options []byte
+
+	err := row.Scan(
+		&v.ID,
+		&v.ProductID,
+		&v.SKU,
+		&currency,
+		&price,
+		&v.Stock,
+		&v.Available,
+		&v.ImageURL,
+		&v.IsActive,
+		&options,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if price.Valid {
+		m, err := money.Parse(price.String, currency)
+		if err != nil {
+			return err
+		}
+		v.Price = &m
+	}
+
+	return json.Unmarshal(options, &v.Options)
+}
+
+// ------------ Attributes ------------
+
+// GetAttributeDefs returns the attributes with the given codes by code,
+// without values.
+func (r *productRepository) GetAttributeDefs(ctx context.Context, codes []string) (map[string]*ProductAttribute, error) {
+	query := `SELECT id, code, name, type, unit FROM attributes WHERE code = ANY($1::text[])`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	defs := make(map[string]*ProductAttribute, len(codes))
+	for rows.Next() {
+		a := new(ProductAttribute)
+		if err = rows.Scan(&a.AttributeID, &a.Code, &a.Name, &a.Type, &a.Unit); err != nil {
+			return nil, err
+		}
+		defs[a.Code] = a
+	}
+
+	return defs, rows.Err()
+}
+
+func (r *productRepository) ListAttributes(ctx context.Context, productID int64) ([]*ProductAttribute, error) {
+	query := `
+		SELECT a.id, a.code, a.name, a.type, a.unit, av.value_text, av.value_number, av.value_boolean
+		FROM product_attribute_values av
+		JOIN attributes a ON a.id = av.attribute_id
+		WHERE av.product_id = $1
+		ORDER BY a.position, a.code
+	`
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attrs []*ProductAttribute
+	for rows.Next() {
+		a := new(ProductAttribute)
+		var text sql.NullString
+		var number sql.NullFloat64
+		var boolean sql.NullBool
+		if err = rows.Scan(&a.AttributeID, &a.Code, &a.Name, &a.Type, &a.Unit, &text, &number, &boolean); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case text.Valid:
+			a.Value = text.String
+		case number.Valid:
+			a.Value = number.Float64
+		case boolean.Valid:
+			a.Value = boolean.Bool
+		}
+		attrs = append(attrs, a)
+	}
+
+	return attrs, rows.Err()
+}
+
+// SetAttributes upserts the values and removes the attributes in removeIDs
+// in one statement. Values hold a string, float64 or bool.
+func (r *productRepository) SetAttributes(ctx context.Context, productID int64, values []*ProductAttribute, removeIDs []int64) error {
+	ids := make([]int64, len(values))
+	texts := make([]sql.NullString, len(values))
+	numbers := make([]sql.NullFloat64, len(values))
+	booleans := make([]sql.NullBool, len(values))
+	for i, v := range values {
+		ids[i] = v.AttributeID
+		switch val := v.Value.(type) {
+		case string:
+			texts[i] = sql.NullString{String: val, Valid: true}
+		case float64:
+			numbers[i] = sql.NullFloat64{Float64: val, Valid: true}
+		case bool:
+			booleans[i] = sql.NullBool{Bool: val, Valid: true}
+		}
+	}
+
+	query := `
+		WITH p AS (
+			SELECT id FROM products WHERE id = $1
+		), removed AS (
+			DELETE FROM product_attribute_values
+			WHERE product_id IN (SELECT id FROM p) AND attribute_id = ANY($2::bigint[])
+		), upserted AS (
+			INSERT INTO product_attribute_values (product_id, attribute_id, value_text, value_number, value_boolean)
+			SELECT p.id, t.attribute_id, t.value_text, t.value_number, t.value_boolean
+			FROM p, unnest($3::bigint[], $4::text[], $5::numeric[], $6::boolean[])
+				AS t(attribute_id, value_text, value_number, value_boolean)
+			ON CONFLICT (product_id, attribute_id) DO UPDATE SET
+				value_text = EXCLUDED.value_text,
+				value_number = EXCLUDED.value_number,
+				value_boolean = EXCLUDED.value_boolean
+		)
+		SELECT EXISTS (SELECT 1 FROM p)
+	`
+	var exists bool
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		productID,
+		pq.Array(removeIDs),
+		pq.Array(ids),
+		pq.Array(texts),
+		pq.Array(numbers),
+		pq.Array(booleans),
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errs.ErrProductNotFound
+	}
+
+	return nil
+}
@@ -2,38 +2,75 @@ package products
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
 
 	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/attributes"
 	"github.com/codepnw/core-ecommerce-system/internal/features/categories"
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/features/notifications"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/slug"
 )
 
 type IProductService interface {
 	// Products
-	Create(ctx context.Context, req *ProductCreate) (*Product, error)
+	Create(ctx context.Context, req *ProductCreate, actorID string) (*Product, error)
 	GetByID(ctx context.Context, id int64) (*Product, error)
-	List(ctx context.Context, filter *ProductFilter) ([]*Product, error)
-	UpdateStock(ctx context.Context, id int64, stock int) error
-	DeductStock(ctx context.Context, exec database.DBExec, productID int64, qty int) (bool, error)
-	Update(ctx context.Context, id int64, req *ProductUpdate) error
+	GetBySlug(ctx context.Context, slug string) (*ProductBySlugResponse, error)
+	List(ctx context.Context, filter *ProductFilter) (*ProductListResponse, error)
+	Search(ctx context.Context, filter *ProductSearchFilter) ([]*ProductSearchResult, error)
+	Suggest(ctx context.Context, query string, limit int) (*Suggestions, error)
+	UpdateStock(ctx context.Context, id int64, req *ProductUpdateStock, actorID string) error
+	DeductStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) (bool, error)
+	RestoreStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error
+	Update(ctx context.Context, id int64, req *ProductUpdate, actorID string) error
 	Delete(ctx context.Context, id int64) error
 
 	// Product Categories
 	AssignCategories(ctx context.Context, req *ProductCategoryRequest) error
 	GetCategoriesByProduct(ctx context.Context, productID int64) ([]*categories.Category, error)
 	DelCategoryByProduct(ctx context.Context, productID, categoryID int64) error
+
+	// Inventory Movements
+	ListMovements(ctx context.Context, filter *MovementFilter) ([]*InventoryMovement, error)
+
+	// Stock Alerts
+	ListLowStock(ctx context.Context, filter *LowStockFilter) ([]*Product, error)
+	Subscribe(ctx context.Context, productID int64, userID string) (*StockSubscription, error)
+
+	// Attributes
+	SetAttributes(ctx context.Context, productID int64, req *ProductAttributesSet) ([]*ProductAttribute, error)
+
+	// Options & Variants
+	CreateOption(ctx context.Context, productID int64, req *OptionCreate) (*ProductOption, error)
+	DeleteOption(ctx context.Context, productID, optionID int64) error
+	ListVariants(ctx context.Context, productID int64) ([]*ProductVariant, error)
+	CreateVariant(ctx context.Context, productID int64, req *VariantCreate) (*ProductVariant, error)
+	UpdateVariant(ctx context.Context, productID, variantID int64, req *VariantUpdate) error
+	DeleteVariant(ctx context.Context, productID, variantID int64) error
+	ResolveVariant(ctx context.Context, productID int64, variantID *int64) (*ProductVariant, error)
 }
 
 type productService struct {
-	repo IProductRepository
+	repo      IProductRepository
+	rateSrv   currencies.IExchangeRateService
+	notifySrv notifications.INotificationService
+	tracker   *SearchTracker
+	tx        *database.TxManager
 }
 
-func NewProductService(repo IProductRepository) IProductService {
-	return &productService{repo: repo}
+func NewProductService(repo IProductRepository, rateSrv currencies.IExchangeRateService, notifySrv notifications.INotificationService, tracker *SearchTracker, tx *database.TxManager) IProductService {
+	return &productService{repo: repo, rateSrv: rateSrv, notifySrv: notifySrv, tracker: tracker, tx: tx}
 }
 
-func (s *productService) Create(ctx context.Context, req *ProductCreate) (*Product, error) {
+func (s *productService) Create(ctx context.Context, req *ProductCreate, actorID string) (*Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
@@ -43,19 +80,106 @@ func (s *productService) Create(ctx context.Context, req *ProductCreate) (*Produ
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
+		WeightGrams: req.WeightGrams,
 		ImageURL:    req.ImageURL,
+
+		LowStockThreshold: req.LowStockThreshold,
+	}
+
+	var err error
+	if p.Slug, err = s.newSlug(ctx, 0, req.Name, req.Slug); err != nil {
+		return nil, err
+	}
+	p.CustomSlug = req.Slug != ""
+
+	return s.repo.Create(ctx, p, req.SKU, &StockChange{Reason: MovementInitial, ActorID: actorRef(actorID)})
+}
+
+// GetBySlug returns the product with the slug, or with a slug it had before
+// along with its current slug to redirect to.
+func (s *productService) GetBySlug(ctx context.Context, slug string) (*ProductBySlugResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	id, current, err := s.repo.FindSlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ProductBySlugResponse{Product: product}
+	if !current {
+		res.RedirectSlug = product.Slug
 	}
-	return s.repo.Create(ctx, p)
+
+	return res, nil
+}
+
+// newSlug returns the explicit slug when it is free, otherwise a free slug
+// made from the name. excludeID is the product the slug is for.
+func (s *productService) newSlug(ctx context.Context, excludeID int64, name, explicit string) (string, error) {
+	taken := func(ctx context.Context, candidate string) (bool, error) {
+		return s.repo.SlugTaken(ctx, candidate, excludeID)
+	}
+
+	if explicit != "" {
+		value := slug.Make(explicit)
+		if value == "" {
+			return "", errs.ErrSlugInvalid
+		}
+
+		used, err := taken(ctx, value)
+		if err != nil {
+			return "", err
+		}
+		if used {
+			return "", errs.ErrSlugExists
+		}
+		return value, nil
+	}
+
+	base := slug.Make(name)
+	if base == "" {
+		base = "product"
+	}
+	return slug.Unique(ctx, base, taken)
 }
 
 func (s *productService) GetByID(ctx context.Context, id int64) (*Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	return s.repo.GetByID(ctx, id)
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Warehouses, err = s.repo.GetWarehouseStock(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if product.Attributes, err = s.repo.ListAttributes(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if product.Options, err = s.repo.ListOptions(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if product.Variants, err = s.repo.ListVariants(ctx, id, true); err != nil {
+		return nil, err
+	}
+
+	return product, nil
 }
 
-func (s *productService) List(ctx context.Context, filter *ProductFilter) ([]*Product, error) {
+// List returns a page of products matching the filter, with the facets of
+// every matching product.
+func (s *productService) List(ctx context.Context, filter *ProductFilter) (*ProductListResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
@@ -72,40 +196,325 @@ func (s *productService) List(ctx context.Context, filter *ProductFilter) ([]*Pr
 		offset = int(*filter.Offset)
 	}
 
+	// Price bounds are in the display currency
+	currency := money.DefaultCurrency
+	if filter.Currency != nil && *filter.Currency != "" {
+		currency = strings.ToUpper(*filter.Currency)
+	}
+
+	// Bounds go to SQL as plain decimals, money.Parse rejects hex, NaN and Inf
+	priceMin, err := parsePriceBound(filter.PriceMin, currency)
+	if err != nil {
+		return nil, err
+	}
+	priceMax, err := parsePriceBound(filter.PriceMax, currency)
+	if err != nil {
+		return nil, err
+	}
+
 	params := &ProductListParams{
-		CategoryID: *filter.CategoryID,
-		OrderBy:    filter.OrderBy,
-		Sort:       filter.Sort,
-		Limit:      limit,
-		Offset:     offset,
+		CategoryID:    *filter.CategoryID,
+		OrderBy:       filter.OrderBy,
+		Sort:          filter.Sort,
+		Limit:         limit,
+		Offset:        offset,
+		PriceMin:      priceMin,
+		PriceMax:      priceMax,
+		PriceCurrency: currency,
+		InStock:       filter.InStock,
+		Attributes:    filter.Attributes,
+
+		IncludeDescendants: filter.IncludeDescendants,
+	}
+
+	if filter.Cursor != nil {
+		params.Cursor = *filter.Cursor
+	}
+
+	products, page, err := s.repo.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Total, err = s.repo.Count(ctx, params); err != nil {
+		return nil, err
+	}
+
+	facets, err := s.repo.Facets(ctx, params)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.List(ctx, params)
+	// Display prices in the requested currency
+	if filter.Currency != nil && *filter.Currency != "" {
+		conv := s.rateSrv.NewConverter(*filter.Currency)
+		for _, p := range products {
+			if p.Price, err = conv.Convert(ctx, p.Price); err != nil {
+				return nil, err
+			}
+		}
+		for _, pf := range facets.Prices {
+			if pf.Min, err = conv.Convert(ctx, pf.Min); err != nil {
+				return nil, err
+			}
+			if pf.Max, err = conv.Convert(ctx, pf.Max); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &ProductListResponse{Products: products, Facets: facets, Page: page}, nil
 }
 
-func (s *productService) UpdateStock(ctx context.Context, id int64, stock int) error {
+// parsePriceBound reads a price_min or price_max filter as a decimal in the
+// currency, nil when it is not set.
+func parsePriceBound(value *string, currency string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	m, err := money.Parse(*value, currency)
+	if err != nil {
+		return nil, errs.ErrInvalidPriceFilter
+	}
+
+	bound := m.String()
+	return &bound, nil
+}
+
+// Search ranks products matching the words of the query, the last word may be
+// incomplete, typos in the name are tolerated.
+func (s *productService) Search(ctx context.Context, filter *ProductSearchFilter) ([]*ProductSearchResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	if stock <= 0 {
-		return errs.ErrProductOutOfStock
+	text := strings.TrimSpace(filter.Query)
+	tsQuery := prefixTSQuery(text)
+	if tsQuery == "" {
+		return nil, errs.ErrSearchQueryRequired
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	results, err := s.repo.Search(ctx, &ProductSearchParams{
+		TSQuery: tsQuery,
+		Text:    text,
+		Filter: &ProductListParams{
+			CategoryID: filter.CategoryID,
+			InStock:    filter.InStock,
+			Limit:      filter.Limit,
+			Offset:     filter.Offset,
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.UpdateStock(ctx, id, stock)
+	// Queries that found something feed the suggestions, later pages are
+	// the same search
+	if len(results) > 0 && filter.Offset == 0 {
+		s.tracker.Log(normalizeQuery(text))
+	}
+
+	// Display prices in the requested currency
+	if filter.Currency != "" {
+		conv := s.rateSrv.NewConverter(filter.Currency)
+		for _, res := range results {
+			if res.Price, err = conv.Convert(ctx, res.Price); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return results, nil
 }
 
-func (s *productService) DeductStock(ctx context.Context, exec database.DBExec, productID int64, qty int) (bool, error) {
+// Suggest returns autocomplete entries for a partly typed query, hot
+// prefixes are answered from the cache.
+func (s *productService) Suggest(ctx context.Context, query string, limit int) (*Suggestions, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	return s.repo.DeductStock(ctx, exec, productID, qty)
+	prefix := normalizeQuery(query)
+	if prefix == "" {
+		return nil, errs.ErrSearchQueryRequired
+	}
+
+	if limit <= 0 || limit > maxSuggestions {
+		limit = defaultSuggestions
+	}
+
+	key := fmt.Sprintf("%d:%s", limit, prefix)
+	if cached, ok := s.tracker.cache.get(key); ok {
+		return cached, nil
+	}
+
+	suggestions, err := s.repo.Suggest(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.tracker.cache.set(key, suggestions)
+
+	return suggestions, nil
+}
+
+const (
+	defaultSuggestions = 5
+	maxSuggestions     = 10
+)
+
+// prefixTSQuery turns free text into a tsquery matching every word as a
+// prefix, e.g. "red sho" becomes "red:* & sho:*". Anything but letters and
+// digits separates words, so the result is always a valid query.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
 }
 
-func (s *productService) Update(ctx context.Context, id int64, req *ProductUpdate) error {
+func (s *productService) UpdateStock(ctx context.Context, id int64, req *ProductUpdateStock, actorID string) error {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	return s.repo.Update(ctx, id, req)
+	if req.Quantity == 0 {
+		return errs.ErrQuantityIsZero
+	}
+
+	variant, err := s.resolveVariant(ctx, id, req.VariantID, false)
+	if err != nil {
+		return err
+	}
+
+	change := &StockChange{
+		Reason:      MovementAdjustment,
+		WarehouseID: req.WarehouseID,
+		ActorID:     actorRef(actorID),
+		Note:        req.Note,
+	}
+	err = s.tx.Transaction(ctx, func(tx *sql.Tx) error {
+		return s.repo.UpdateStock(ctx, tx, variant.ID, req.Quantity, change)
+	})
+	if err != nil {
+		return err
+	}
+
+	if req.Quantity > 0 {
+		s.notifyBackInStock(ctx, id)
+	}
+
+	return nil
+}
+
+func (s *productService) DeductStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.DeductStock(ctx, exec, variantID, qty, change)
+}
+
+func (s *productService) RestoreStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if qty <= 0 {
+		return errs.ErrQuantityIsZero
+	}
+
+	return s.repo.RestoreStock(ctx, exec, variantID, qty, change)
+}
+
+func (s *productService) Update(ctx context.Context, id int64, req *ProductUpdate, actorID string) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if req.Slug != nil && *req.Slug == "" {
+		return errs.ErrSlugInvalid
+	}
+
+	// Setting the stock goes through the ledger as an adjustment of the
+	// default warehouse, only for single-variant products
+	var variant *ProductVariant
+	if req.Stock != nil {
+		var err error
+		if variant, err = s.resolveVariant(ctx, id, nil, false); err != nil {
+			return err
+		}
+	}
+
+	// The stock, the slug and the other fields change together or not at all
+	restocked := false
+	err := s.tx.Transaction(ctx, func(tx *sql.Tx) error {
+		if variant != nil {
+			if delta := *req.Stock - variant.Stock; delta != 0 {
+				change := &StockChange{Reason: MovementAdjustment, ActorID: actorRef(actorID)}
+				if err := s.repo.UpdateStock(ctx, tx, variant.ID, delta, change); err != nil {
+					return err
+				}
+				restocked = delta > 0
+			}
+		}
+
+		if req.Slug != nil || req.Name != nil {
+			if err := s.updateSlug(ctx, tx, id, req); err != nil {
+				return err
+			}
+		}
+
+		fields := *req
+		fields.Stock, fields.Slug = nil, nil
+		if fields == (ProductUpdate{}) && (req.Slug != nil || req.Stock != nil) {
+			return nil
+		}
+
+		return s.repo.Update(ctx, tx, id, &fields)
+	})
+	if err != nil {
+		return err
+	}
+
+	if restocked {
+		s.notifyBackInStock(ctx, id)
+	}
+
+	return nil
+}
+
+// updateSlug sets the slug given in req, or one made from the new name while
+// the current slug is a generated one, a slug set by hand stays on rename.
+// The old slug keeps resolving through the slug history.
+func (s *productService) updateSlug(ctx context.Context, tx *sql.Tx, id int64, req *ProductUpdate) error {
+	custom, err := s.repo.LockSlug(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if req.Slug == nil {
+		if custom {
+			return nil
+		}
+
+		value, err := s.newSlug(ctx, id, *req.Name, "")
+		if err != nil {
+			return err
+		}
+		return s.repo.SetSlug(ctx, tx, id, value, false)
+	}
+
+	value, err := s.newSlug(ctx, id, "", *req.Slug)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetSlug(ctx, tx, id, value, true)
 }
 
 func (s *productService) Delete(ctx context.Context, id int64) error {
@@ -142,3 +551,379 @@ func (s *productService) DelCategoryByProduct(ctx context.Context, productID, ca
 
 	return s.repo.DelCategoryByProduct(ctx, productID, categoryID)
 }
+
+func (s *productService) ListMovements(ctx context.Context, filter *MovementFilter) ([]*InventoryMovement, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if _, err := s.repo.GetByID(ctx, filter.ProductID); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.ListMovements(ctx, filter)
+}
+
+func (s *productService) ListLowStock(ctx context.Context, filter *LowStockFilter) ([]*Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.ListLowStock(ctx, filter)
+}
+
+// Subscribe asks to notify the user once the out of stock product is back.
+func (s *productService) Subscribe(ctx context.Context, productID int64, userID string) (*StockSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Available > 0 {
+		return nil, errs.ErrProductInStock
+	}
+
+	sub := &StockSubscription{ProductID: productID, UserID: userID}
+	if err = s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// notifyBackInStock queues a notification to every subscriber once the
+// product is available again. The stock change is already saved, failures
+// are only logged and leave the subscriptions pending for the next restock.
+func (s *productService) notifyBackInStock(ctx context.Context, productID int64) {
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		log.Printf("back in stock product %d: %v", productID, err)
+		return
+	}
+
+	if product.Available <= 0 {
+		return
+	}
+
+	// Claimed and queued together, a failed notification releases the claim
+	err = s.tx.Transaction(ctx, func(tx *sql.Tx) error {
+		subs, err := s.repo.ClaimSubscriptions(ctx, tx, productID)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range subs {
+			_, err = s.notifySrv.NotifyUser(ctx, tx, &notifications.NotifyRequest{
+				UserID:  sub.UserID,
+				Subject: fmt.Sprintf("%s is back in stock", product.Name),
+				Body:    fmt.Sprintf("%s is available again, %d left.", product.Name, product.Available),
+				RefType: "stock_subscription",
+				RefID:   sub.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("subscription %d: %w", sub.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("back in stock product %d: %v", productID, err)
+	}
+}
+
+func (s *productService) CreateOption(ctx context.Context, productID int64, req *OptionCreate) (*ProductOption, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	option := &ProductOption{ProductID: productID, Name: req.Name, Position: req.Position}
+
+	seen := make(map[string]bool, len(req.Values))
+	for _, v := range req.Values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		option.Values = append(option.Values, &ProductOptionValue{Value: v, Position: len(option.Values)})
+	}
+
+	if err := s.repo.CreateOption(ctx, option); err != nil {
+		return nil, err
+	}
+
+	return option, nil
+}
+
+func (s *productService) DeleteOption(ctx context.Context, productID, optionID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.DeleteOption(ctx, productID, optionID)
+}
+
+func (s *productService) ListVariants(ctx context.Context, productID int64) ([]*ProductVariant, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListVariants(ctx, productID, false)
+}
+
+// CreateVariant adds a variant with exactly one value of every product option,
+// no two variants of a product share the same values.
+func (s *productService) CreateVariant(ctx context.Context, productID int64, req *VariantCreate) (*ProductVariant, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Price != nil && req.Price.CurrencyCode() != product.Price.CurrencyCode() {
+		return nil, errs.ErrVariantCurrency
+	}
+
+	options, err := s.repo.ListOptions(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Options) != len(options) {
+		return nil, errs.ErrVariantOptionsInvalid
+	}
+
+	var valueIDs []int64
+	for _, o := range options {
+		value, ok := req.Options[o.Name]
+		if !ok {
+			return nil, errs.ErrVariantOptionsInvalid
+		}
+
+		var valueID int64
+		for _, v := range o.Values {
+			if v.Value == value {
+				valueID = v.ID
+				break
+			}
+		}
+		if valueID == 0 {
+			return nil, errs.ErrVariantOptionsInvalid
+		}
+		valueIDs = append(valueIDs, valueID)
+	}
+
+	existing, err := s.repo.ListVariants(ctx, productID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without options a product has a single variant
+	var defaultVariant *ProductVariant
+	for _, v := range existing {
+		if sameOptions(v.Options, req.Options) {
+			return nil, errs.ErrVariantExists
+		}
+		if len(v.Options) == 0 {
+			defaultVariant = v
+		}
+	}
+
+	variant := &ProductVariant{
+		ProductID: productID,
+		SKU:       req.SKU,
+		Price:     req.Price,
+		ImageURL:  req.ImageURL,
+		Options:   req.Options,
+	}
+
+	// The first variant with options takes over the default variant, with
+	// its stock and history, so no variant without options is left to pick
+	if defaultVariant != nil {
+		variant.ID = defaultVariant.ID
+		if err = s.repo.ConvertVariant(ctx, variant, valueIDs); err != nil {
+			return nil, err
+		}
+		return variant, nil
+	}
+
+	if err = s.repo.CreateVariant(ctx, variant, valueIDs); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+func sameOptions(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *productService) UpdateVariant(ctx context.Context, productID, variantID int64, req *VariantUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	variant, err := s.productVariant(ctx, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	if req.Price != nil && !req.ClearPrice {
+		product, err := s.repo.GetByID(ctx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+		if req.Price.CurrencyCode() != product.Price.CurrencyCode() {
+			return errs.ErrVariantCurrency
+		}
+	}
+
+	return s.repo.UpdateVariant(ctx, variantID, req)
+}
+
+func (s *productService) DeleteVariant(ctx context.Context, productID, variantID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if _, err := s.productVariant(ctx, productID, variantID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteVariant(ctx, variantID)
+}
+
+// ResolveVariant returns the active variant a customer picked. The variant is
+// only optional for products with a single active variant.
+func (s *productService) ResolveVariant(ctx context.Context, productID int64, variantID *int64) (*ProductVariant, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.resolveVariant(ctx, productID, variantID, true)
+}
+
+func (s *productService) resolveVariant(ctx context.Context, productID int64, variantID *int64, activeOnly bool) (*ProductVariant, error) {
+	if variantID != nil {
+		variant, err := s.productVariant(ctx, productID, *variantID)
+		if err != nil {
+			return nil, err
+		}
+		if activeOnly && !variant.IsActive {
+			return nil, errs.ErrVariantNotFound
+		}
+		return variant, nil
+	}
+
+	variants, err := s.repo.ListVariants(ctx, productID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(variants) {
+	case 0:
+		if _, err = s.repo.GetByID(ctx, productID); err != nil {
+			return nil, err
+		}
+		return nil, errs.ErrVariantNotFound
+	case 1:
+		return variants[0], nil
+	}
+
+	return nil, errs.ErrVariantRequired
+}
+
+// productVariant returns the variant when it belongs to the product.
+func (s *productService) productVariant(ctx context.Context, productID, variantID int64) (*ProductVariant, error) {
+	variant, err := s.repo.GetVariant(ctx, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if variant.ProductID != productID {
+		return nil, errs.ErrVariantNotFound
+	}
+
+	return variant, nil
+}
+
+// SetAttributes sets the attribute values of the product and returns all its
+// attributes. Each value has to match the attribute type, null removes it.
+func (s *productService) SetAttributes(ctx context.Context, productID int64, req *ProductAttributesSet) ([]*ProductAttribute, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	codes := make([]string, 0, len(req.Attributes))
+	for code := range req.Attributes {
+		codes = append(codes, code)
+	}
+
+	defs, err := s.repo.GetAttributeDefs(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []*ProductAttribute
+	var removeIDs []int64
+	for code, value := range req.Attributes {
+		def, ok := defs[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errs.ErrAttributeNotFound, code)
+		}
+
+		if value == nil {
+			removeIDs = append(removeIDs, def.AttributeID)
+			continue
+		}
+
+		if value, ok = attributeValue(attributes.AttributeType(def.Type), value); !ok {
+			return nil, fmt.Errorf("%w: %s must be %s", errs.ErrAttributeValueInvalid, code, def.Type)
+		}
+		values = append(values, &ProductAttribute{AttributeID: def.AttributeID, Value: value})
+	}
+
+	if err = s.repo.SetAttributes(ctx, productID, values, removeIDs); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListAttributes(ctx, productID)
+}
+
+// attributeValue checks a decoded JSON value against the attribute type.
+func attributeValue(typ attributes.AttributeType, value any) (any, bool) {
+	switch typ {
+	case attributes.TypeText:
+		v, ok := value.(string)
+		v = strings.TrimSpace(v)
+		return v, ok && v != "" && len(v) <= 255
+	case attributes.TypeNumber:
+		v, ok := value.(float64)
+		return v, ok
+	case attributes.TypeBoolean:
+		v, ok := value.(bool)
+		return v, ok
+	}
+	return nil, false
+}
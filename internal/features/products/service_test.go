@@ -0,0 +1,158 @@
+package products
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/database/dbtest"
+	"github.com/codepnw/core-ecommerce-system/internal/features/notifications"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+func TestParsePriceBound(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     string
+		wantErr  bool
+	}{
+		{name: "whole", value: "100", currency: "THB", want: "100.00"},
+		{name: "decimals", value: "19.999", currency: "USD", want: "20.00"},
+		{name: "zero decimal currency", value: "1500", currency: "JPY", want: "1500"},
+		{name: "hex float", value: "0x1p3", currency: "THB", wantErr: true},
+		{name: "exponent", value: "1e3", currency: "THB", wantErr: true},
+		{name: "nan", value: "NaN", currency: "THB", wantErr: true},
+		{name: "inf", value: "Inf", currency: "THB", wantErr: true},
+		{name: "empty", value: "", currency: "THB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePriceBound(&tt.value, tt.currency)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrInvalidPriceFilter) {
+					t.Fatalf("parsePriceBound(%q) error = %v, want ErrInvalidPriceFilter", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePriceBound(%q) error = %v", tt.value, err)
+			}
+			if *got != tt.want {
+				t.Errorf("parsePriceBound(%q) = %q, want %q", tt.value, *got, tt.want)
+			}
+		})
+	}
+
+	if got, err := parsePriceBound(nil, "THB"); got != nil || err != nil {
+		t.Errorf("parsePriceBound(nil) = %v, %v, want nil, nil", got, err)
+	}
+}
+
+// fakeProductRepo has one product in stock with a single variant and two
+// pending subscriptions, it records the transaction of each write.
+type fakeProductRepo struct {
+	IProductRepository
+
+	claimTx   *sql.Tx
+	stockErr  error
+	stockExec database.DBExec
+	fieldExec database.DBExec
+}
+
+func (r *fakeProductRepo) ListVariants(ctx context.Context, productID int64, activeOnly bool) ([]*ProductVariant, error) {
+	return []*ProductVariant{{ID: 10, ProductID: productID, Stock: 3}}, nil
+}
+
+func (r *fakeProductRepo) UpdateStock(ctx context.Context, exec database.DBExec, variantID int64, qty int, change *StockChange) error {
+	r.stockExec = exec
+	return r.stockErr
+}
+
+func (r *fakeProductRepo) Update(ctx context.Context, exec database.DBExec, id int64, input *ProductUpdate) error {
+	r.fieldExec = exec
+	return nil
+}
+
+func (r *fakeProductRepo) GetByID(ctx context.Context, id int64) (*Product, error) {
+	return &Product{ID: id, Name: "Mug", Available: 3}, nil
+}
+
+func (r *fakeProductRepo) ClaimSubscriptions(ctx context.Context, tx *sql.Tx, productID int64) ([]*StockSubscription, error) {
+	r.claimTx = tx
+	return []*StockSubscription{{ID: 1, UserID: "u1"}, {ID: 2, UserID: "u2"}}, nil
+}
+
+// fakeNotifier records where each notification was queued.
+type fakeNotifier struct {
+	notifications.INotificationService
+
+	execs []database.DBExec
+}
+
+func (n *fakeNotifier) NotifyUser(ctx context.Context, exec database.DBExec, req *notifications.NotifyRequest) (*notifications.Notification, error) {
+	n.execs = append(n.execs, exec)
+	return &notifications.Notification{}, nil
+}
+
+func TestNotifyBackInStock(t *testing.T) {
+	repo := &fakeProductRepo{}
+	notifier := &fakeNotifier{}
+	s := &productService{repo: repo, notifySrv: notifier, tx: database.NewTxManager(dbtest.Open(t))}
+
+	s.notifyBackInStock(context.Background(), 1)
+
+	if repo.claimTx == nil {
+		t.Fatal("subscriptions claimed outside a transaction")
+	}
+	if len(notifier.execs) != 2 {
+		t.Fatalf("queued %d notifications, want 2", len(notifier.execs))
+	}
+	// Queued in the claim's transaction, a failure releases the claim
+	for i, exec := range notifier.execs {
+		if tx, ok := exec.(*sql.Tx); !ok || tx != repo.claimTx {
+			t.Errorf("notification %d queued on %T, want the claim transaction", i, exec)
+		}
+	}
+}
+
+func TestUpdateWithStock(t *testing.T) {
+	stock, description := 1, "Holds 350 ml"
+
+	tests := []struct {
+		name     string
+		stockErr error
+	}{
+		{name: "saved together"},
+		{name: "stock change rejected", stockErr: errs.ErrProductOutOfStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProductRepo{stockErr: tt.stockErr}
+			s := &productService{repo: repo, tx: database.NewTxManager(dbtest.Open(t))}
+
+			err := s.Update(context.Background(), 1, &ProductUpdate{Stock: &stock, Description: &description}, "staff")
+			if !errors.Is(err, tt.stockErr) {
+				t.Fatalf("Update error = %v, want %v", err, tt.stockErr)
+			}
+
+			if _, ok := repo.stockExec.(*sql.Tx); !ok {
+				t.Fatalf("stock changed on %T, want a transaction", repo.stockExec)
+			}
+			if tt.stockErr != nil {
+				if repo.fieldExec != nil {
+					t.Error("fields updated after the stock change failed")
+				}
+				return
+			}
+			if repo.fieldExec != repo.stockExec {
+				t.Error("stock and fields updated in different transactions")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package products
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+)
+
+// ------------ Search Tracker ------------
+
+// queryLogBuffer is how many searches wait for the next flush, more are
+// dropped rather than slowing down requests.
+const queryLogBuffer = 1024
+
+// SearchTracker counts searched queries in the background and caches the
+// suggestions of hot prefixes. It is shared by every product service, Log
+// never blocks and RunQueryLog writes the counts every interval.
+type SearchTracker struct {
+	repo    IProductRepository
+	queries chan string
+	cache   *suggestCache
+}
+
+func NewSearchTracker(repo IProductRepository, cacheTTL time.Duration) *SearchTracker {
+	return &SearchTracker{
+		repo:    repo,
+		queries: make(chan string, queryLogBuffer),
+		cache:   newSuggestCache(cacheTTL),
+	}
+}
+
+// Log records a normalized query, it is dropped when the buffer is full.
+func (t *SearchTracker) Log(query string) {
+	select {
+	case t.queries <- query:
+	default:
+	}
+}
+
+// RunQueryLog writes the logged queries every interval until ctx is done,
+// what is left is written before returning.
+func RunQueryLog(ctx context.Context, t *SearchTracker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	counts := make(map[string]int)
+	flush := func(ctx context.Context) {
+		if len(counts) == 0 {
+			return
+		}
+		if err := t.repo.LogQueries(ctx, counts); err != nil {
+			log.Printf("search query log: %v", err)
+		}
+		counts = make(map[string]int)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), consts.ContextTimeout)
+			flush(flushCtx)
+			cancel()
+			return
+		case q := <-t.queries:
+			counts[q]++
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// maxQueryLength is the longest query kept, matching search_queries.query.
+const maxQueryLength = 100
+
+// normalizeQuery lower-cases the query and collapses whitespace, so the same
+// search is counted and cached once.
+func normalizeQuery(q string) string {
+	q = strings.Join(strings.Fields(strings.ToLower(q)), " ")
+	if r := []rune(q); len(r) > maxQueryLength {
+		q = string(r[:maxQueryLength])
+	}
+	return q
+}
+
+// ------------ Suggestion Cache ------------
+
+// suggestCacheSize bounds the cached prefixes, expired entries are dropped
+// first, then the whole cache once it is still full.
+const suggestCacheSize = 1000
+
+type suggestEntry struct {
+	value     *Suggestions
+	expiresAt time.Time
+}
+
+// suggestCache keeps the suggestions of hot prefixes in memory for ttl.
+type suggestCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]suggestEntry
+}
+
+func newSuggestCache(ttl time.Duration) *suggestCache {
+	return &suggestCache{ttl: ttl, entries: make(map[string]suggestEntry)}
+}
+
+func (c *suggestCache) get(prefix string) (*Suggestions, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[prefix]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *suggestCache) set(prefix string, value *Suggestions) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= suggestCacheSize {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= suggestCacheSize {
+			c.entries = make(map[string]suggestEntry)
+		}
+	}
+
+	c.entries[prefix] = suggestEntry{value: value, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,55 @@
+package promotions
+
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFixed      DiscountType = "fixed"
+)
+
+type CouponCreate struct {
+	Code         string       `json:"code" validate:"required,min=3,max=50"`
+	Description  string       `json:"description,omitempty" validate:"omitempty"`
+	DiscountType DiscountType `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	// DiscountPercent for percentage coupons, DiscountAmount for fixed ones,
+	// its currency is the coupon currency
+	DiscountPercent *float64     `json:"discount_percent,omitempty" validate:"omitempty,gt=0,lte=100"`
+	DiscountAmount  *money.Money `json:"discount_amount,omitempty" validate:"omitempty,gt=0"`
+	MinOrderValue   money.Money  `json:"min_order_value,omitempty" validate:"omitempty,gte=0"`
+	MaxUses         *int         `json:"max_uses,omitempty" validate:"omitempty,gt=0"`
+	MaxUsesPerUser  *int         `json:"max_uses_per_user,omitempty" validate:"omitempty,gt=0"`
+	StartsAt        *time.Time   `json:"starts_at,omitempty" validate:"omitempty"`
+	EndsAt          *time.Time   `json:"ends_at,omitempty" validate:"omitempty"`
+	ProductIDs      []int64      `json:"product_ids,omitempty" validate:"omitempty"`
+	CategoryIDs     []int64      `json:"category_ids,omitempty" validate:"omitempty"`
+}
+
+type CouponUpdate struct {
+	Description    *string      `json:"description,omitempty" validate:"omitempty"`
+	MinOrderValue  *money.Money `json:"min_order_value,omitempty" validate:"omitempty,gte=0"`
+	MaxUses        *int         `json:"max_uses,omitempty" validate:"omitempty,gt=0"`
+	MaxUsesPerUser *int         `json:"max_uses_per_user,omitempty" validate:"omitempty,gt=0"`
+	StartsAt       *time.Time   `json:"starts_at,omitempty" validate:"omitempty"`
+	EndsAt         *time.Time   `json:"ends_at,omitempty" validate:"omitempty"`
+	IsActive       *bool        `json:"is_active,omitempty" validate:"omitempty"`
+}
+
+// CouponLine is a cart line the coupon is checked against.
+type CouponLine struct {
+	ProductID int64
+	Price     money.Money
+	Quantity  int64
+}
+
+// CouponResult is the discount a coupon gives for a cart, used to redeem it after the order is created.
+type CouponResult struct {
+	CouponID int64
+	Code     string
+	Discount money.Money
+}
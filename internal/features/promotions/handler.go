@@ -0,0 +1,118 @@
+package promotions
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const couponIDKey = "coupon_id"
+
+type promotionHandler struct {
+	srv IPromotionService
+}
+
+func NewPromotionHandler(srv IPromotionService) *promotionHandler {
+	return &promotionHandler{srv: srv}
+}
+
+func (h *promotionHandler) CreateCoupon(ctx *fiber.Ctx) error {
+	req := new(CouponCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	created, err := h.srv.CreateCoupon(ctx.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrCouponInvalidDiscount),
+			errors.Is(err, errs.ErrCouponDiscountValue),
+			errors.Is(err, errs.ErrCouponCurrency):
+			return response.BadRequest(ctx, err.Error())
+		case errors.Is(err, errs.ErrCouponCodeExists):
+			return response.Conflict(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Created(ctx, "coupon created", created)
+}
+
+func (h *promotionHandler) GetCoupon(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, couponIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	coupon, err := h.srv.GetCoupon(ctx.Context(), id)
+	if err != nil {
+		if errors.Is(err, errs.ErrCouponNotFound) {
+			return response.NotFound(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", coupon)
+}
+
+func (h *promotionHandler) ListCoupons(ctx *fiber.Ctx) error {
+	coupons, err := h.srv.ListCoupons(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", coupons)
+}
+
+func (h *promotionHandler) UpdateCoupon(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, couponIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(CouponUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err = h.srv.UpdateCoupon(ctx.Context(), id, req); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrNoFieldUpdate),
+			errors.Is(err, errs.ErrCouponCurrency):
+			return response.BadRequest(ctx, err.Error())
+		case errors.Is(err, errs.ErrCouponNotFound):
+			return response.NotFound(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "coupon updated", nil)
+}
+
+func (h *promotionHandler) DeleteCoupon(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, couponIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err = h.srv.DeleteCoupon(ctx.Context(), id); err != nil {
+		if errors.Is(err, errs.ErrCouponNotFound) {
+			return response.NotFound(ctx, err.Error())
+		}
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "coupon deleted", nil)
+}
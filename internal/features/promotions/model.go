@@ -0,0 +1,39 @@
+package promotions
+
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type Coupon struct {
+	ID           int64  `json:"id"`
+	Code         string `json:"code"`
+	Description  string `json:"description"`
+	DiscountType string `json:"discount_type"`
+	// DiscountPercent is set on percentage coupons, DiscountAmount on fixed ones
+	DiscountPercent *float64     `json:"discount_percent,omitempty"`
+	DiscountAmount  *money.Money `json:"discount_amount,omitempty"`
+	// Currency of DiscountAmount and MinOrderValue
+	Currency       string      `json:"currency"`
+	MinOrderValue  money.Money `json:"min_order_value"`
+	MaxUses        *int        `json:"max_uses"`
+	MaxUsesPerUser *int        `json:"max_uses_per_user"`
+	UsedCount      int         `json:"used_count"`
+	StartsAt       *time.Time  `json:"starts_at"`
+	EndsAt         *time.Time  `json:"ends_at"`
+	IsActive       bool        `json:"is_active"`
+	ProductIDs     []int64     `json:"product_ids"`
+	CategoryIDs    []int64     `json:"category_ids"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type CouponRedemption struct {
+	ID             int64       `json:"id"`
+	CouponID       int64       `json:"coupon_id"`
+	UserID         string      `json:"user_id"`
+	OrderID        int64       `json:"order_id"`
+	DiscountAmount money.Money `json:"discount_amount"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
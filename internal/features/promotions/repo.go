@@ -0,0 +1,367 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/lib/pq"
+)
+
+const (
+	selectCouponQuery = `
+		SELECT id, code, COALESCE(description, ''), discount_type, discount_percent, discount_amount,
+			currency, min_order_value,
+			max_uses, max_uses_per_user, used_count, starts_at, ends_at, is_active, created_at, updated_at
+		FROM coupons
+	`
+)
+
+type IPromotionRepository interface {
+	// Coupons
+	Create(ctx context.Context, tx *sql.Tx, input *Coupon) error
+	GetByID(ctx context.Context, id int64) (*Coupon, error)
+	GetByCodeForUpdate(ctx context.Context, tx *sql.Tx, code string) (*Coupon, error)
+	List(ctx context.Context) ([]*Coupon, error)
+	Update(ctx context.Context, id int64, input *CouponUpdate) error
+	Delete(ctx context.Context, id int64) error
+	IncrementUsage(ctx context.Context, tx *sql.Tx, id int64) error
+
+	// Coupon scope
+	InsertScope(ctx context.Context, tx *sql.Tx, couponID int64, productIDs, categoryIDs []int64) error
+	GetScope(ctx context.Context, exec database.DBExec, coupon *Coupon) error
+	GetEligibleProductIDs(ctx context.Context, exec database.DBExec, couponID int64, productIDs []int64) (map[int64]bool, error)
+
+	// Coupon redemptions
+	CountUserRedemptions(ctx context.Context, exec database.DBExec, couponID int64, userID string) (int, error)
+	InsertRedemption(ctx context.Context, tx *sql.Tx, input *CouponRedemption) error
+}
+
+type promotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) IPromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+// ------------ Table coupons ------------
+
+func (r *promotionRepository) Create(ctx context.Context, tx *sql.Tx, input *Coupon) error {
+	query := `
+		INSERT INTO coupons (code, description, discount_type, discount_percent, discount_amount,
+			currency, min_order_value, max_uses, max_uses_per_user, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, used_count, is_active, created_at, updated_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.Code,
+		input.Description,
+		input.DiscountType,
+		input.DiscountPercent,
+		input.DiscountAmount,
+		input.Currency,
+		input.MinOrderValue,
+		input.MaxUses,
+		input.MaxUsesPerUser,
+		input.StartsAt,
+		input.EndsAt,
+	).Scan(
+		&input.ID,
+		&input.UsedCount,
+		&input.IsActive,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *promotionRepository) GetByID(ctx context.Context, id int64) (*Coupon, error) {
+	query := fmt.Sprintf("%s WHERE id = $1", selectCouponQuery)
+	return r.getCoupon(ctx, r.db, query, id)
+}
+
+func (r *promotionRepository) GetByCodeForUpdate(ctx context.Context, tx *sql.Tx, code string) (*Coupon, error) {
+	query := fmt.Sprintf("%s WHERE upper(code) = upper($1) FOR UPDATE", selectCouponQuery)
+	return r.getCoupon(ctx, tx, query, code)
+}
+
+func (r *promotionRepository) getCoupon(ctx context.Context, exec database.DBExec, query string, arg any) (*Coupon, error) {
+	c, err := scanCoupon(exec.QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrCouponNotFound
+		}
+		return nil, err
+	}
+
+	if err = r.GetScope(ctx, exec, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *promotionRepository) List(ctx context.Context) ([]*Coupon, error) {
+	query := fmt.Sprintf("%s ORDER BY created_at DESC", selectCouponQuery)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coupons []*Coupon
+	for rows.Next() {
+		c, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, c)
+	}
+
+	return coupons, rows.Err()
+}
+
+func (r *promotionRepository) Update(ctx context.Context, id int64, input *CouponUpdate) error {
+	query, args, err := r.buildUpdateQuery(id, input)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrCouponNotFound
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) buildUpdateQuery(id int64, c *CouponUpdate) (string, []any, error) {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if c.Description != nil {
+		columns = append(columns, fmt.Sprintf("description = $%d", idx))
+		args = append(args, *c.Description)
+		idx++
+	}
+
+	if c.MinOrderValue != nil {
+		columns = append(columns, fmt.Sprintf("min_order_value = $%d", idx))
+		args = append(args, *c.MinOrderValue)
+		idx++
+	}
+
+	if c.MaxUses != nil {
+		columns = append(columns, fmt.Sprintf("max_uses = $%d", idx))
+		args = append(args, *c.MaxUses)
+		idx++
+	}
+
+	if c.MaxUsesPerUser != nil {
+		columns = append(columns, fmt.Sprintf("max_uses_per_user = $%d", idx))
+		args = append(args, *c.MaxUsesPerUser)
+		idx++
+	}
+
+	if c.StartsAt != nil {
+		columns = append(columns, fmt.Sprintf("starts_at = $%d", idx))
+		args = append(args, *c.StartsAt)
+		idx++
+	}
+
+	if c.EndsAt != nil {
+		columns = append(columns, fmt.Sprintf("ends_at = $%d", idx))
+		args = append(args, *c.EndsAt)
+		idx++
+	}
+
+	if c.IsActive != nil {
+		columns = append(columns, fmt.Sprintf("is_active = $%d", idx))
+		args = append(args, *c.IsActive)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return "", nil, errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE coupons SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return query, args, nil
+}
+
+func (r *promotionRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM coupons WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrCouponNotFound
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) IncrementUsage(ctx context.Context, tx *sql.Tx, id int64) error {
+	query := `UPDATE coupons SET used_count = used_count + 1, updated_at = now() WHERE id = $1`
+	_, err := tx.ExecContext(ctx, query, id)
+	return err
+}
+
+// ------------ Tables coupon_products, coupon_categories ------------
+
+func (r *promotionRepository) InsertScope(ctx context.Context, tx *sql.Tx, couponID int64, productIDs, categoryIDs []int64) error {
+	if len(productIDs) > 0 {
+		query := `
+			INSERT INTO coupon_products (coupon_id, product_id)
+			SELECT $1, UNNEST($2::BIGINT[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, couponID, pq.Array(productIDs)); err != nil {
+			return err
+		}
+	}
+
+	if len(categoryIDs) > 0 {
+		query := `
+			INSERT INTO coupon_categories (coupon_id, category_id)
+			SELECT $1, UNNEST($2::BIGINT[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, couponID, pq.Array(categoryIDs)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) GetScope(ctx context.Context, exec database.DBExec, coupon *Coupon) error {
+	query := `
+		SELECT
+			COALESCE((SELECT array_agg(product_id) FROM coupon_products WHERE coupon_id = $1), '{}'),
+			COALESCE((SELECT array_agg(category_id) FROM coupon_categories WHERE coupon_id = $1), '{}')
+	`
+	return exec.QueryRowContext(ctx, query, coupon.ID).Scan(
+		pq.Array(&coupon.ProductIDs),
+		pq.Array(&coupon.CategoryIDs),
+	)
+}
+
+// GetEligibleProductIDs returns the products covered by the coupon directly or through product_categories.
+func (r *promotionRepository) GetEligibleProductIDs(ctx context.Context, exec database.DBExec, couponID int64, productIDs []int64) (map[int64]bool, error) {
+	query := `
+		SELECT product_id FROM coupon_products
+		WHERE coupon_id = $1 AND product_id = ANY($2)
+		UNION
+		SELECT pc.product_id FROM coupon_categories cc
+		JOIN product_categories pc ON pc.category_id = cc.category_id
+		WHERE cc.coupon_id = $1 AND pc.product_id = ANY($2)
+	`
+	rows, err := exec.QueryContext(ctx, query, couponID, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	eligible := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		eligible[id] = true
+	}
+
+	return eligible, rows.Err()
+}
+
+// ------------ Table coupon_redemptions ------------
+
+func (r *promotionRepository) CountUserRedemptions(ctx context.Context, exec database.DBExec, couponID int64, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2`
+	err := exec.QueryRowContext(ctx, query, couponID, userID).Scan(&count)
+	return count, err
+}
+
+func (r *promotionRepository) InsertRedemption(ctx context.Context, tx *sql.Tx, input *CouponRedemption) error {
+	query := `
+		INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.CouponID,
+		input.UserID,
+		input.OrderID,
+		input.DiscountAmount,
+	).Scan(
+		&input.ID,
+		&input.CreatedAt,
+	)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCoupon(row rowScanner) (*Coupon, error) {
+	c := new(Coupon)
+	err := row.Scan(
+		&c.ID,
+		&c.Code,
+		&c.Description,
+		&c.DiscountType,
+		&c.DiscountPercent,
+		&c.DiscountAmount,
+		&c.Currency,
+		&c.MinOrderValue,
+		&c.MaxUses,
+		&c.MaxUsesPerUser,
+		&c.UsedCount,
+		&c.StartsAt,
+		&c.EndsAt,
+		&c.IsActive,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Amounts are read with the default currency, relabel them
+	c.MinOrderValue = c.MinOrderValue.WithCurrency(c.Currency)
+	if c.DiscountAmount != nil {
+		amount := c.DiscountAmount.WithCurrency(c.Currency)
+		c.DiscountAmount = &amount
+	}
+
+	return c, nil
+}
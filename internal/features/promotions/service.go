@@ -0,0 +1,236 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type IPromotionService interface {
+	// Coupons
+	CreateCoupon(ctx context.Context, req *CouponCreate) (*Coupon, error)
+	GetCoupon(ctx context.Context, id int64) (*Coupon, error)
+	ListCoupons(ctx context.Context) ([]*Coupon, error)
+	UpdateCoupon(ctx context.Context, id int64, req *CouponUpdate) error
+	DeleteCoupon(ctx context.Context, id int64) error
+
+	// Checkout
+	ApplyCouponTx(ctx context.Context, tx *sql.Tx, code, userID string, lines []*CouponLine, conv *currencies.Converter) (*CouponResult, error)
+	RedeemCouponTx(ctx context.Context, tx *sql.Tx, result *CouponResult, userID string, orderID int64) error
+}
+
+type promotionService struct {
+	repo IPromotionRepository
+	tx   *database.TxManager
+}
+
+func NewPromotionService(repo IPromotionRepository, tx *database.TxManager) IPromotionService {
+	return &promotionService{repo: repo, tx: tx}
+}
+
+func (s *promotionService) CreateCoupon(ctx context.Context, req *CouponCreate) (*Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	switch req.DiscountType {
+	case DiscountPercentage:
+		if req.DiscountPercent == nil || req.DiscountAmount != nil {
+			return nil, errs.ErrCouponDiscountValue
+		}
+		if *req.DiscountPercent > 100 {
+			return nil, errs.ErrCouponInvalidDiscount
+		}
+	case DiscountFixed:
+		if req.DiscountAmount == nil || req.DiscountPercent != nil {
+			return nil, errs.ErrCouponDiscountValue
+		}
+	}
+
+	// The fixed amount sets the coupon currency, the minimum order value
+	// has to be in it too
+	currency := req.MinOrderValue.CurrencyCode()
+	if req.DiscountAmount != nil {
+		currency = req.DiscountAmount.CurrencyCode()
+		if req.MinOrderValue.IsPositive() && req.MinOrderValue.CurrencyCode() != currency {
+			return nil, errs.ErrCouponCurrency
+		}
+	}
+
+	c := &Coupon{
+		Code:            strings.ToUpper(strings.TrimSpace(req.Code)),
+		Description:     req.Description,
+		DiscountType:    string(req.DiscountType),
+		DiscountPercent: req.DiscountPercent,
+		DiscountAmount:  req.DiscountAmount,
+		Currency:        currency,
+		MinOrderValue:   req.MinOrderValue.WithCurrency(currency),
+		MaxUses:         req.MaxUses,
+		MaxUsesPerUser:  req.MaxUsesPerUser,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		ProductIDs:      req.ProductIDs,
+		CategoryIDs:     req.CategoryIDs,
+	}
+
+	err := s.tx.Transaction(ctx, func(tx *sql.Tx) error {
+		if err := s.repo.Create(ctx, tx, c); err != nil {
+			if strings.Contains(err.Error(), "coupons_code_key") {
+				return errs.ErrCouponCodeExists
+			}
+			return err
+		}
+		return s.repo.InsertScope(ctx, tx, c.ID, c.ProductIDs, c.CategoryIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *promotionService) GetCoupon(ctx context.Context, id int64) (*Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *promotionService) ListCoupons(ctx context.Context) ([]*Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.List(ctx)
+}
+
+func (s *promotionService) UpdateCoupon(ctx context.Context, id int64, req *CouponUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if req.MinOrderValue != nil && req.MinOrderValue.IsPositive() {
+		c, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if req.MinOrderValue.CurrencyCode() != c.Currency {
+			return errs.ErrCouponCurrency
+		}
+	}
+
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *promotionService) DeleteCoupon(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.Delete(ctx, id)
+}
+
+// ApplyCouponTx locks the coupon row and returns the discount it gives for the
+// cart lines. Lines are priced in the order currency, conv converts the coupon
+// amounts into it, the discount is in it too.
+func (s *promotionService) ApplyCouponTx(ctx context.Context, tx *sql.Tx, code, userID string, lines []*CouponLine, conv *currencies.Converter) (*CouponResult, error) {
+	c, err := s.repo.GetByCodeForUpdate(ctx, tx, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !c.IsActive || (c.StartsAt != nil && now.Before(*c.StartsAt)) || (c.EndsAt != nil && now.After(*c.EndsAt)) {
+		return nil, errs.ErrCouponInactive
+	}
+
+	// USAGE LIMITS
+	if c.MaxUses != nil && c.UsedCount >= *c.MaxUses {
+		return nil, errs.ErrCouponUsageLimit
+	}
+	if c.MaxUsesPerUser != nil {
+		used, err := s.repo.CountUserRedemptions(ctx, tx, c.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("count coupon redemptions failed: %w", err)
+		}
+		if used >= *c.MaxUsesPerUser {
+			return nil, errs.ErrCouponUsageLimit
+		}
+	}
+
+	// MINIMUM ORDER VALUE
+	subtotal := money.New(0, conv.Currency())
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.Price.Mul(line.Quantity))
+	}
+	minOrder, err := conv.Convert(ctx, c.MinOrderValue)
+	if err != nil {
+		return nil, fmt.Errorf("convert coupon minimum failed: %w", err)
+	}
+	if subtotal.Amount < minOrder.Amount {
+		return nil, errs.ErrCouponMinOrderValue
+	}
+
+	// SCOPE: products and categories
+	eligibleTotal := subtotal
+	if len(c.ProductIDs) > 0 || len(c.CategoryIDs) > 0 {
+		ids := make([]int64, 0, len(lines))
+		for _, line := range lines {
+			ids = append(ids, line.ProductID)
+		}
+
+		eligible, err := s.repo.GetEligibleProductIDs(ctx, tx, c.ID, ids)
+		if err != nil {
+			return nil, fmt.Errorf("get coupon products failed: %w", err)
+		}
+
+		eligibleTotal = money.New(0, subtotal.CurrencyCode())
+		for _, line := range lines {
+			if eligible[line.ProductID] {
+				eligibleTotal = eligibleTotal.Add(line.Price.Mul(line.Quantity))
+			}
+		}
+	}
+	if !eligibleTotal.IsPositive() {
+		return nil, errs.ErrCouponNotApplicable
+	}
+
+	var discount money.Money
+	switch DiscountType(c.DiscountType) {
+	case DiscountPercentage:
+		discount = eligibleTotal.Percent(*c.DiscountPercent)
+	case DiscountFixed:
+		if discount, err = conv.Convert(ctx, *c.DiscountAmount); err != nil {
+			return nil, fmt.Errorf("convert coupon discount failed: %w", err)
+		}
+	}
+	discount = discount.Min(eligibleTotal)
+
+	return &CouponResult{
+		CouponID: c.ID,
+		Code:     c.Code,
+		Discount: discount,
+	}, nil
+}
+
+func (s *promotionService) RedeemCouponTx(ctx context.Context, tx *sql.Tx, result *CouponResult, userID string, orderID int64) error {
+	if err := s.repo.IncrementUsage(ctx, tx, result.CouponID); err != nil {
+		return fmt.Errorf("increment coupon usage failed: %w", err)
+	}
+
+	err := s.repo.InsertRedemption(ctx, tx, &CouponRedemption{
+		CouponID:       result.CouponID,
+		UserID:         userID,
+		OrderID:        orderID,
+		DiscountAmount: result.Discount,
+	})
+	if err != nil {
+		return fmt.Errorf("insert coupon redemption failed: %w", err)
+	}
+
+	return nil
+}
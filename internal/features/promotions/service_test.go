@@ -0,0 +1,132 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+// fakePromotionRepo holds one coupon, eligible stands for the products its
+// product and category scope resolve to.
+type fakePromotionRepo struct {
+	IPromotionRepository
+
+	coupon   *Coupon
+	eligible map[int64]bool
+}
+
+func (r *fakePromotionRepo) GetByCodeForUpdate(ctx context.Context, tx *sql.Tx, code string) (*Coupon, error) {
+	if code != r.coupon.Code {
+		return nil, errs.ErrCouponNotFound
+	}
+	return r.coupon, nil
+}
+
+func (r *fakePromotionRepo) GetEligibleProductIDs(ctx context.Context, exec database.DBExec, couponID int64, productIDs []int64) (map[int64]bool, error) {
+	found := make(map[int64]bool)
+	for _, id := range productIDs {
+		if r.eligible[id] {
+			found[id] = true
+		}
+	}
+	return found, nil
+}
+
+// fakeRates knows one pair, 1 USD buys 35 THB.
+type fakeRates struct {
+	currencies.IExchangeRateRepository
+}
+
+func (fakeRates) Get(ctx context.Context, base, quote string) (*currencies.ExchangeRate, error) {
+	if base == "USD" && quote == "THB" {
+		return &currencies.ExchangeRate{BaseCurrency: base, QuoteCurrency: quote, Rate: 35}, nil
+	}
+	return nil, errs.ErrExchangeRateNotFound
+}
+
+func TestApplyCouponTx(t *testing.T) {
+	thb := func(amount int64) money.Money { return money.New(amount, "THB") }
+	percent := func(p float64) *float64 { return &p }
+	fixed := func(m money.Money) *money.Money { return &m }
+
+	// 200.00 of product 1 and 300.00 of product 2, in category 5
+	lines := []*CouponLine{
+		{ProductID: 1, Price: thb(10000), Quantity: 2},
+		{ProductID: 2, Price: thb(30000), Quantity: 1},
+	}
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		eligible map[int64]bool
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:   "percentage, whole cart",
+			coupon: Coupon{DiscountType: string(DiscountPercentage), DiscountPercent: percent(10)},
+			want:   5000,
+		},
+		{
+			name:     "percentage, product scope",
+			coupon:   Coupon{DiscountType: string(DiscountPercentage), DiscountPercent: percent(10), ProductIDs: []int64{1}},
+			eligible: map[int64]bool{1: true},
+			want:     2000,
+		},
+		{
+			name:     "percentage, category scope",
+			coupon:   Coupon{DiscountType: string(DiscountPercentage), DiscountPercent: percent(10), CategoryIDs: []int64{5}},
+			eligible: map[int64]bool{2: true},
+			want:     3000,
+		},
+		{
+			name:     "fixed, capped at the eligible lines",
+			coupon:   Coupon{DiscountType: string(DiscountFixed), DiscountAmount: fixed(thb(50000)), ProductIDs: []int64{1}},
+			eligible: map[int64]bool{1: true},
+			want:     20000,
+		},
+		{
+			name:   "fixed, converted to the order currency",
+			coupon: Coupon{DiscountType: string(DiscountFixed), DiscountAmount: fixed(money.New(1000, "USD")), MinOrderValue: money.New(0, "USD")},
+			want:   35000,
+		},
+		{
+			name:     "no line in scope",
+			coupon:   Coupon{DiscountType: string(DiscountPercentage), DiscountPercent: percent(10), ProductIDs: []int64{3}},
+			eligible: map[int64]bool{3: true},
+			wantErr:  errs.ErrCouponNotApplicable,
+		},
+		{
+			name:    "below the minimum order value",
+			coupon:  Coupon{DiscountType: string(DiscountPercentage), DiscountPercent: percent(10), MinOrderValue: thb(60000)},
+			wantErr: errs.ErrCouponMinOrderValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := tt.coupon
+			coupon.ID, coupon.Code, coupon.IsActive = 1, "SAVE", true
+
+			s := &promotionService{repo: &fakePromotionRepo{coupon: &coupon, eligible: tt.eligible}}
+			conv := currencies.NewExchangeRateService(fakeRates{}).NewConverter("THB")
+
+			res, err := s.ApplyCouponTx(context.Background(), nil, " SAVE ", "u1", lines, conv)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplyCouponTx error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if res.Discount != thb(tt.want) {
+				t.Errorf("discount = %v, want %d THB", res.Discount, tt.want)
+			}
+		})
+	}
+}
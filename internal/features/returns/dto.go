@@ -0,0 +1,59 @@
+package returns
+
+import "github.com/codepnw/core-ecommerce-system/internal/utils/money"
+
+type ReturnStatus string
+
+const (
+	StatusRequested ReturnStatus = "requested"
+	StatusApproved  ReturnStatus = "approved"
+	StatusRejected  ReturnStatus = "rejected"
+	StatusReceived  ReturnStatus = "received"
+)
+
+type RefundMethod string
+
+const (
+	// RefundPayment is refunded through the captured payment provider.
+	RefundPayment RefundMethod = "payment"
+	// RefundManual is recorded only, the money is returned outside the system.
+	RefundManual RefundMethod = "manual"
+)
+
+type ReturnCreate struct {
+	OrderID int64               `json:"order_id" validate:"required"`
+	Note    *string             `json:"note"`
+	Items   []*ReturnItemCreate `json:"items" validate:"required,min=1,dive"`
+}
+
+type ReturnItemCreate struct {
+	OrderItemID int64  `json:"order_item_id" validate:"required"`
+	Quantity    int    `json:"quantity" validate:"required,gt=0"`
+	Reason      string `json:"reason" validate:"required,max=500"`
+}
+
+type ReturnReview struct {
+	Note *string `json:"note"`
+}
+
+// ReturnReceive restocks the items in the warehouse that shipped them, or in
+// WarehouseID when set.
+type ReturnReceive struct {
+	Restock     bool   `json:"restock"`
+	WarehouseID *int64 `json:"warehouse_id,omitempty"`
+}
+
+type ReturnFilter struct {
+	Status  *string
+	OrderID *int64
+	UserID  *string
+	Limit   *int
+	Offset  *int
+}
+
+// ReturnSummary is the number of returns and their refund total per status and currency.
+type ReturnSummary struct {
+	Status       string      `json:"status"`
+	Count        int         `json:"count"`
+	RefundAmount money.Money `json:"refund_amount"`
+}
@@ -0,0 +1,213 @@
+package returns
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	returnIDKey = "return_id"
+	orderIDKey  = "order_id"
+)
+
+type returnHandler struct {
+	srv IReturnService
+}
+
+func NewReturnHandler(srv IReturnService) *returnHandler {
+	return &returnHandler{srv: srv}
+}
+
+func (h *returnHandler) CreateReturn(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	req := new(ReturnCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.CreateReturn(ctx.Context(), req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "return requested", res)
+}
+
+func (h *returnHandler) GetReturn(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, returnIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.GetReturn(ctx.Context(), id, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *returnHandler) ListByOrder(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	orderID, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.ListByOrder(ctx.Context(), orderID, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *returnHandler) ListReturns(ctx *fiber.Ctx) error {
+	filter := new(ReturnFilter)
+
+	if status := ctx.Query("status"); status != "" {
+		filter.Status = &status
+	}
+
+	if orderID := ctx.QueryInt("order_id", 0); orderID != 0 {
+		id := int64(orderID)
+		filter.OrderID = &id
+	}
+
+	if userID := ctx.Query("user_id"); userID != "" {
+		filter.UserID = &userID
+	}
+
+	if limit := ctx.QueryInt("limit", 0); limit != 0 {
+		filter.Limit = &limit
+	}
+
+	if offset := ctx.QueryInt("offset", 0); offset != 0 {
+		filter.Offset = &offset
+	}
+
+	res, err := h.srv.ListReturns(ctx.Context(), filter)
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *returnHandler) Summary(ctx *fiber.Ctx) error {
+	res, err := h.srv.Summary(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *returnHandler) ApproveReturn(ctx *fiber.Ctx) error {
+	return h.review(ctx, "return approved", h.srv.ApproveReturn)
+}
+
+func (h *returnHandler) RejectReturn(ctx *fiber.Ctx) error {
+	return h.review(ctx, "return rejected", h.srv.RejectReturn)
+}
+
+func (h *returnHandler) ReceiveReturn(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, returnIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(ReturnReceive)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.ReceiveReturn(ctx.Context(), id, req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "return received", res)
+}
+
+type reviewFunc func(ctx context.Context, id int64, req *ReturnReview, actor *orders.OrderActor) (*Return, error)
+
+func (h *returnHandler) review(ctx *fiber.Ctx, msg string, fn reviewFunc) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	id, err := commons.GetParamIDInt(ctx, returnIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(ReturnReview)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := fn(ctx.Context(), id, req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, msg, res)
+}
+
+func (h *returnHandler) handleError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrReturnNotFound), errors.Is(err, errs.ErrOrderNotFound),
+		errors.Is(err, errs.ErrWarehouseNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrReturnForbidden), errors.Is(err, errs.ErrOrderForbidden):
+		return response.Forbidden(ctx, err.Error())
+	case errors.Is(err, errs.ErrReturnItemInvalid),
+		errors.Is(err, errs.ErrReturnQuantityExceeded):
+		return response.BadRequest(ctx, err.Error())
+	case errors.Is(err, errs.ErrOrderNotReturnable),
+		errors.Is(err, errs.ErrReturnNotRequested),
+		errors.Is(err, errs.ErrReturnNotApproved),
+		errors.Is(err, errs.ErrRefundExceedsPayment):
+		return response.Conflict(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
+
+func newActor(user *middleware.UserContext) *orders.OrderActor {
+	return &orders.OrderActor{
+		UserID:  user.UserID,
+		IsStaff: user.Role == middleware.RoleAdmin || user.Role == middleware.RoleStaff,
+	}
+}
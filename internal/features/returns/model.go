@@ -0,0 +1,49 @@
+package returns
+
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type Return struct {
+	ID           int64         `json:"id"`
+	OrderID      int64         `json:"order_id"`
+	UserID       string        `json:"user_id"`
+	Status       string        `json:"status"`
+	RefundAmount money.Money   `json:"refund_amount"`
+	Note         *string       `json:"note,omitempty"`
+	StaffNote    *string       `json:"staff_note,omitempty"`
+	Restocked    bool          `json:"restocked"`
+	ReviewedBy   *string       `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time    `json:"reviewed_at,omitempty"`
+	ReceivedAt   *time.Time    `json:"received_at,omitempty"`
+	Items        []*ReturnItem `json:"items,omitempty"`
+	Refund       *Refund       `json:"refund,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type ReturnItem struct {
+	ID           int64       `json:"id"`
+	ReturnID     int64       `json:"return_id"`
+	OrderItemID  int64       `json:"order_item_id"`
+	ProductID    int64       `json:"product_id"`
+	VariantID    int64       `json:"variant_id"`
+	WarehouseID  *int64      `json:"warehouse_id,omitempty"` // warehouse that shipped the item
+	Quantity     int         `json:"quantity"`
+	Reason       string      `json:"reason"`
+	RefundAmount money.Money `json:"refund_amount"`
+}
+
+type Refund struct {
+	ID          int64       `json:"id"`
+	ReturnID    int64       `json:"return_id"`
+	OrderID     int64       `json:"order_id"`
+	PaymentID   *int64      `json:"payment_id,omitempty"`
+	Method      string      `json:"method"`
+	ProviderRef *string     `json:"provider_ref,omitempty"`
+	Amount      money.Money `json:"amount"`
+	CreatedBy   *string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
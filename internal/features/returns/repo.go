@@ -0,0 +1,408 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const (
+	selectReturnQuery = `
+		SELECT id, order_id, user_id, status, currency, refund_amount, note, staff_note, restocked,
+			reviewed_by, reviewed_at, received_at, created_at, updated_at
+		FROM returns
+	`
+	selectReturnItemQuery = `
+		SELECT id, return_id, order_item_id, product_id,
+			(SELECT oi.variant_id FROM order_items oi WHERE oi.id = return_items.order_item_id),
+			(SELECT oi.warehouse_id FROM order_items oi WHERE oi.id = return_items.order_item_id),
+			quantity, reason, refund_amount
+		FROM return_items
+	`
+	selectRefundQuery = `
+		SELECT id, return_id, order_id, payment_id, method, provider_ref, currency, amount, created_by, created_at
+		FROM refunds
+	`
+)
+
+type IReturnRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, input *Return) error
+	GetByID(ctx context.Context, id int64) (*Return, error)
+	GetForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Return, error)
+	List(ctx context.Context, filter *ReturnFilter) ([]*Return, error)
+	Summary(ctx context.Context) ([]*ReturnSummary, error)
+	UpdateReview(ctx context.Context, tx *sql.Tx, id int64, status ReturnStatus, note *string, reviewedBy string) error
+	MarkReceived(ctx context.Context, tx *sql.Tx, id int64, restocked bool) error
+	InsertRefund(ctx context.Context, tx *sql.Tx, input *Refund) error
+
+	// Orders
+	LockOrder(ctx context.Context, tx *sql.Tx, orderID int64) error
+	GetReturnedQuantities(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]int, error)
+}
+
+type returnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnRepository(db *sql.DB) IReturnRepository {
+	return &returnRepository{db: db}
+}
+
+func (r *returnRepository) Create(ctx context.Context, tx *sql.Tx, input *Return) error {
+	query := `
+		INSERT INTO returns (order_id, user_id, currency, refund_amount, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at, updated_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.UserID,
+		input.RefundAmount.CurrencyCode(),
+		input.RefundAmount,
+		input.Note,
+	).Scan(
+		&input.ID,
+		&input.Status,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO return_items (return_id, order_item_id, product_id, quantity, reason, refund_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	for _, item := range input.Items {
+		item.ReturnID = input.ID
+		err = tx.QueryRowContext(
+			ctx,
+			itemQuery,
+			input.ID,
+			item.OrderItemID,
+			item.ProductID,
+			item.Quantity,
+			item.Reason,
+			item.RefundAmount,
+		).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *returnRepository) GetByID(ctx context.Context, id int64) (*Return, error) {
+	query := fmt.Sprintf("%s WHERE id = $1", selectReturnQuery)
+
+	ret, err := scanReturn(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrReturnNotFound
+		}
+		return nil, err
+	}
+
+	if ret.Items, err = r.getItems(ctx, r.db, ret); err != nil {
+		return nil, err
+	}
+
+	refundQuery := fmt.Sprintf("%s WHERE return_id = $1", selectRefundQuery)
+	ret.Refund, err = scanRefund(r.db.QueryRowContext(ctx, refundQuery, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		ret.Refund = nil
+	}
+
+	return ret, nil
+}
+
+func (r *returnRepository) GetForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Return, error) {
+	query := fmt.Sprintf("%s WHERE id = $1 FOR UPDATE", selectReturnQuery)
+
+	ret, err := scanReturn(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrReturnNotFound
+		}
+		return nil, err
+	}
+
+	if ret.Items, err = r.getItems(ctx, tx, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *returnRepository) List(ctx context.Context, filter *ReturnFilter) ([]*Return, error) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString(selectReturnQuery)
+	sb.WriteString(" WHERE 1=1")
+
+	if filter.Status != nil {
+		sb.WriteString(fmt.Sprintf(" AND status = $%d", len(args)+1))
+		args = append(args, *filter.Status)
+	}
+
+	if filter.OrderID != nil {
+		sb.WriteString(fmt.Sprintf(" AND order_id = $%d", len(args)+1))
+		args = append(args, *filter.OrderID)
+	}
+
+	if filter.UserID != nil {
+		sb.WriteString(fmt.Sprintf(" AND user_id = $%d", len(args)+1))
+		args = append(args, *filter.UserID)
+	}
+
+	sb.WriteString(" ORDER BY created_at DESC")
+
+	if filter.Limit != nil {
+		sb.WriteString(fmt.Sprintf(" LIMIT $%d", len(args)+1))
+		args = append(args, *filter.Limit)
+	}
+
+	if filter.Offset != nil {
+		sb.WriteString(fmt.Sprintf(" OFFSET $%d", len(args)+1))
+		args = append(args, *filter.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []*Return
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+
+	return returns, rows.Err()
+}
+
+func (r *returnRepository) Summary(ctx context.Context) ([]*ReturnSummary, error) {
+	query := `
+		SELECT status, currency, COUNT(*), COALESCE(SUM(refund_amount), 0)
+		FROM returns
+		GROUP BY status, currency
+		ORDER BY status, currency
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []*ReturnSummary
+	for rows.Next() {
+		s := new(ReturnSummary)
+		if err = rows.Scan(&s.Status, &s.RefundAmount.Currency, &s.Count, &s.RefundAmount); err != nil {
+			return nil, err
+		}
+		summary = append(summary, s)
+	}
+
+	return summary, rows.Err()
+}
+
+func (r *returnRepository) UpdateReview(ctx context.Context, tx *sql.Tx, id int64, status ReturnStatus, note *string, reviewedBy string) error {
+	query := `
+		UPDATE returns
+		SET status = $1, staff_note = $2, reviewed_by = $3, reviewed_at = now(), updated_at = now()
+		WHERE id = $4
+	`
+	return execAffected(ctx, tx, query, status, note, reviewedBy, id)
+}
+
+func (r *returnRepository) MarkReceived(ctx context.Context, tx *sql.Tx, id int64, restocked bool) error {
+	query := `
+		UPDATE returns
+		SET status = 'received', restocked = $1, received_at = now(), updated_at = now()
+		WHERE id = $2
+	`
+	return execAffected(ctx, tx, query, restocked, id)
+}
+
+func (r *returnRepository) InsertRefund(ctx context.Context, tx *sql.Tx, input *Refund) error {
+	query := `
+		INSERT INTO refunds (return_id, order_id, payment_id, method, provider_ref, currency, amount, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.ReturnID,
+		input.OrderID,
+		input.PaymentID,
+		input.Method,
+		input.ProviderRef,
+		input.Amount.CurrencyCode(),
+		input.Amount,
+		input.CreatedBy,
+	).Scan(
+		&input.ID,
+		&input.CreatedAt,
+	)
+}
+
+// ------------ Orders ------------
+
+// LockOrder serializes return requests of the same order.
+func (r *returnRepository) LockOrder(ctx context.Context, tx *sql.Tx, orderID int64) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrOrderNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetReturnedQuantities sums the quantity per order item of every return that was not rejected.
+func (r *returnRepository) GetReturnedQuantities(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]int, error) {
+	query := `
+		SELECT ri.order_item_id, SUM(ri.quantity)
+		FROM return_items ri
+		JOIN returns r ON r.id = ri.return_id
+		WHERE r.order_id = $1 AND r.status <> 'rejected'
+		GROUP BY ri.order_item_id
+	`
+	rows, err := tx.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returned := make(map[int64]int)
+	for rows.Next() {
+		var (
+			id  int64
+			qty int
+		)
+		if err = rows.Scan(&id, &qty); err != nil {
+			return nil, err
+		}
+		returned[id] = qty
+	}
+
+	return returned, rows.Err()
+}
+
+func (r *returnRepository) getItems(ctx context.Context, exec database.DBExec, ret *Return) ([]*ReturnItem, error) {
+	query := fmt.Sprintf("%s WHERE return_id = $1 ORDER BY id", selectReturnItemQuery)
+	rows, err := exec.QueryContext(ctx, query, ret.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*ReturnItem
+	for rows.Next() {
+		item := &ReturnItem{RefundAmount: ret.RefundAmount}
+		err = rows.Scan(
+			&item.ID,
+			&item.ReturnID,
+			&item.OrderItemID,
+			&item.ProductID,
+			&item.VariantID,
+			&item.WarehouseID,
+			&item.Quantity,
+			&item.Reason,
+			&item.RefundAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func execAffected(ctx context.Context, exec database.DBExec, query string, args ...any) error {
+	res, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrReturnNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReturn(row rowScanner) (*Return, error) {
+	ret := new(Return)
+	err := row.Scan(
+		&ret.ID,
+		&ret.OrderID,
+		&ret.UserID,
+		&ret.Status,
+		&ret.RefundAmount.Currency,
+		&ret.RefundAmount,
+		&ret.Note,
+		&ret.StaffNote,
+		&ret.Restocked,
+		&ret.ReviewedBy,
+		&ret.ReviewedAt,
+		&ret.ReceivedAt,
+		&ret.CreatedAt,
+		&ret.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func scanRefund(row rowScanner) (*Refund, error) {
+	rf := new(Refund)
+	err := row.Scan(
+		&rf.ID,
+		&rf.ReturnID,
+		&rf.OrderID,
+		&rf.PaymentID,
+		&rf.Method,
+		&rf.ProviderRef,
+		&rf.Amount.Currency,
+		&rf.Amount,
+		&rf.CreatedBy,
+		&rf.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
@@ -0,0 +1,314 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/features/payments"
+	"github.com/codepnw/core-ecommerce-system/internal/features/products"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+)
+
+type IReturnService interface {
+	CreateReturn(ctx context.Context, req *ReturnCreate, actor *orders.OrderActor) (*Return, error)
+	GetReturn(ctx context.Context, id int64, actor *orders.OrderActor) (*Return, error)
+	ListByOrder(ctx context.Context, orderID int64, actor *orders.OrderActor) ([]*Return, error)
+	ListReturns(ctx context.Context, filter *ReturnFilter) ([]*Return, error)
+	Summary(ctx context.Context) ([]*ReturnSummary, error)
+	ApproveReturn(ctx context.Context, id int64, req *ReturnReview, actor *orders.OrderActor) (*Return, error)
+	RejectReturn(ctx context.Context, id int64, req *ReturnReview, actor *orders.OrderActor) (*Return, error)
+	ReceiveReturn(ctx context.Context, id int64, req *ReturnReceive, actor *orders.OrderActor) (*Return, error)
+}
+
+type ReturnServiceConfig struct {
+	ReturnRepo IReturnRepository        `validate:"required"`
+	OrderSrv   orders.IOrderService     `validate:"required"`
+	ProdSrv    products.IProductService `validate:"required"`
+	PaySrv     payments.IPaymentService `validate:"required"`
+	Tx         *database.TxManager      `validate:"required"`
+}
+
+func NewReturnService(cfg *ReturnServiceConfig) (IReturnService, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("ReturnServiceConfig required all fields: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *ReturnServiceConfig) CreateReturn(ctx context.Context, req *ReturnCreate, actor *orders.OrderActor) (*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Checks the order exists and belongs to the customer
+	order, err := s.OrderSrv.GetOrder(ctx, req.OrderID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if orders.OrderStatus(order.Status) != orders.StatusComplated {
+		return nil, errs.ErrOrderNotReturnable
+	}
+
+	orderItems := make(map[int64]*orders.OrderItemResponse, len(order.Items))
+	for _, item := range order.Items {
+		orderItems[item.ID] = item
+	}
+
+	// Same order item listed twice counts as one line
+	requested := make(map[int64]*ReturnItemCreate)
+	var lines []*ReturnItemCreate
+	for _, item := range req.Items {
+		if line, ok := requested[item.OrderItemID]; ok {
+			line.Quantity += item.Quantity
+			continue
+		}
+		line := *item
+		requested[item.OrderItemID] = &line
+		lines = append(lines, &line)
+	}
+
+	ret := &Return{
+		OrderID:      order.ID,
+		UserID:       order.UserID,
+		Note:         req.Note,
+		RefundAmount: money.New(0, order.Currency),
+	}
+
+	err = s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		if err := s.ReturnRepo.LockOrder(ctx, tx, order.ID); err != nil {
+			return err
+		}
+
+		returned, err := s.ReturnRepo.GetReturnedQuantities(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			item, ok := orderItems[line.OrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: %d", errs.ErrReturnItemInvalid, line.OrderItemID)
+			}
+
+			left := item.Quantity - returned[item.ID]
+			if line.Quantity > left {
+				return fmt.Errorf("%w: order item %d has %d left", errs.ErrReturnQuantityExceeded, item.ID, left)
+			}
+
+			amount := refundAmount(order, item, line.Quantity)
+			ret.RefundAmount = ret.RefundAmount.Add(amount)
+			ret.Items = append(ret.Items, &ReturnItem{
+				OrderItemID:  item.ID,
+				ProductID:    item.ProductID,
+				Quantity:     line.Quantity,
+				Reason:       line.Reason,
+				RefundAmount: amount,
+			})
+		}
+
+		return s.ReturnRepo.Create(ctx, tx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (s *ReturnServiceConfig) GetReturn(ctx context.Context, id int64, actor *orders.OrderActor) (*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	ret, err := s.ReturnRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.IsStaff && ret.UserID != actor.UserID {
+		return nil, errs.ErrReturnForbidden
+	}
+
+	return ret, nil
+}
+
+func (s *ReturnServiceConfig) ListByOrder(ctx context.Context, orderID int64, actor *orders.OrderActor) ([]*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Checks the order exists and belongs to the customer
+	if _, err := s.OrderSrv.GetOrder(ctx, orderID, actor); err != nil {
+		return nil, err
+	}
+
+	return s.ReturnRepo.List(ctx, &ReturnFilter{OrderID: &orderID})
+}
+
+func (s *ReturnServiceConfig) ListReturns(ctx context.Context, filter *ReturnFilter) ([]*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.ReturnRepo.List(ctx, filter)
+}
+
+func (s *ReturnServiceConfig) Summary(ctx context.Context) ([]*ReturnSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.ReturnRepo.Summary(ctx)
+}
+
+// ApproveReturn accepts the return and refunds it, through the captured
+// payment when the order has one, otherwise as a manual refund record. The
+// approval, the refunded total of the payment and the refund record commit
+// together, the provider refund is keyed by the return so approving again
+// after a failure does not refund twice.
+func (s *ReturnServiceConfig) ApproveReturn(ctx context.Context, id int64, req *ReturnReview, actor *orders.OrderActor) (*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		ret, err := s.ReturnRepo.GetForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if ReturnStatus(ret.Status) != StatusRequested {
+			return errs.ErrReturnNotRequested
+		}
+
+		if err = s.ReturnRepo.UpdateReview(ctx, tx, ret.ID, StatusApproved, req.Note, actor.UserID); err != nil {
+			return err
+		}
+
+		refund := &Refund{
+			ReturnID:  ret.ID,
+			OrderID:   ret.OrderID,
+			Method:    string(RefundManual),
+			Amount:    ret.RefundAmount,
+			CreatedBy: &actor.UserID,
+		}
+
+		if ret.RefundAmount.IsPositive() {
+			reason := fmt.Sprintf("return #%d", ret.ID)
+			key := fmt.Sprintf("return-%d", ret.ID)
+			payment, result, err := s.PaySrv.RefundOrderTx(ctx, tx, ret.OrderID, ret.RefundAmount, reason, key)
+			switch {
+			case err == nil:
+				refund.Method = string(RefundPayment)
+				refund.PaymentID = &payment.ID
+				refund.ProviderRef = &result.RefundID
+			case errors.Is(err, errs.ErrPaymentNotFound):
+				// No captured payment, recorded as a manual refund
+			default:
+				return err
+			}
+		}
+
+		if err = s.ReturnRepo.InsertRefund(ctx, tx, refund); err != nil {
+			return fmt.Errorf("insert refund failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ReturnRepo.GetByID(ctx, id)
+}
+
+func (s *ReturnServiceConfig) RejectReturn(ctx context.Context, id int64, req *ReturnReview, actor *orders.OrderActor) (*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		ret, err := s.ReturnRepo.GetForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if ReturnStatus(ret.Status) != StatusRequested {
+			return errs.ErrReturnNotRequested
+		}
+
+		return s.ReturnRepo.UpdateReview(ctx, tx, ret.ID, StatusRejected, req.Note, actor.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ReturnRepo.GetByID(ctx, id)
+}
+
+// ReceiveReturn marks the returned goods as back in the warehouse and
+// optionally puts them back in stock.
+func (s *ReturnServiceConfig) ReceiveReturn(ctx context.Context, id int64, req *ReturnReceive, actor *orders.OrderActor) (*Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		ret, err := s.ReturnRepo.GetForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if ReturnStatus(ret.Status) != StatusApproved {
+			return errs.ErrReturnNotApproved
+		}
+
+		if req.Restock {
+			for _, item := range ret.Items {
+				warehouseID := item.WarehouseID
+				if req.WarehouseID != nil {
+					warehouseID = req.WarehouseID
+				}
+
+				change := products.NewStockChange(products.MovementReturn, warehouseID, actor.ID(), products.RefReturn, ret.ID)
+				if err = s.ProdSrv.RestoreStock(ctx, tx, item.VariantID, item.Quantity, change); err != nil {
+					return fmt.Errorf("restock product %d variant %d failed: %w", item.ProductID, item.VariantID, err)
+				}
+			}
+		}
+
+		return s.ReturnRepo.MarkReceived(ctx, tx, ret.ID, req.Restock)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ReturnRepo.GetByID(ctx, id)
+}
+
+// refundAmount is what the customer paid for qty units of the item: the item
+// total with exclusive tax, less its share of the order discount. Shipping is
+// not refunded.
+func refundAmount(order *orders.OrderDetailResponse, item *orders.OrderItemResponse, qty int) money.Money {
+	gross := func(it *orders.OrderItemResponse) int64 {
+		amount := it.SubTotal.Amount
+		if !it.TaxInclusive {
+			amount += it.TaxAmount.Amount
+		}
+		return amount
+	}
+
+	var totalGross int64
+	for _, it := range order.Items {
+		totalGross += gross(it)
+	}
+	if totalGross == 0 || item.Quantity == 0 {
+		return money.New(0, order.Currency)
+	}
+
+	paid := order.TotalPrice.Sub(order.ShippingFee).Amount
+	share := float64(gross(item)) * float64(paid) / float64(totalGross)
+	share = share * float64(qty) / float64(item.Quantity)
+
+	return money.New(int64(math.Round(share)), order.Currency)
+}
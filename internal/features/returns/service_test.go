@@ -0,0 +1,201 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/database/dbtest"
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/features/payments"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+func TestRefundAmount(t *testing.T) {
+	thb := func(amount int64) money.Money { return money.New(amount, "THB") }
+
+	// 1,000.00 with 7% exclusive tax and 500.00 with tax included, 100.00
+	// off the order and 50.00 shipping: 1,470.00 paid for the goods
+	exclusive := &orders.OrderItemResponse{ID: 1, Quantity: 2, SubTotal: thb(100000), TaxAmount: thb(7000)}
+	inclusive := &orders.OrderItemResponse{ID: 2, Quantity: 1, SubTotal: thb(50000), TaxAmount: thb(3271), TaxInclusive: true}
+	order := &orders.OrderDetailResponse{
+		Currency:       "THB",
+		DiscountAmount: thb(10000),
+		ShippingFee:    thb(5000),
+		TotalPrice:     thb(152000),
+		Items:          []*orders.OrderItemResponse{exclusive, inclusive},
+	}
+
+	plain := &orders.OrderItemResponse{ID: 3, Quantity: 3, SubTotal: thb(30000)}
+	noDiscount := &orders.OrderDetailResponse{
+		Currency:    "THB",
+		ShippingFee: thb(5000),
+		TotalPrice:  thb(35000),
+		Items:       []*orders.OrderItemResponse{plain},
+	}
+
+	free := &orders.OrderItemResponse{ID: 4, Quantity: 1, SubTotal: thb(0)}
+	freeOrder := &orders.OrderDetailResponse{
+		Currency:   "THB",
+		TotalPrice: thb(0),
+		Items:      []*orders.OrderItemResponse{free},
+	}
+
+	tests := []struct {
+		name  string
+		order *orders.OrderDetailResponse
+		item  *orders.OrderItemResponse
+		qty   int
+		want  int64
+	}{
+		{name: "exclusive tax, whole line", order: order, item: exclusive, qty: 2, want: 100185},
+		{name: "exclusive tax, one unit", order: order, item: exclusive, qty: 1, want: 50092},
+		{name: "inclusive tax", order: order, item: inclusive, qty: 1, want: 46815},
+		{name: "no discount, shipping kept", order: noDiscount, item: plain, qty: 1, want: 10000},
+		{name: "free order", order: freeOrder, item: free, qty: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refundAmount(tt.order, tt.item, tt.qty)
+			if got != thb(tt.want) {
+				t.Errorf("refundAmount = %+v, want %d THB", got, tt.want)
+			}
+		})
+	}
+
+	// Returning every line gives back exactly what was paid for the goods
+	total := refundAmount(order, exclusive, 2).Add(refundAmount(order, inclusive, 1))
+	if want := order.TotalPrice.Sub(order.ShippingFee); total != want {
+		t.Errorf("all lines refund %v, want %v", total, want)
+	}
+}
+
+// fakeReturnRepo holds one return. UpdateReview only records the status, so
+// the return stays requested after a failed approval as after a rollback.
+type fakeReturnRepo struct {
+	IReturnRepository
+
+	ret          Return
+	failInsert   bool
+	refunds      []*Refund
+	reviewStatus ReturnStatus
+}
+
+func (r *fakeReturnRepo) GetForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Return, error) {
+	if id != r.ret.ID {
+		return nil, errs.ErrReturnNotFound
+	}
+	ret := r.ret
+	return &ret, nil
+}
+
+func (r *fakeReturnRepo) GetByID(ctx context.Context, id int64) (*Return, error) {
+	return r.GetForUpdate(ctx, nil, id)
+}
+
+func (r *fakeReturnRepo) UpdateReview(ctx context.Context, tx *sql.Tx, id int64, status ReturnStatus, note *string, reviewedBy string) error {
+	r.reviewStatus = status
+	return nil
+}
+
+func (r *fakeReturnRepo) InsertRefund(ctx context.Context, tx *sql.Tx, input *Refund) error {
+	if r.failInsert {
+		return errors.New("insert failed")
+	}
+	r.refunds = append(r.refunds, input)
+	return nil
+}
+
+// fakePayments refunds through the fake gateway, captured tells whether the
+// order has a captured payment.
+type fakePayments struct {
+	payments.IPaymentService
+
+	captured bool
+	provider *payments.FakeProvider
+	keys     []string
+}
+
+func (s *fakePayments) RefundOrderTx(ctx context.Context, tx *sql.Tx, orderID int64, amount money.Money, reason, key string) (*payments.Payment, *payments.RefundResult, error) {
+	if !s.captured {
+		return nil, nil, errs.ErrPaymentNotFound
+	}
+	s.keys = append(s.keys, key)
+
+	res, err := s.provider.Refund(ctx, &payments.RefundRequest{IntentID: "fake_pi_1", Amount: amount, Reason: reason, IdempotencyKey: key})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &payments.Payment{ID: 3, OrderID: orderID}, res, nil
+}
+
+func TestApproveReturn(t *testing.T) {
+	staff := &orders.OrderActor{UserID: "staff", IsStaff: true}
+
+	tests := []struct {
+		name       string
+		captured   bool
+		wantMethod RefundMethod
+	}{
+		{name: "captured payment", captured: true, wantMethod: RefundPayment},
+		{name: "no captured payment", captured: false, wantMethod: RefundManual},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeReturnRepo{ret: Return{ID: 7, OrderID: 1, Status: string(StatusRequested), RefundAmount: money.New(50000, "THB")}}
+			pay := &fakePayments{captured: tt.captured, provider: payments.NewFakeProvider()}
+			s := &ReturnServiceConfig{ReturnRepo: repo, PaySrv: pay, Tx: database.NewTxManager(dbtest.Open(t))}
+
+			// The refund record fails after the provider refunded, the whole
+			// approval rolls back
+			repo.failInsert = true
+			if _, err := s.ApproveReturn(context.Background(), 7, &ReturnReview{}, staff); err == nil {
+				t.Fatal("ApproveReturn succeeded, want the insert error")
+			}
+
+			repo.failInsert = false
+			if _, err := s.ApproveReturn(context.Background(), 7, &ReturnReview{}, staff); err != nil {
+				t.Fatalf("ApproveReturn retry error = %v", err)
+			}
+
+			if repo.reviewStatus != StatusApproved || len(repo.refunds) != 1 {
+				t.Fatalf("review %s with %d refunds, want approved with 1", repo.reviewStatus, len(repo.refunds))
+			}
+			refund := repo.refunds[0]
+			if RefundMethod(refund.Method) != tt.wantMethod || refund.Amount != repo.ret.RefundAmount {
+				t.Errorf("refund = %s %v, want %s %v", refund.Method, refund.Amount, tt.wantMethod, repo.ret.RefundAmount)
+			}
+
+			if !tt.captured {
+				return
+			}
+			// Both attempts use the return as key, the gateway pays once
+			if len(pay.keys) != 2 || pay.keys[0] != "return-7" || pay.keys[1] != pay.keys[0] {
+				t.Errorf("refund keys = %v, want return-7 twice", pay.keys)
+			}
+			first, _ := pay.provider.Refund(context.Background(), &payments.RefundRequest{IdempotencyKey: "return-7"})
+			if refund.ProviderRef == nil || *refund.ProviderRef != first.RefundID {
+				t.Errorf("provider_ref = %v, want the first refund %s", refund.ProviderRef, first.RefundID)
+			}
+		})
+	}
+}
+
+func TestApproveReturnTwice(t *testing.T) {
+	repo := &fakeReturnRepo{ret: Return{ID: 7, OrderID: 1, Status: string(StatusApproved), RefundAmount: money.New(50000, "THB")}}
+	pay := &fakePayments{captured: true, provider: payments.NewFakeProvider()}
+	s := &ReturnServiceConfig{ReturnRepo: repo, PaySrv: pay, Tx: database.NewTxManager(dbtest.Open(t))}
+
+	_, err := s.ApproveReturn(context.Background(), 7, &ReturnReview{}, &orders.OrderActor{UserID: "staff", IsStaff: true})
+	if !errors.Is(err, errs.ErrReturnNotRequested) {
+		t.Fatalf("ApproveReturn error = %v, want ErrReturnNotRequested", err)
+	}
+	if len(pay.keys) != 0 {
+		t.Errorf("approved return refunded again: %v", pay.keys)
+	}
+}
@@ -0,0 +1,31 @@
+package shipments
+
+type ShipmentStatus string
+
+// Status is derived from the timestamps, it is not stored.
+const (
+	StatusShipped   ShipmentStatus = "shipped"
+	StatusDelivered ShipmentStatus = "delivered"
+)
+
+type ShipmentCreate struct {
+	Carrier        string                `json:"carrier" validate:"required,max=100"`
+	TrackingNumber string                `json:"tracking_number" validate:"required,max=100"`
+	Items          []*ShipmentItemCreate `json:"items" validate:"required,min=1,dive"`
+}
+
+type ShipmentItemCreate struct {
+	OrderItemID int64 `json:"order_item_id" validate:"required"`
+	Quantity    int   `json:"quantity" validate:"required,gt=0"`
+}
+
+// orderItemQuantity is the ordered and already shipped quantity of one order item.
+type orderItemQuantity struct {
+	ProductID int64
+	Ordered   int
+	Shipped   int
+}
+
+func (q *orderItemQuantity) remaining() int {
+	return q.Ordered - q.Shipped
+}
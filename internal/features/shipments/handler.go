@@ -0,0 +1,122 @@
+package shipments
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	orderIDKey    = "order_id"
+	shipmentIDKey = "shipment_id"
+)
+
+type shipmentHandler struct {
+	srv IShipmentService
+}
+
+func NewShipmentHandler(srv IShipmentService) *shipmentHandler {
+	return &shipmentHandler{srv: srv}
+}
+
+func (h *shipmentHandler) CreateShipment(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	orderID, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(ShipmentCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.CreateShipment(ctx.Context(), orderID, req, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "shipment created", res)
+}
+
+func (h *shipmentHandler) MarkDelivered(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	orderID, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	shipmentID, err := commons.GetParamIDInt(ctx, shipmentIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.MarkDelivered(ctx.Context(), orderID, shipmentID, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "shipment delivered", res)
+}
+
+func (h *shipmentHandler) ListByOrder(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	orderID, err := commons.GetParamIDInt(ctx, orderIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	res, err := h.srv.ListByOrder(ctx.Context(), orderID, newActor(user))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", res)
+}
+
+func (h *shipmentHandler) handleError(ctx *fiber.Ctx, err error) error {
+	var transErr *errs.InvalidTransitionError
+	switch {
+	case errors.Is(err, errs.ErrShipmentNotFound), errors.Is(err, errs.ErrOrderNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrOrderForbidden):
+		return response.Forbidden(ctx, err.Error())
+	case errors.Is(err, errs.ErrShipmentItemInvalid),
+		errors.Is(err, errs.ErrShipmentQuantityExceeded):
+		return response.BadRequest(ctx, err.Error())
+	case errors.Is(err, errs.ErrOrderNotShippable),
+		errors.Is(err, errs.ErrShipmentDelivered),
+		errors.As(err, &transErr):
+		return response.Conflict(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
+
+func newActor(user *middleware.UserContext) *orders.OrderActor {
+	return &orders.OrderActor{
+		UserID:  user.UserID,
+		IsStaff: user.Role == middleware.RoleAdmin || user.Role == middleware.RoleStaff,
+	}
+}
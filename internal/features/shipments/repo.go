@@ -0,0 +1,257 @@
+package shipments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const (
+	selectShipmentQuery = `
+		SELECT id, order_id, carrier, tracking_number, shipped_at, delivered_at, created_by, created_at, updated_at
+		FROM shipments
+	`
+)
+
+type IShipmentRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, input *Shipment) error
+	GetForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Shipment, error)
+	ListByOrder(ctx context.Context, orderID int64) ([]*Shipment, error)
+	MarkDelivered(ctx context.Context, tx *sql.Tx, id int64) error
+	CountUndelivered(ctx context.Context, tx *sql.Tx, orderID int64) (int, error)
+
+	// Orders
+	GetOrderStatusForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (string, error)
+	GetItemQuantities(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]*orderItemQuantity, error)
+}
+
+type shipmentRepository struct {
+	db *sql.DB
+}
+
+func NewShipmentRepository(db *sql.DB) IShipmentRepository {
+	return &shipmentRepository{db: db}
+}
+
+func (r *shipmentRepository) Create(ctx context.Context, tx *sql.Tx, input *Shipment) error {
+	query := `
+		INSERT INTO shipments (order_id, carrier, tracking_number, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, shipped_at, created_at, updated_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.Carrier,
+		input.TrackingNumber,
+		input.CreatedBy,
+	).Scan(
+		&input.ID,
+		&input.ShippedAt,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	for _, item := range input.Items {
+		item.ShipmentID = input.ID
+		if err = tx.QueryRowContext(ctx, itemQuery, input.ID, item.OrderItemID, item.Quantity).Scan(&item.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *shipmentRepository) GetForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*Shipment, error) {
+	query := fmt.Sprintf("%s WHERE id = $1 FOR UPDATE", selectShipmentQuery)
+
+	s, err := scanShipment(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrShipmentNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *shipmentRepository) ListByOrder(ctx context.Context, orderID int64) ([]*Shipment, error) {
+	query := fmt.Sprintf("%s WHERE order_id = $1 ORDER BY shipped_at, id", selectShipmentQuery)
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []*Shipment
+	byID := make(map[int64]*Shipment)
+	for rows.Next() {
+		s, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, s)
+		byID[s.ID] = s
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(shipments) == 0 {
+		return shipments, nil
+	}
+
+	itemQuery := `
+		SELECT si.id, si.shipment_id, si.order_item_id, oi.product_id, si.quantity
+		FROM shipment_items si
+		JOIN shipments s ON s.id = si.shipment_id
+		JOIN order_items oi ON oi.id = si.order_item_id
+		WHERE s.order_id = $1
+		ORDER BY si.id
+	`
+	itemRows, err := r.db.QueryContext(ctx, itemQuery, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		item := new(ShipmentItem)
+		err = itemRows.Scan(
+			&item.ID,
+			&item.ShipmentID,
+			&item.OrderItemID,
+			&item.ProductID,
+			&item.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := byID[item.ShipmentID]; ok {
+			s.Items = append(s.Items, item)
+		}
+	}
+
+	return shipments, itemRows.Err()
+}
+
+func (r *shipmentRepository) MarkDelivered(ctx context.Context, tx *sql.Tx, id int64) error {
+	query := `
+		UPDATE shipments SET delivered_at = now(), updated_at = now()
+		WHERE id = $1
+	`
+	res, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrShipmentNotFound
+	}
+
+	return nil
+}
+
+func (r *shipmentRepository) CountUndelivered(ctx context.Context, tx *sql.Tx, orderID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM shipments WHERE order_id = $1 AND delivered_at IS NULL`
+
+	var count int
+	if err := tx.QueryRowContext(ctx, query, orderID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ------------ Orders ------------
+
+// GetOrderStatusForUpdate locks the order row so concurrent shipments of the
+// same order are serialized.
+func (r *shipmentRepository) GetOrderStatusForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (string, error) {
+	query := `SELECT status FROM orders WHERE id = $1 FOR UPDATE`
+
+	var status string
+	if err := tx.QueryRowContext(ctx, query, orderID).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errs.ErrOrderNotFound
+		}
+		return "", err
+	}
+
+	return status, nil
+}
+
+func (r *shipmentRepository) GetItemQuantities(ctx context.Context, tx *sql.Tx, orderID int64) (map[int64]*orderItemQuantity, error) {
+	query := `
+		SELECT oi.id, oi.product_id, oi.quantity, COALESCE(SUM(si.quantity), 0)
+		FROM order_items oi
+		LEFT JOIN shipment_items si ON si.order_item_id = oi.id
+		WHERE oi.order_id = $1
+		GROUP BY oi.id
+	`
+	rows, err := tx.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[int64]*orderItemQuantity)
+	for rows.Next() {
+		var id int64
+		q := new(orderItemQuantity)
+		if err = rows.Scan(&id, &q.ProductID, &q.Ordered, &q.Shipped); err != nil {
+			return nil, err
+		}
+		items[id] = q
+	}
+
+	return items, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShipment(row rowScanner) (*Shipment, error) {
+	s := new(Shipment)
+	err := row.Scan(
+		&s.ID,
+		&s.OrderID,
+		&s.Carrier,
+		&s.TrackingNumber,
+		&s.ShippedAt,
+		&s.DeliveredAt,
+		&s.CreatedBy,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Status = string(StatusShipped)
+	if s.DeliveredAt != nil {
+		s.Status = string(StatusDelivered)
+	}
+	s.Items = []*ShipmentItem{}
+
+	return s, nil
+}
@@ -0,0 +1,172 @@
+package shipments
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+)
+
+type IShipmentService interface {
+	CreateShipment(ctx context.Context, orderID int64, req *ShipmentCreate, actor *orders.OrderActor) (*Shipment, error)
+	MarkDelivered(ctx context.Context, orderID, shipmentID int64, actor *orders.OrderActor) (*Shipment, error)
+	ListByOrder(ctx context.Context, orderID int64, actor *orders.OrderActor) ([]*Shipment, error)
+}
+
+type ShipmentServiceConfig struct {
+	ShipmentRepo IShipmentRepository  `validate:"required"`
+	OrderSrv     orders.IOrderService `validate:"required"`
+	Tx           *database.TxManager  `validate:"required"`
+}
+
+func NewShipmentService(cfg *ShipmentServiceConfig) (IShipmentService, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("ShipmentServiceConfig required all fields: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *ShipmentServiceConfig) CreateShipment(ctx context.Context, orderID int64, req *ShipmentCreate, actor *orders.OrderActor) (*Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Same order item listed twice counts as one line
+	requested := make(map[int64]int)
+	var lineOrder []int64
+	for _, item := range req.Items {
+		if _, ok := requested[item.OrderItemID]; !ok {
+			lineOrder = append(lineOrder, item.OrderItemID)
+		}
+		requested[item.OrderItemID] += item.Quantity
+	}
+
+	shipment := &Shipment{
+		OrderID:        orderID,
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		CreatedBy:      &actor.UserID,
+	}
+
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		status, err := s.ShipmentRepo.GetOrderStatusForUpdate(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		// Partial shipments keep the order paid until every item is covered
+		if orders.OrderStatus(status) != orders.StatusPaid {
+			return errs.ErrOrderNotShippable
+		}
+
+		quantities, err := s.ShipmentRepo.GetItemQuantities(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range lineOrder {
+			q, ok := quantities[id]
+			if !ok {
+				return fmt.Errorf("%w: %d", errs.ErrShipmentItemInvalid, id)
+			}
+			if requested[id] > q.remaining() {
+				return fmt.Errorf("%w: order item %d has %d left", errs.ErrShipmentQuantityExceeded, id, q.remaining())
+			}
+			q.Shipped += requested[id]
+
+			shipment.Items = append(shipment.Items, &ShipmentItem{
+				OrderItemID: id,
+				ProductID:   q.ProductID,
+				Quantity:    requested[id],
+			})
+		}
+
+		if err = s.ShipmentRepo.Create(ctx, tx, shipment); err != nil {
+			return fmt.Errorf("create shipment failed: %w", err)
+		}
+
+		for _, q := range quantities {
+			if q.remaining() > 0 {
+				return nil
+			}
+		}
+		return s.OrderSrv.UpdateOrderStatusTx(ctx, tx, orderID, orders.StatusShipped, actor, "all items shipped")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shipment.Status = string(StatusShipped)
+	return shipment, nil
+}
+
+func (s *ShipmentServiceConfig) MarkDelivered(ctx context.Context, orderID, shipmentID int64, actor *orders.OrderActor) (*Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := s.Tx.Transaction(ctx, func(tx *sql.Tx) error {
+		status, err := s.ShipmentRepo.GetOrderStatusForUpdate(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		shipment, err := s.ShipmentRepo.GetForUpdate(ctx, tx, shipmentID)
+		if err != nil {
+			return err
+		}
+		if shipment.OrderID != orderID {
+			return errs.ErrShipmentNotFound
+		}
+		if shipment.DeliveredAt != nil {
+			return errs.ErrShipmentDelivered
+		}
+
+		if err = s.ShipmentRepo.MarkDelivered(ctx, tx, shipmentID); err != nil {
+			return fmt.Errorf("update shipment failed: %w", err)
+		}
+
+		// Only a fully shipped order can be completed
+		if orders.OrderStatus(status) != orders.StatusShipped {
+			return nil
+		}
+
+		undelivered, err := s.ShipmentRepo.CountUndelivered(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		if undelivered > 0 {
+			return nil
+		}
+		return s.OrderSrv.UpdateOrderStatusTx(ctx, tx, orderID, orders.StatusComplated, actor, "all shipments delivered")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := s.ShipmentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, shipment := range shipments {
+		if shipment.ID == shipmentID {
+			return shipment, nil
+		}
+	}
+
+	return nil, errs.ErrShipmentNotFound
+}
+
+func (s *ShipmentServiceConfig) ListByOrder(ctx context.Context, orderID int64, actor *orders.OrderActor) ([]*Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Checks the order exists and belongs to the customer
+	if _, err := s.OrderSrv.GetOrder(ctx, orderID, actor); err != nil {
+		return nil, err
+	}
+
+	return s.ShipmentRepo.ListByOrder(ctx, orderID)
+}
@@ -0,0 +1,64 @@
+package shipping
+
+import "github.com/codepnw/core-ecommerce-system/internal/utils/money"
+
+type RateType string
+
+const (
+	// RateFlat charges BaseFee.
+	RateFlat RateType = "flat"
+	// RateWeight charges BaseFee plus PerKgFee for every started kilogram.
+	RateWeight RateType = "weight"
+	// RateFreeOverThreshold charges BaseFee unless the subtotal reaches FreeThreshold.
+	RateFreeOverThreshold RateType = "free_over_threshold"
+)
+
+type ZoneCreate struct {
+	Name               string   `json:"name" validate:"required,max=100"`
+	States             []string `json:"states,omitempty" validate:"omitempty"`
+	PostalCodePrefixes []string `json:"postal_code_prefixes,omitempty" validate:"omitempty"`
+	Priority           int      `json:"priority"`
+}
+
+type ZoneUpdate struct {
+	Name               *string   `json:"name,omitempty" validate:"omitempty,max=100"`
+	States             *[]string `json:"states,omitempty" validate:"omitempty"`
+	PostalCodePrefixes *[]string `json:"postal_code_prefixes,omitempty" validate:"omitempty"`
+	Priority           *int      `json:"priority,omitempty" validate:"omitempty"`
+	IsActive           *bool     `json:"is_active,omitempty" validate:"omitempty"`
+}
+
+type MethodCreate struct {
+	Name          string      `json:"name" validate:"required,max=100"`
+	RateType      RateType    `json:"rate_type" validate:"required,oneof=flat weight free_over_threshold"`
+	Currency      string      `json:"currency,omitempty" validate:"omitempty,len=3,alpha"`
+	BaseFee       money.Money `json:"base_fee" validate:"gte=0"`
+	PerKgFee      money.Money `json:"per_kg_fee" validate:"gte=0"`
+	FreeThreshold money.Money `json:"free_threshold" validate:"gte=0"`
+}
+
+type MethodUpdate struct {
+	Name          *string      `json:"name,omitempty" validate:"omitempty,max=100"`
+	BaseFee       *money.Money `json:"base_fee,omitempty" validate:"omitempty,gte=0"`
+	PerKgFee      *money.Money `json:"per_kg_fee,omitempty" validate:"omitempty,gte=0"`
+	FreeThreshold *money.Money `json:"free_threshold,omitempty" validate:"omitempty,gte=0"`
+	IsActive      *bool        `json:"is_active,omitempty" validate:"omitempty"`
+}
+
+// ShippingQuote is what a shipment is priced on. Fees are returned in the
+// currency of Subtotal.
+type ShippingQuote struct {
+	State       string
+	PostalCode  string
+	Subtotal    money.Money
+	WeightGrams int64
+}
+
+type ShippingOption struct {
+	MethodID int64       `json:"method_id"`
+	Name     string      `json:"name"`
+	RateType string      `json:"rate_type"`
+	ZoneID   int64       `json:"zone_id"`
+	ZoneName string      `json:"zone_name"`
+	Fee      money.Money `json:"fee"`
+}
@@ -0,0 +1,183 @@
+package shipping
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	zoneIDKey   = "zone_id"
+	methodIDKey = "method_id"
+)
+
+type shippingHandler struct {
+	srv IShippingService
+}
+
+func NewShippingHandler(srv IShippingService) *shippingHandler {
+	return &shippingHandler{srv: srv}
+}
+
+// ------------ Zones ------------
+
+func (h *shippingHandler) CreateZone(ctx *fiber.Ctx) error {
+	req := new(ZoneCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	zone, err := h.srv.CreateZone(ctx.Context(), req)
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Created(ctx, "shipping zone created", zone)
+}
+
+func (h *shippingHandler) ListZones(ctx *fiber.Ctx) error {
+	zones, err := h.srv.ListZones(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", zones)
+}
+
+func (h *shippingHandler) UpdateZone(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, zoneIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(ZoneUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.UpdateZone(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "shipping zone updated", nil)
+}
+
+func (h *shippingHandler) DeleteZone(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, zoneIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.DeleteZone(ctx.Context(), id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+// ------------ Methods ------------
+
+func (h *shippingHandler) CreateMethod(ctx *fiber.Ctx) error {
+	zoneID, err := commons.GetParamIDInt(ctx, zoneIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(MethodCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	method, err := h.srv.CreateMethod(ctx.Context(), zoneID, req)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "shipping method created", method)
+}
+
+func (h *shippingHandler) UpdateMethod(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, methodIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(MethodUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.UpdateMethod(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "shipping method updated", nil)
+}
+
+func (h *shippingHandler) DeleteMethod(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, methodIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.DeleteMethod(ctx.Context(), id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+// ------------ Cart ------------
+
+func (h *shippingHandler) CartShippingOptions(ctx *fiber.Ctx) error {
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		return response.Unauthorized(ctx, err.Error())
+	}
+
+	addressID := ctx.Query("address_id")
+	if addressID == "" {
+		return response.BadRequest(ctx, "address_id is required")
+	}
+
+	options, err := h.srv.CartOptions(ctx.Context(), user.UserID, addressID, ctx.Query("currency"))
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", options)
+}
+
+func (h *shippingHandler) handleError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrShippingZoneNotFound),
+		errors.Is(err, errs.ErrShippingMethodNotFound),
+		errors.Is(err, errs.ErrAddressNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrNoFieldUpdate),
+		errors.Is(err, errs.ErrExchangeRateNotFound):
+		return response.BadRequest(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
@@ -0,0 +1,34 @@
+package shipping
+
+import (
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+// ShippingZone groups addresses by state and postal code prefix. Empty lists
+// match any address; the active zone with the highest priority wins.
+type ShippingZone struct {
+	ID                 int64             `json:"id"`
+	Name               string            `json:"name"`
+	States             []string          `json:"states"`
+	PostalCodePrefixes []string          `json:"postal_code_prefixes"`
+	Priority           int               `json:"priority"`
+	IsActive           bool              `json:"is_active"`
+	Methods            []*ShippingMethod `json:"methods,omitempty"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+type ShippingMethod struct {
+	ID            int64       `json:"id"`
+	ZoneID        int64       `json:"zone_id"`
+	Name          string      `json:"name"`
+	RateType      string      `json:"rate_type"`
+	BaseFee       money.Money `json:"base_fee"`
+	PerKgFee      money.Money `json:"per_kg_fee"`
+	FreeThreshold money.Money `json:"free_threshold"`
+	IsActive      bool        `json:"is_active"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
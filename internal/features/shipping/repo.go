@@ -0,0 +1,339 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/lib/pq"
+)
+
+const (
+	selectZoneQuery = `
+		SELECT id, name, states, postal_code_prefixes, priority, is_active, created_at, updated_at
+		FROM shipping_zones
+	`
+	selectMethodQuery = `
+		SELECT id, zone_id, name, rate_type, currency, base_fee, per_kg_fee, free_threshold, is_active, created_at, updated_at
+		FROM shipping_methods
+	`
+)
+
+type IShippingRepository interface {
+	// Zones
+	CreateZone(ctx context.Context, input *ShippingZone) error
+	ListZones(ctx context.Context) ([]*ShippingZone, error)
+	UpdateZone(ctx context.Context, id int64, input *ZoneUpdate) error
+	DeleteZone(ctx context.Context, id int64) error
+	FindZone(ctx context.Context, state, postalCode string) (*ShippingZone, error)
+
+	// Methods
+	CreateMethod(ctx context.Context, input *ShippingMethod) error
+	GetMethod(ctx context.Context, id int64) (*ShippingMethod, error)
+	ListMethods(ctx context.Context, zoneID int64, activeOnly bool) ([]*ShippingMethod, error)
+	UpdateMethod(ctx context.Context, id int64, input *MethodUpdate) error
+	DeleteMethod(ctx context.Context, id int64) error
+}
+
+type shippingRepository struct {
+	db *sql.DB
+}
+
+func NewShippingRepository(db *sql.DB) IShippingRepository {
+	return &shippingRepository{db: db}
+}
+
+// ------------ Table shipping_zones ------------
+
+func (r *shippingRepository) CreateZone(ctx context.Context, input *ShippingZone) error {
+	query := `
+		INSERT INTO shipping_zones (name, states, postal_code_prefixes, priority)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, is_active, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.Name,
+		pq.Array(input.States),
+		pq.Array(input.PostalCodePrefixes),
+		input.Priority,
+	).Scan(
+		&input.ID,
+		&input.IsActive,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *shippingRepository) ListZones(ctx context.Context) ([]*ShippingZone, error) {
+	query := fmt.Sprintf("%s ORDER BY priority DESC, id", selectZoneQuery)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []*ShippingZone
+	for rows.Next() {
+		z := new(ShippingZone)
+		if err = scanZone(rows, z); err != nil {
+			return nil, err
+		}
+		zones = append(zones, z)
+	}
+
+	return zones, rows.Err()
+}
+
+func (r *shippingRepository) UpdateZone(ctx context.Context, id int64, input *ZoneUpdate) error {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if input.Name != nil {
+		columns = append(columns, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+
+	if input.States != nil {
+		columns = append(columns, fmt.Sprintf("states = $%d", idx))
+		args = append(args, pq.Array(*input.States))
+		idx++
+	}
+
+	if input.PostalCodePrefixes != nil {
+		columns = append(columns, fmt.Sprintf("postal_code_prefixes = $%d", idx))
+		args = append(args, pq.Array(*input.PostalCodePrefixes))
+		idx++
+	}
+
+	if input.Priority != nil {
+		columns = append(columns, fmt.Sprintf("priority = $%d", idx))
+		args = append(args, *input.Priority)
+		idx++
+	}
+
+	if input.IsActive != nil {
+		columns = append(columns, fmt.Sprintf("is_active = $%d", idx))
+		args = append(args, *input.IsActive)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE shipping_zones SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return r.execAffected(ctx, errs.ErrShippingZoneNotFound, query, args...)
+}
+
+func (r *shippingRepository) DeleteZone(ctx context.Context, id int64) error {
+	return r.execAffected(ctx, errs.ErrShippingZoneNotFound, "DELETE FROM shipping_zones WHERE id = $1", id)
+}
+
+// FindZone returns the active zone with the highest priority matching the
+// address, or nil when none does.
+func (r *shippingRepository) FindZone(ctx context.Context, state, postalCode string) (*ShippingZone, error) {
+	query := fmt.Sprintf(`%s
+		WHERE is_active
+			AND (cardinality(states) = 0
+				OR EXISTS (SELECT 1 FROM unnest(states) s WHERE LOWER(s) = LOWER($1)))
+			AND (cardinality(postal_code_prefixes) = 0
+				OR EXISTS (SELECT 1 FROM unnest(postal_code_prefixes) p WHERE $2 LIKE p || '%%'))
+		ORDER BY priority DESC, id
+		LIMIT 1
+	`, selectZoneQuery)
+
+	z := new(ShippingZone)
+	err := scanZone(r.db.QueryRowContext(ctx, query, state, postalCode), z)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return z, nil
+}
+
+// ------------ Table shipping_methods ------------
+
+func (r *shippingRepository) CreateMethod(ctx context.Context, input *ShippingMethod) error {
+	query := `
+		INSERT INTO shipping_methods (zone_id, name, rate_type, currency, base_fee, per_kg_fee, free_threshold)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, is_active, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.ZoneID,
+		input.Name,
+		input.RateType,
+		input.BaseFee.CurrencyCode(),
+		input.BaseFee,
+		input.PerKgFee,
+		input.FreeThreshold,
+	).Scan(
+		&input.ID,
+		&input.IsActive,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *shippingRepository) GetMethod(ctx context.Context, id int64) (*ShippingMethod, error) {
+	query := fmt.Sprintf("%s WHERE id = $1", selectMethodQuery)
+
+	m := new(ShippingMethod)
+	err := scanMethod(r.db.QueryRowContext(ctx, query, id), m)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrShippingMethodNotFound
+		}
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *shippingRepository) ListMethods(ctx context.Context, zoneID int64, activeOnly bool) ([]*ShippingMethod, error) {
+	query := fmt.Sprintf("%s WHERE zone_id = $1 AND (is_active OR NOT $2) ORDER BY id", selectMethodQuery)
+	rows, err := r.db.QueryContext(ctx, query, zoneID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var methods []*ShippingMethod
+	for rows.Next() {
+		m := new(ShippingMethod)
+		if err = scanMethod(rows, m); err != nil {
+			return nil, err
+		}
+		methods = append(methods, m)
+	}
+
+	return methods, rows.Err()
+}
+
+func (r *shippingRepository) UpdateMethod(ctx context.Context, id int64, input *MethodUpdate) error {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if input.Name != nil {
+		columns = append(columns, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+
+	if input.BaseFee != nil {
+		columns = append(columns, fmt.Sprintf("base_fee = $%d", idx))
+		args = append(args, *input.BaseFee)
+		idx++
+	}
+
+	if input.PerKgFee != nil {
+		columns = append(columns, fmt.Sprintf("per_kg_fee = $%d", idx))
+		args = append(args, *input.PerKgFee)
+		idx++
+	}
+
+	if input.FreeThreshold != nil {
+		columns = append(columns, fmt.Sprintf("free_threshold = $%d", idx))
+		args = append(args, *input.FreeThreshold)
+		idx++
+	}
+
+	if input.IsActive != nil {
+		columns = append(columns, fmt.Sprintf("is_active = $%d", idx))
+		args = append(args, *input.IsActive)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE shipping_methods SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return r.execAffected(ctx, errs.ErrShippingMethodNotFound, query, args...)
+}
+
+func (r *shippingRepository) DeleteMethod(ctx context.Context, id int64) error {
+	return r.execAffected(ctx, errs.ErrShippingMethodNotFound, "DELETE FROM shipping_methods WHERE id = $1", id)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanZone(row rowScanner, z *ShippingZone) error {
+	return row.Scan(
+		&z.ID,
+		&z.Name,
+		pq.Array(&z.States),
+		pq.Array(&z.PostalCodePrefixes),
+		&z.Priority,
+		&z.IsActive,
+		&z.CreatedAt,
+		&z.UpdatedAt,
+	)
+}
+
+func scanMethod(row rowScanner, m *ShippingMethod) error {
+	var currency string
+	err := row.Scan(
+		&m.ID,
+		&m.ZoneID,
+		&m.Name,
+		&m.RateType,
+		&currency,
+		&m.BaseFee,
+		&m.PerKgFee,
+		&m.FreeThreshold,
+		&m.IsActive,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	// NUMERIC columns are scanned in the default currency
+	for _, fee := range []*money.Money{&m.BaseFee, &m.PerKgFee, &m.FreeThreshold} {
+		*fee = fee.WithCurrency(currency)
+	}
+
+	return nil
+}
+
+func (r *shippingRepository) execAffected(ctx context.Context, notFound error, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
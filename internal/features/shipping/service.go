@@ -0,0 +1,266 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/features/addresses"
+	"github.com/codepnw/core-ecommerce-system/internal/features/carts"
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+)
+
+type IShippingService interface {
+	// Zones
+	CreateZone(ctx context.Context, req *ZoneCreate) (*ShippingZone, error)
+	ListZones(ctx context.Context) ([]*ShippingZone, error)
+	UpdateZone(ctx context.Context, id int64, req *ZoneUpdate) error
+	DeleteZone(ctx context.Context, id int64) error
+
+	// Methods
+	CreateMethod(ctx context.Context, zoneID int64, req *MethodCreate) (*ShippingMethod, error)
+	UpdateMethod(ctx context.Context, id int64, req *MethodUpdate) error
+	DeleteMethod(ctx context.Context, id int64) error
+
+	// Rates
+	Quote(ctx context.Context, q *ShippingQuote) ([]*ShippingOption, error)
+	QuoteMethod(ctx context.Context, methodID int64, q *ShippingQuote) (*ShippingOption, error)
+	CartOptions(ctx context.Context, userID, addressID, currency string) ([]*ShippingOption, error)
+}
+
+type ShippingServiceConfig struct {
+	ShippingRepo IShippingRepository             `validate:"required"`
+	CartSrv      carts.ICartService              `validate:"required"`
+	AddrSrv      addresses.IAddressServide       `validate:"required"`
+	RateSrv      currencies.IExchangeRateService `validate:"required"`
+}
+
+func NewShippingService(cfg *ShippingServiceConfig) (IShippingService, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("ShippingServiceConfig required all fields: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *ShippingServiceConfig) CreateZone(ctx context.Context, req *ZoneCreate) (*ShippingZone, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	z := &ShippingZone{
+		Name:               req.Name,
+		States:             nonNil(req.States),
+		PostalCodePrefixes: nonNil(req.PostalCodePrefixes),
+		Priority:           req.Priority,
+	}
+	if err := s.ShippingRepo.CreateZone(ctx, z); err != nil {
+		return nil, err
+	}
+
+	return z, nil
+}
+
+func (s *ShippingServiceConfig) ListZones(ctx context.Context) ([]*ShippingZone, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	zones, err := s.ShippingRepo.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, z := range zones {
+		if z.Methods, err = s.ShippingRepo.ListMethods(ctx, z.ID, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return zones, nil
+}
+
+func (s *ShippingServiceConfig) UpdateZone(ctx context.Context, id int64, req *ZoneUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if req.States != nil {
+		*req.States = nonNil(*req.States)
+	}
+	if req.PostalCodePrefixes != nil {
+		*req.PostalCodePrefixes = nonNil(*req.PostalCodePrefixes)
+	}
+
+	return s.ShippingRepo.UpdateZone(ctx, id, req)
+}
+
+func (s *ShippingServiceConfig) DeleteZone(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.ShippingRepo.DeleteZone(ctx, id)
+}
+
+func (s *ShippingServiceConfig) CreateMethod(ctx context.Context, zoneID int64, req *MethodCreate) (*ShippingMethod, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currency := strings.ToUpper(req.Currency)
+	m := &ShippingMethod{
+		ZoneID:        zoneID,
+		Name:          req.Name,
+		RateType:      string(req.RateType),
+		BaseFee:       req.BaseFee.WithCurrency(currency),
+		PerKgFee:      req.PerKgFee.WithCurrency(currency),
+		FreeThreshold: req.FreeThreshold.WithCurrency(currency),
+	}
+	if err := s.ShippingRepo.CreateMethod(ctx, m); err != nil {
+		if strings.Contains(err.Error(), "shipping_methods_zone_id_fkey") {
+			return nil, errs.ErrShippingZoneNotFound
+		}
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *ShippingServiceConfig) UpdateMethod(ctx context.Context, id int64, req *MethodUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.ShippingRepo.UpdateMethod(ctx, id, req)
+}
+
+func (s *ShippingServiceConfig) DeleteMethod(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.ShippingRepo.DeleteMethod(ctx, id)
+}
+
+// Quote lists the active methods of the zone matching the address with their fee.
+func (s *ShippingServiceConfig) Quote(ctx context.Context, q *ShippingQuote) ([]*ShippingOption, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	zone, err := s.ShippingRepo.FindZone(ctx, q.State, q.PostalCode)
+	if err != nil {
+		return nil, fmt.Errorf("find shipping zone failed: %w", err)
+	}
+	if zone == nil {
+		return []*ShippingOption{}, nil
+	}
+
+	methods, err := s.ShippingRepo.ListMethods(ctx, zone.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("list shipping methods failed: %w", err)
+	}
+
+	conv := s.RateSrv.NewConverter(q.Subtotal.CurrencyCode())
+	options := make([]*ShippingOption, 0, len(methods))
+	for _, m := range methods {
+		opt, err := s.price(ctx, conv, zone, m, q)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, opt)
+	}
+
+	return options, nil
+}
+
+// QuoteMethod prices one method, failing when it does not serve the address.
+func (s *ShippingServiceConfig) QuoteMethod(ctx context.Context, methodID int64, q *ShippingQuote) (*ShippingOption, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	method, err := s.ShippingRepo.GetMethod(ctx, methodID)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := s.ShippingRepo.FindZone(ctx, q.State, q.PostalCode)
+	if err != nil {
+		return nil, fmt.Errorf("find shipping zone failed: %w", err)
+	}
+	if zone == nil || zone.ID != method.ZoneID || !method.IsActive {
+		return nil, errs.ErrShippingMethodUnavailable
+	}
+
+	conv := s.RateSrv.NewConverter(q.Subtotal.CurrencyCode())
+	return s.price(ctx, conv, zone, method, q)
+}
+
+func (s *ShippingServiceConfig) CartOptions(ctx context.Context, userID, addressID, currency string) ([]*ShippingOption, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	addr, err := s.AddrSrv.GetAddressByID(ctx, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if addr.UserID != userID {
+		return nil, errs.ErrAddressNotFound
+	}
+
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	items, err := s.CartSrv.GetCart(ctx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &ShippingQuote{
+		State:      addr.State,
+		PostalCode: addr.PostalCode,
+		Subtotal:   money.New(0, currency),
+	}
+	for _, item := range items {
+		q.Subtotal = q.Subtotal.Add(item.ProductPrice.Mul(item.ProductQuantity))
+		q.WeightGrams += int64(item.ProductWeight) * item.ProductQuantity
+	}
+
+	return s.Quote(ctx, q)
+}
+
+// price calculates the fee in the method currency and converts it to the quote currency.
+func (s *ShippingServiceConfig) price(ctx context.Context, conv *currencies.Converter, zone *ShippingZone, m *ShippingMethod, q *ShippingQuote) (*ShippingOption, error) {
+	fee := m.BaseFee
+
+	switch RateType(m.RateType) {
+	case RateWeight:
+		kg := (q.WeightGrams + 999) / 1000
+		fee = fee.Add(m.PerKgFee.Mul(kg))
+	case RateFreeOverThreshold:
+		threshold, err := conv.Convert(ctx, m.FreeThreshold)
+		if err != nil {
+			return nil, err
+		}
+		if q.Subtotal.Amount >= threshold.Amount {
+			fee = money.New(0, fee.CurrencyCode())
+		}
+	}
+
+	fee, err := conv.Convert(ctx, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShippingOption{
+		MethodID: m.ID,
+		Name:     m.Name,
+		RateType: m.RateType,
+		ZoneID:   zone.ID,
+		ZoneName: zone.Name,
+		Fee:      fee,
+	}, nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package tax
+
+import "github.com/codepnw/core-ecommerce-system/internal/utils/money"
+
+type TaxClassCreate struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description,omitempty" validate:"omitempty"`
+}
+
+type TaxClassUpdate struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty"`
+}
+
+type TaxClassCategories struct {
+	CategoryIDs []int64 `json:"category_ids" validate:"required,min=1"`
+}
+
+type TaxRateCreate struct {
+	TaxClassID       *int64  `json:"tax_class_id,omitempty" validate:"omitempty"`
+	Name             string  `json:"name" validate:"required,max=100"`
+	State            *string `json:"state,omitempty" validate:"omitempty,max=100"`
+	PostalCodePrefix *string `json:"postal_code_prefix,omitempty" validate:"omitempty,max=20"`
+	Rate             float64 `json:"rate" validate:"gte=0,lte=100"`
+	IsInclusive      bool    `json:"is_inclusive"`
+	Priority         int     `json:"priority"`
+}
+
+type TaxRateUpdate struct {
+	Name             *string  `json:"name,omitempty" validate:"omitempty,max=100"`
+	State            *string  `json:"state,omitempty" validate:"omitempty,max=100"`
+	PostalCodePrefix *string  `json:"postal_code_prefix,omitempty" validate:"omitempty,max=20"`
+	Rate             *float64 `json:"rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	IsInclusive      *bool    `json:"is_inclusive,omitempty" validate:"omitempty"`
+	Priority         *int     `json:"priority,omitempty" validate:"omitempty"`
+}
+
+// TaxAddress is the part of the shipping address tax rules are matched on.
+type TaxAddress struct {
+	State      string
+	PostalCode string
+}
+
+// TaxLine is one order line to calculate tax for, Amount is price * quantity.
+type TaxLine struct {
+	ProductID int64
+	Amount    money.Money
+}
+
+type TaxLineResult struct {
+	ProductID   int64
+	Name        *string
+	Rate        float64
+	IsInclusive bool
+	Amount      money.Money
+}
+
+// TaxResult holds one result per input line, in the same order. Exclusive is
+// the part of Total that has to be added on top of the prices.
+type TaxResult struct {
+	Lines     []*TaxLineResult
+	Total     money.Money
+	Exclusive money.Money
+}
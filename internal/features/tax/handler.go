@@ -0,0 +1,207 @@
+package tax
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	classIDKey    = "class_id"
+	rateIDKey     = "rate_id"
+	categoryIDKey = "category_id"
+)
+
+type taxHandler struct {
+	srv ITaxService
+}
+
+func NewTaxHandler(srv ITaxService) *taxHandler {
+	return &taxHandler{srv: srv}
+}
+
+// ------------ Tax classes ------------
+
+func (h *taxHandler) CreateClass(ctx *fiber.Ctx) error {
+	req := new(TaxClassCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	class, err := h.srv.CreateClass(ctx.Context(), req)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "tax class created", class)
+}
+
+func (h *taxHandler) ListClasses(ctx *fiber.Ctx) error {
+	classes, err := h.srv.ListClasses(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", classes)
+}
+
+func (h *taxHandler) UpdateClass(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, classIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(TaxClassUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.UpdateClass(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "tax class updated", nil)
+}
+
+func (h *taxHandler) DeleteClass(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, classIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.DeleteClass(ctx.Context(), id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+func (h *taxHandler) AssignCategories(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, classIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(TaxClassCategories)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.AssignCategories(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "categories assigned", nil)
+}
+
+func (h *taxHandler) UnassignCategory(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, classIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	categoryID, err := commons.GetParamIDInt(ctx, categoryIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.UnassignCategory(ctx.Context(), id, categoryID); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+// ------------ Tax rates ------------
+
+func (h *taxHandler) CreateRate(ctx *fiber.Ctx) error {
+	req := new(TaxRateCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	rate, err := h.srv.CreateRate(ctx.Context(), req)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "tax rate created", rate)
+}
+
+func (h *taxHandler) ListRates(ctx *fiber.Ctx) error {
+	rates, err := h.srv.ListRates(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", rates)
+}
+
+func (h *taxHandler) UpdateRate(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, rateIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(TaxRateUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.UpdateRate(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "tax rate updated", nil)
+}
+
+func (h *taxHandler) DeleteRate(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, rateIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.DeleteRate(ctx.Context(), id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+func (h *taxHandler) handleError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrTaxClassNotFound),
+		errors.Is(err, errs.ErrTaxRateNotFound),
+		errors.Is(err, errs.ErrCategoryNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrTaxClassExists):
+		return response.Conflict(ctx, err.Error())
+	case errors.Is(err, errs.ErrNoFieldUpdate):
+		return response.BadRequest(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
@@ -0,0 +1,26 @@
+package tax
+
+import "time"
+
+type TaxClass struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaxRate is a rule matched against the shipping address. A nil TaxClassID,
+// State or PostalCodePrefix matches anything.
+type TaxRate struct {
+	ID               int64     `json:"id"`
+	TaxClassID       *int64    `json:"tax_class_id"`
+	Name             string    `json:"name"`
+	State            *string   `json:"state"`
+	PostalCodePrefix *string   `json:"postal_code_prefix"`
+	Rate             float64   `json:"rate"`
+	IsInclusive      bool      `json:"is_inclusive"`
+	Priority         int       `json:"priority"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,327 @@
+package tax
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/lib/pq"
+)
+
+const (
+	selectTaxRateQuery = `
+		SELECT id, tax_class_id, name, state, postal_code_prefix, rate, is_inclusive, priority, created_at, updated_at
+		FROM tax_rates
+	`
+)
+
+type ITaxRepository interface {
+	// Tax classes
+	CreateClass(ctx context.Context, input *TaxClass) error
+	ListClasses(ctx context.Context) ([]*TaxClass, error)
+	UpdateClass(ctx context.Context, id int64, input *TaxClassUpdate) error
+	DeleteClass(ctx context.Context, id int64) error
+	AssignCategories(ctx context.Context, classID int64, categoryIDs []int64) error
+	UnassignCategory(ctx context.Context, classID, categoryID int64) error
+	GetProductClasses(ctx context.Context, productIDs []int64) (map[int64]int64, error)
+
+	// Tax rates
+	CreateRate(ctx context.Context, input *TaxRate) error
+	ListRates(ctx context.Context) ([]*TaxRate, error)
+	UpdateRate(ctx context.Context, id int64, input *TaxRateUpdate) error
+	DeleteRate(ctx context.Context, id int64) error
+	FindRate(ctx context.Context, classID *int64, addr *TaxAddress) (*TaxRate, error)
+}
+
+type taxRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRepository(db *sql.DB) ITaxRepository {
+	return &taxRepository{db: db}
+}
+
+// ------------ Table tax_classes ------------
+
+func (r *taxRepository) CreateClass(ctx context.Context, input *TaxClass) error {
+	query := `
+		INSERT INTO tax_classes (name, description)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, input.Name, input.Description).Scan(
+		&input.ID,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *taxRepository) ListClasses(ctx context.Context) ([]*TaxClass, error) {
+	query := `
+		SELECT id, name, COALESCE(description, ''), created_at, updated_at
+		FROM tax_classes
+		ORDER BY name
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []*TaxClass
+	for rows.Next() {
+		c := new(TaxClass)
+		err = rows.Scan(
+			&c.ID,
+			&c.Name,
+			&c.Description,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, c)
+	}
+
+	return classes, rows.Err()
+}
+
+func (r *taxRepository) UpdateClass(ctx context.Context, id int64, input *TaxClassUpdate) error {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if input.Name != nil {
+		columns = append(columns, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+
+	if input.Description != nil {
+		columns = append(columns, fmt.Sprintf("description = $%d", idx))
+		args = append(args, *input.Description)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE tax_classes SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return r.execAffected(ctx, errs.ErrTaxClassNotFound, query, args...)
+}
+
+func (r *taxRepository) DeleteClass(ctx context.Context, id int64) error {
+	return r.execAffected(ctx, errs.ErrTaxClassNotFound, "DELETE FROM tax_classes WHERE id = $1", id)
+}
+
+func (r *taxRepository) AssignCategories(ctx context.Context, classID int64, categoryIDs []int64) error {
+	query := `UPDATE categories SET tax_class_id = $1, updated_at = now() WHERE id = ANY($2)`
+	return r.execAffected(ctx, errs.ErrCategoryNotFound, query, classID, pq.Array(categoryIDs))
+}
+
+func (r *taxRepository) UnassignCategory(ctx context.Context, classID, categoryID int64) error {
+	query := `UPDATE categories SET tax_class_id = NULL, updated_at = now() WHERE id = $1 AND tax_class_id = $2`
+	return r.execAffected(ctx, errs.ErrCategoryNotFound, query, categoryID, classID)
+}
+
+// GetProductClasses returns the tax class of each product, taken from its
+// categories. Products without a classed category are left out.
+func (r *taxRepository) GetProductClasses(ctx context.Context, productIDs []int64) (map[int64]int64, error) {
+	query := `
+		SELECT DISTINCT ON (pc.product_id) pc.product_id, c.tax_class_id
+		FROM product_categories pc
+		JOIN categories c ON c.id = pc.category_id
+		WHERE pc.product_id = ANY($1) AND c.tax_class_id IS NOT NULL
+		ORDER BY pc.product_id, c.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classes := make(map[int64]int64)
+	for rows.Next() {
+		var productID, classID int64
+		if err = rows.Scan(&productID, &classID); err != nil {
+			return nil, err
+		}
+		classes[productID] = classID
+	}
+
+	return classes, rows.Err()
+}
+
+// ------------ Table tax_rates ------------
+
+func (r *taxRepository) CreateRate(ctx context.Context, input *TaxRate) error {
+	query := `
+		INSERT INTO tax_rates (tax_class_id, name, state, postal_code_prefix, rate, is_inclusive, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.TaxClassID,
+		input.Name,
+		input.State,
+		input.PostalCodePrefix,
+		input.Rate,
+		input.IsInclusive,
+		input.Priority,
+	).Scan(
+		&input.ID,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *taxRepository) ListRates(ctx context.Context) ([]*TaxRate, error) {
+	query := fmt.Sprintf("%s ORDER BY tax_class_id NULLS FIRST, state NULLS FIRST, postal_code_prefix NULLS FIRST, id", selectTaxRateQuery)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*TaxRate
+	for rows.Next() {
+		t := new(TaxRate)
+		if err = scanTaxRate(rows, t); err != nil {
+			return nil, err
+		}
+		rates = append(rates, t)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *taxRepository) UpdateRate(ctx context.Context, id int64, input *TaxRateUpdate) error {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if input.Name != nil {
+		columns = append(columns, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+
+	if input.State != nil {
+		columns = append(columns, fmt.Sprintf("state = NULLIF($%d, '')", idx))
+		args = append(args, *input.State)
+		idx++
+	}
+
+	if input.PostalCodePrefix != nil {
+		columns = append(columns, fmt.Sprintf("postal_code_prefix = NULLIF($%d, '')", idx))
+		args = append(args, *input.PostalCodePrefix)
+		idx++
+	}
+
+	if input.Rate != nil {
+		columns = append(columns, fmt.Sprintf("rate = $%d", idx))
+		args = append(args, *input.Rate)
+		idx++
+	}
+
+	if input.IsInclusive != nil {
+		columns = append(columns, fmt.Sprintf("is_inclusive = $%d", idx))
+		args = append(args, *input.IsInclusive)
+		idx++
+	}
+
+	if input.Priority != nil {
+		columns = append(columns, fmt.Sprintf("priority = $%d", idx))
+		args = append(args, *input.Priority)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE tax_rates SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return r.execAffected(ctx, errs.ErrTaxRateNotFound, query, args...)
+}
+
+func (r *taxRepository) DeleteRate(ctx context.Context, id int64) error {
+	return r.execAffected(ctx, errs.ErrTaxRateNotFound, "DELETE FROM tax_rates WHERE id = $1", id)
+}
+
+// FindRate returns the most specific rule for the class and address: class
+// over catch-all, longer postal prefix over shorter, state over any, then
+// priority. It returns nil when no rule matches.
+func (r *taxRepository) FindRate(ctx context.Context, classID *int64, addr *TaxAddress) (*TaxRate, error) {
+	query := fmt.Sprintf(`%s
+		WHERE (tax_class_id IS NULL OR tax_class_id = $1)
+			AND (state IS NULL OR LOWER(state) = LOWER($2))
+			AND (postal_code_prefix IS NULL OR $3 LIKE postal_code_prefix || '%%')
+		ORDER BY tax_class_id IS NOT NULL DESC,
+			COALESCE(LENGTH(postal_code_prefix), 0) DESC,
+			state IS NOT NULL DESC,
+			priority DESC,
+			id
+		LIMIT 1
+	`, selectTaxRateQuery)
+
+	t := new(TaxRate)
+	err := scanTaxRate(r.db.QueryRowContext(ctx, query, classID, addr.State, addr.PostalCode), t)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTaxRate(row rowScanner, t *TaxRate) error {
+	return row.Scan(
+		&t.ID,
+		&t.TaxClassID,
+		&t.Name,
+		&t.State,
+		&t.PostalCodePrefix,
+		&t.Rate,
+		&t.IsInclusive,
+		&t.Priority,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+}
+
+func (r *taxRepository) execAffected(ctx context.Context, notFound error, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
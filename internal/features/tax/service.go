@@ -0,0 +1,243 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+type ITaxService interface {
+	// Tax classes
+	CreateClass(ctx context.Context, req *TaxClassCreate) (*TaxClass, error)
+	ListClasses(ctx context.Context) ([]*TaxClass, error)
+	UpdateClass(ctx context.Context, id int64, req *TaxClassUpdate) error
+	DeleteClass(ctx context.Context, id int64) error
+	AssignCategories(ctx context.Context, classID int64, req *TaxClassCategories) error
+	UnassignCategory(ctx context.Context, classID, categoryID int64) error
+
+	// Tax rates
+	CreateRate(ctx context.Context, req *TaxRateCreate) (*TaxRate, error)
+	ListRates(ctx context.Context) ([]*TaxRate, error)
+	UpdateRate(ctx context.Context, id int64, req *TaxRateUpdate) error
+	DeleteRate(ctx context.Context, id int64) error
+
+	// Calculation
+	Calculate(ctx context.Context, addr *TaxAddress, lines []*TaxLine, discount money.Money) (*TaxResult, error)
+}
+
+type taxService struct {
+	repo ITaxRepository
+}
+
+func NewTaxService(repo ITaxRepository) ITaxService {
+	return &taxService{repo: repo}
+}
+
+func (s *taxService) CreateClass(ctx context.Context, req *TaxClassCreate) (*TaxClass, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	c := &TaxClass{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.repo.CreateClass(ctx, c); err != nil {
+		if strings.Contains(err.Error(), "tax_classes_name_key") {
+			return nil, errs.ErrTaxClassExists
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *taxService) ListClasses(ctx context.Context) ([]*TaxClass, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.ListClasses(ctx)
+}
+
+func (s *taxService) UpdateClass(ctx context.Context, id int64, req *TaxClassUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := s.repo.UpdateClass(ctx, id, req)
+	if err != nil && strings.Contains(err.Error(), "tax_classes_name_key") {
+		return errs.ErrTaxClassExists
+	}
+	return err
+}
+
+func (s *taxService) DeleteClass(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.DeleteClass(ctx, id)
+}
+
+func (s *taxService) AssignCategories(ctx context.Context, classID int64, req *TaxClassCategories) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	err := s.repo.AssignCategories(ctx, classID, req.CategoryIDs)
+	if err != nil && strings.Contains(err.Error(), "categories_tax_class_id_fkey") {
+		return errs.ErrTaxClassNotFound
+	}
+	return err
+}
+
+func (s *taxService) UnassignCategory(ctx context.Context, classID, categoryID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.UnassignCategory(ctx, classID, categoryID)
+}
+
+func (s *taxService) CreateRate(ctx context.Context, req *TaxRateCreate) (*TaxRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	t := &TaxRate{
+		TaxClassID:       req.TaxClassID,
+		Name:             req.Name,
+		State:            req.State,
+		PostalCodePrefix: req.PostalCodePrefix,
+		Rate:             req.Rate,
+		IsInclusive:      req.IsInclusive,
+		Priority:         req.Priority,
+	}
+	if err := s.repo.CreateRate(ctx, t); err != nil {
+		if strings.Contains(err.Error(), "tax_rates_tax_class_id_fkey") {
+			return nil, errs.ErrTaxClassNotFound
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *taxService) ListRates(ctx context.Context) ([]*TaxRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.ListRates(ctx)
+}
+
+func (s *taxService) UpdateRate(ctx context.Context, id int64, req *TaxRateUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.UpdateRate(ctx, id, req)
+}
+
+func (s *taxService) DeleteRate(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.DeleteRate(ctx, id)
+}
+
+// Calculate resolves a rate for every line from its product tax class and the
+// address. The order discount is spread over the lines by amount first, so
+// tax is charged on what the customer actually pays.
+func (s *taxService) Calculate(ctx context.Context, addr *TaxAddress, lines []*TaxLine, discount money.Money) (*TaxResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currency := discount.CurrencyCode()
+	result := &TaxResult{
+		Lines:     make([]*TaxLineResult, 0, len(lines)),
+		Total:     money.New(0, currency),
+		Exclusive: money.New(0, currency),
+	}
+	if len(lines) == 0 {
+		return result, nil
+	}
+
+	productIDs := make([]int64, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	classes, err := s.repo.GetProductClasses(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get product tax classes failed: %w", err)
+	}
+
+	rates := make(map[int64]*TaxRate) // by class ID, 0 for unclassed products
+	amounts := allocateDiscount(lines, discount)
+
+	for i, line := range lines {
+		classID, ok := classes[line.ProductID]
+
+		rate, found := rates[classID]
+		if !found {
+			var class *int64
+			if ok {
+				class = &classID
+			}
+			if rate, err = s.repo.FindRate(ctx, class, addr); err != nil {
+				return nil, fmt.Errorf("find tax rate failed: %w", err)
+			}
+			rates[classID] = rate
+		}
+
+		lineTax := &TaxLineResult{
+			ProductID: line.ProductID,
+			Amount:    money.New(0, line.Amount.CurrencyCode()),
+		}
+		if rate != nil {
+			lineTax.Name = &rate.Name
+			lineTax.Rate = rate.Rate
+			lineTax.IsInclusive = rate.IsInclusive
+			lineTax.Amount = calculateTax(amounts[i], rate)
+		}
+
+		result.Lines = append(result.Lines, lineTax)
+		result.Total = result.Total.Add(lineTax.Amount)
+		if !lineTax.IsInclusive {
+			result.Exclusive = result.Exclusive.Add(lineTax.Amount)
+		}
+	}
+
+	return result, nil
+}
+
+// calculateTax returns the tax contained in (inclusive) or owed on top of
+// (exclusive) amount.
+func calculateTax(amount money.Money, rate *TaxRate) money.Money {
+	if rate.IsInclusive {
+		net := math.Round(float64(amount.Amount) / (1 + rate.Rate/100))
+		return money.New(amount.Amount-int64(net), amount.CurrencyCode())
+	}
+	return amount.Percent(rate.Rate)
+}
+
+// allocateDiscount splits discount over the lines in proportion to their
+// amounts; the last line takes the rounding remainder.
+func allocateDiscount(lines []*TaxLine, discount money.Money) []money.Money {
+	amounts := make([]money.Money, len(lines))
+
+	var total int64
+	for _, line := range lines {
+		total += line.Amount.Amount
+	}
+
+	remaining := discount.Amount
+	for i, line := range lines {
+		share := remaining
+		if i < len(lines)-1 && total > 0 {
+			share = int64(math.Round(float64(discount.Amount) * float64(line.Amount.Amount) / float64(total)))
+			share = min(share, remaining)
+		}
+		remaining -= share
+		amounts[i] = line.Amount.Sub(money.New(share, line.Amount.CurrencyCode()))
+	}
+
+	return amounts
+}
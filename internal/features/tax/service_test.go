@@ -0,0 +1,75 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+)
+
+func TestCalculateTax(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		rate      float64
+		inclusive bool
+		want      int64
+	}{
+		{name: "exclusive", amount: 100000, rate: 7, want: 7000},
+		{name: "exclusive rounded", amount: 999, rate: 7, want: 70},
+		{name: "inclusive", amount: 50000, rate: 7, inclusive: true, want: 3271},
+		{name: "inclusive rounded", amount: 107, rate: 7, inclusive: true, want: 7},
+		{name: "zero rate", amount: 50000, rate: 0, want: 0},
+		{name: "zero amount", amount: 0, rate: 7, inclusive: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateTax(money.New(tt.amount, "THB"), &TaxRate{Rate: tt.rate, IsInclusive: tt.inclusive})
+			if got != money.New(tt.want, "THB") {
+				t.Errorf("calculateTax = %v, want %d THB", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		amounts  []int64
+		discount int64
+		want     []int64
+	}{
+		{name: "no discount", amounts: []int64{100000, 50000}, discount: 0, want: []int64{100000, 50000}},
+		{name: "by amount", amounts: []int64{100000, 50000}, discount: 10000, want: []int64{93333, 46667}},
+		{name: "last line takes the remainder", amounts: []int64{100, 100, 100}, discount: 100, want: []int64{67, 67, 66}},
+		{name: "whole order", amounts: []int64{3000, 2000}, discount: 5000, want: []int64{0, 0}},
+		{name: "free lines", amounts: []int64{0, 0}, discount: 0, want: []int64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]*TaxLine, len(tt.amounts))
+			for i, amount := range tt.amounts {
+				lines[i] = &TaxLine{ProductID: int64(i + 1), Amount: money.New(amount, "THB")}
+			}
+
+			got := allocateDiscount(lines, money.New(tt.discount, "THB"))
+			if len(got) != len(tt.want) {
+				t.Fatalf("allocateDiscount returned %d amounts, want %d", len(got), len(tt.want))
+			}
+
+			var before, after int64
+			for i := range got {
+				if got[i] != money.New(tt.want[i], "THB") {
+					t.Errorf("line %d = %v, want %d THB", i, got[i], tt.want[i])
+				}
+				before += tt.amounts[i]
+				after += got[i].Amount
+			}
+			// The whole discount is spread, no minor unit lost to rounding
+			if before-after != tt.discount {
+				t.Errorf("lines lost %d, want the discount %d", before-after, tt.discount)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package users
 
+import "github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
+
 type Role string
 
 const (
@@ -19,6 +21,17 @@ type UserUpdate struct {
 	FullName *string `json:"full_name,omitempty" validate:"omitempty"`
 }
 
+type UserFilter struct {
+	Limit  uint
+	Offset uint
+	Cursor string
+}
+
+type UserListResponse struct {
+	Users []*User      `json:"users"`
+	Page  *cursor.Page `json:"-"`
+}
+
 type UserUpdateForAdmin struct {
 	Role *Role `json:"role,omitempty" validate:"omitempty,oneof=customer staff admin"`
 }
@@ -56,15 +56,21 @@ func (h *userHandler) GetUser(ctx *fiber.Ctx) error {
 }
 
 func (h *userHandler) GetUsers(ctx *fiber.Ctx) error {
-	limit := ctx.QueryInt("limit")
-	offset := ctx.QueryInt("offset")
+	filter := &UserFilter{
+		Limit:  uint(ctx.QueryInt("limit")),
+		Offset: uint(ctx.QueryInt("offset")),
+		Cursor: ctx.Query("cursor"),
+	}
 
-	users, err := h.srv.GetUsers(ctx.Context(), uint(limit), uint(offset))
+	users, err := h.srv.GetUsers(ctx.Context(), filter)
 	if err != nil {
+		if errors.Is(err, errs.ErrInvalidCursor) {
+			return response.BadRequest(ctx, err.Error())
+		}
 		return response.InternalServerError(ctx, err)
 	}
 
-	return response.Success(ctx, "", users)
+	return response.Paginated(ctx, "", users.Users, users.Page)
 }
 
 func (h *userHandler) UpdateUser(ctx *fiber.Ctx) error {
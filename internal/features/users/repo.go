@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
 )
 
@@ -21,7 +22,8 @@ type IUserRepository interface {
 	CreateTx(ctx context.Context, tx *sql.Tx, input *User) (*User, error)
 	GetByID(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
-	List(ctx context.Context, limit, offset uint) ([]*User, error)
+	List(ctx context.Context, filter *UserFilter) ([]*User, *cursor.Page, error)
+	Count(ctx context.Context) (int64, error)
 	Update(ctx context.Context, input *User) error
 	Delete(ctx context.Context, id string) error
 }
@@ -125,17 +127,47 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*User, error)
 	return u, nil
 }
 
-func (r *userRepository) List(ctx context.Context, limit uint, offset uint) ([]*User, error) {
-	query := fmt.Sprintf("%s LIMIT $1 OFFSET $2", selectUserQuery)
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+// userListColumns are the sort columns of List, newest users first.
+var userListColumns = map[string]bool{"created_at": true}
+
+// List returns a page of users, after or before filter.Cursor when given,
+// otherwise at filter.Offset.
+func (r *userRepository) List(ctx context.Context, filter *UserFilter) ([]*User, *cursor.Page, error) {
+	keyset := &cursor.Keyset{Listing: "users", Column: "created_at", Sort: "DESC"}
+	if err := keyset.Continue(filter.Cursor, userListColumns); err != nil {
+		return nil, nil, err
+	}
+
+	where, args := keyset.Where(nil)
+	if where != "" {
+		where = "WHERE " + where
+	}
+
+	// Offset mode is kept for older clients, a cursor replaces it
+	offset := int(filter.Offset)
+	if keyset.Cursor != nil {
+		offset = 0
+	}
+
+	// One extra row tells whether there is a next page
+	query := fmt.Sprintf(`
+		SELECT id, email, full_name, role, created_at, updated_at, %s
+		FROM users %s
+		ORDER BY %s LIMIT $%d OFFSET $%d
+	`, keyset.KeyColumns(), where, keyset.OrderBy(), len(args)+1, len(args)+2)
+	args = append(args, filter.Limit+1, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var users []*User
+	var keys []cursor.Key
 	for rows.Next() {
 		u := new(User)
+		var key cursor.Key
 		err = rows.Scan(
 			&u.ID,
 			&u.Email,
@@ -143,17 +175,30 @@ func (r *userRepository) List(ctx context.Context, limit uint, offset uint) ([]*
 			&u.Role,
 			&u.CreatedAt,
 			&u.UpdatedAt,
+			&key.Value,
+			&key.ID,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		users = append(users, u)
+		keys = append(keys, key)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	users, page := cursor.Paginate(keyset, int(filter.Limit), offset, users, keys)
+	return users, page, nil
+}
+
+func (r *userRepository) Count(ctx context.Context) (int64, error) {
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&total); err != nil {
+		return 0, err
 	}
-	return users, nil
+	return total, nil
 }
 
 func (r *userRepository) Update(ctx context.Context, input *User) error {
@@ -14,7 +14,7 @@ type IUserService interface {
 	CreateUser(ctx context.Context, req *UserCreate) (*User, error)
 	CreateUserTx(ctx context.Context, tx *sql.Tx, req *UserCreate) (*User, error)
 	GetUser(ctx context.Context, id string) (*User, error)
-	GetUsers(ctx context.Context, limit, offset uint) ([]*User, error)
+	GetUsers(ctx context.Context, filter *UserFilter) (*UserListResponse, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	UpdateUser(ctx context.Context, id string, req *UserUpdate) error
 	DeleteUser(ctx context.Context, id string) error
@@ -87,15 +87,24 @@ func (s *userService) GetUser(ctx context.Context, id string) (*User, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *userService) GetUsers(ctx context.Context, limit uint, offset uint) ([]*User, error) {
+func (s *userService) GetUsers(ctx context.Context, filter *UserFilter) (*UserListResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	if limit < 10 {
-		limit = 10
+	if filter.Limit < 10 {
+		filter.Limit = 10
 	}
 
-	return s.repo.List(ctx, limit, offset)
+	users, page, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Total, err = s.repo.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	return &UserListResponse{Users: users, Page: page}, nil
 }
 
 func (s *userService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
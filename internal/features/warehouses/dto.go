@@ -0,0 +1,18 @@
+package warehouses
+
+type WarehouseCreate struct {
+	Code     string `json:"code" validate:"required,max=30"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Priority int    `json:"priority"`
+}
+
+type WarehouseUpdate struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Priority *int    `json:"priority,omitempty" validate:"omitempty"`
+	IsActive *bool   `json:"is_active,omitempty" validate:"omitempty"`
+}
+
+type StockFilter struct {
+	Limit  int
+	Offset int
+}
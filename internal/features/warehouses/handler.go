@@ -0,0 +1,129 @@
+package warehouses
+
+import (
+	"errors"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/commons"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+const warehouseIDKey = "warehouse_id"
+
+type warehouseHandler struct {
+	srv IWarehouseService
+}
+
+func NewWarehouseHandler(srv IWarehouseService) *warehouseHandler {
+	return &warehouseHandler{srv: srv}
+}
+
+func (h *warehouseHandler) Create(ctx *fiber.Ctx) error {
+	req := new(WarehouseCreate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	w, err := h.srv.Create(ctx.Context(), req)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Created(ctx, "warehouse created", w)
+}
+
+func (h *warehouseHandler) List(ctx *fiber.Ctx) error {
+	warehouses, err := h.srv.List(ctx.Context())
+	if err != nil {
+		return response.InternalServerError(ctx, err)
+	}
+
+	return response.Success(ctx, "", warehouses)
+}
+
+func (h *warehouseHandler) GetByID(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, warehouseIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	w, err := h.srv.GetByID(ctx.Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", w)
+}
+
+func (h *warehouseHandler) Update(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, warehouseIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	req := new(WarehouseUpdate)
+	if err := ctx.BodyParser(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.Update(ctx.Context(), id, req); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "warehouse updated", nil)
+}
+
+func (h *warehouseHandler) Delete(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, warehouseIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	if err := h.srv.Delete(ctx.Context(), id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+func (h *warehouseHandler) ListStock(ctx *fiber.Ctx) error {
+	id, err := commons.GetParamIDInt(ctx, warehouseIDKey)
+	if err != nil {
+		return response.BadRequest(ctx, err.Error())
+	}
+
+	filter := &StockFilter{
+		Limit:  ctx.QueryInt("limit"),
+		Offset: ctx.QueryInt("offset"),
+	}
+
+	stock, err := h.srv.ListStock(ctx.Context(), id, filter)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return response.Success(ctx, "", stock)
+}
+
+func (h *warehouseHandler) handleError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errs.ErrWarehouseNotFound):
+		return response.NotFound(ctx, err.Error())
+	case errors.Is(err, errs.ErrWarehouseCodeExists),
+		errors.Is(err, errs.ErrWarehouseInUse):
+		return response.Conflict(ctx, err.Error())
+	case errors.Is(err, errs.ErrNoFieldUpdate):
+		return response.BadRequest(ctx, err.Error())
+	}
+	return response.InternalServerError(ctx, err)
+}
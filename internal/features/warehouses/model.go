@@ -0,0 +1,26 @@
+package warehouses
+
+import "time"
+
+// Warehouse holds stock. Orders are allocated to active warehouses, the
+// highest priority first.
+type Warehouse struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Priority  int       `json:"priority"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WarehouseStock is the stock of one product variant in a warehouse.
+type WarehouseStock struct {
+	ProductID   int64     `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	VariantID   int64     `json:"variant_id"`
+	SKU         string    `json:"sku"`
+	Stock       int       `json:"stock"`
+	Available   int       `json:"available"` // stock minus unexpired reservations
+	UpdatedAt   time.Time `json:"updated_at"`
+}
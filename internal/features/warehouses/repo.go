@@ -0,0 +1,217 @@
+package warehouses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+const (
+	selectWarehouseQuery = `
+		SELECT id, code, name, priority, is_active, created_at, updated_at
+		FROM warehouses
+	`
+)
+
+type IWarehouseRepository interface {
+	Create(ctx context.Context, input *Warehouse) error
+	GetByID(ctx context.Context, id int64) (*Warehouse, error)
+	List(ctx context.Context) ([]*Warehouse, error)
+	Update(ctx context.Context, id int64, input *WarehouseUpdate) error
+	Delete(ctx context.Context, id int64) error
+	ListStock(ctx context.Context, id int64, filter *StockFilter) ([]*WarehouseStock, error)
+}
+
+type warehouseRepository struct {
+	db *sql.DB
+}
+
+func NewWarehouseRepository(db *sql.DB) IWarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+func (r *warehouseRepository) Create(ctx context.Context, input *Warehouse) error {
+	query := `
+		INSERT INTO warehouses (code, name, priority)
+		VALUES ($1, $2, $3)
+		RETURNING id, is_active, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.Code,
+		input.Name,
+		input.Priority,
+	).Scan(
+		&input.ID,
+		&input.IsActive,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+}
+
+func (r *warehouseRepository) GetByID(ctx context.Context, id int64) (*Warehouse, error) {
+	w := new(Warehouse)
+	err := scanWarehouse(r.db.QueryRowContext(ctx, selectWarehouseQuery+" WHERE id = $1", id), w)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (r *warehouseRepository) List(ctx context.Context) ([]*Warehouse, error) {
+	rows, err := r.db.QueryContext(ctx, selectWarehouseQuery+" ORDER BY priority DESC, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []*Warehouse
+	for rows.Next() {
+		w := new(Warehouse)
+		if err = scanWarehouse(rows, w); err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+
+	return warehouses, rows.Err()
+}
+
+func (r *warehouseRepository) Update(ctx context.Context, id int64, input *WarehouseUpdate) error {
+	var columns []string
+	var args []any
+	idx := 1
+
+	if input.Name != nil {
+		columns = append(columns, fmt.Sprintf("name = $%d", idx))
+		args = append(args, *input.Name)
+		idx++
+	}
+
+	if input.Priority != nil {
+		columns = append(columns, fmt.Sprintf("priority = $%d", idx))
+		args = append(args, *input.Priority)
+		idx++
+	}
+
+	if input.IsActive != nil {
+		columns = append(columns, fmt.Sprintf("is_active = $%d", idx))
+		args = append(args, *input.IsActive)
+		idx++
+	}
+
+	if len(columns) == 0 {
+		return errs.ErrNoFieldUpdate
+	}
+
+	setColumns := strings.Join(columns, ", ")
+	query := fmt.Sprintf("UPDATE warehouses SET %s, updated_at = NOW() WHERE id = $%d", setColumns, idx)
+	args = append(args, id)
+
+	return r.execAffected(ctx, errs.ErrWarehouseNotFound, query, args...)
+}
+
+// Delete removes a warehouse that holds no stock, its empty stock rows go
+// with it.
+func (r *warehouseRepository) Delete(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM warehouses
+		WHERE id = $1 AND NOT EXISTS (
+			SELECT 1 FROM warehouse_stock WHERE warehouse_id = $1 AND stock > 0
+		)
+	`
+	err := r.execAffected(ctx, errs.ErrWarehouseInUse, query, id)
+	if errors.Is(err, errs.ErrWarehouseInUse) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (r *warehouseRepository) ListStock(ctx context.Context, id int64, filter *StockFilter) ([]*WarehouseStock, error) {
+	query := `
+		SELECT ws.product_id, p.name, ws.variant_id, v.sku, ws.stock,
+			ws.stock - COALESCE((
+				SELECT SUM(sr.quantity) FROM stock_reservations sr
+				WHERE sr.variant_id = ws.variant_id AND sr.warehouse_id = ws.warehouse_id
+					AND sr.status = 'active' AND sr.expires_at > now()
+			), 0),
+			ws.updated_at
+		FROM warehouse_stock ws
+		JOIN products p ON p.id = ws.product_id
+		JOIN product_variants v ON v.id = ws.variant_id
+		WHERE ws.warehouse_id = $1
+		ORDER BY ws.product_id, ws.variant_id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, id, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stock []*WarehouseStock
+	for rows.Next() {
+		ws := new(WarehouseStock)
+		err = rows.Scan(
+			&ws.ProductID,
+			&ws.ProductName,
+			&ws.VariantID,
+			&ws.SKU,
+			&ws.Stock,
+			&ws.Available,
+			&ws.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stock = append(stock, ws)
+	}
+
+	return stock, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWarehouse(row rowScanner, w *Warehouse) error {
+	return row.Scan(
+		&w.ID,
+		&w.Code,
+		&w.Name,
+		&w.Priority,
+		&w.IsActive,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+}
+
+func (r *warehouseRepository) execAffected(ctx context.Context, notFound error, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
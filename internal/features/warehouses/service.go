@@ -0,0 +1,91 @@
+package warehouses
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+type IWarehouseService interface {
+	Create(ctx context.Context, req *WarehouseCreate) (*Warehouse, error)
+	GetByID(ctx context.Context, id int64) (*Warehouse, error)
+	List(ctx context.Context) ([]*Warehouse, error)
+	Update(ctx context.Context, id int64, req *WarehouseUpdate) error
+	Delete(ctx context.Context, id int64) error
+	ListStock(ctx context.Context, id int64, filter *StockFilter) ([]*WarehouseStock, error)
+}
+
+type warehouseService struct {
+	repo IWarehouseRepository
+}
+
+func NewWarehouseService(repo IWarehouseRepository) IWarehouseService {
+	return &warehouseService{repo: repo}
+}
+
+func (s *warehouseService) Create(ctx context.Context, req *WarehouseCreate) (*Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	w := &Warehouse{
+		Code:     strings.ToUpper(req.Code),
+		Name:     req.Name,
+		Priority: req.Priority,
+	}
+	if err := s.repo.Create(ctx, w); err != nil {
+		if strings.Contains(err.Error(), "warehouses_code_key") {
+			return nil, errs.ErrWarehouseCodeExists
+		}
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (s *warehouseService) GetByID(ctx context.Context, id int64) (*Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *warehouseService) List(ctx context.Context) ([]*Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.List(ctx)
+}
+
+func (s *warehouseService) Update(ctx context.Context, id int64, req *WarehouseUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *warehouseService) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *warehouseService) ListStock(ctx context.Context, id int64, filter *StockFilter) ([]*WarehouseStock, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.ListStock(ctx, id, filter)
+}
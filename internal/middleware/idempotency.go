@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/consts"
+	"github.com/codepnw/core-ecommerce-system/internal/utils/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once the
+// handler finished, the response that was sent for it.
+type IdempotencyRecord struct {
+	Key          string
+	Scope        string
+	Method       string
+	Path         string
+	RequestHash  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CompletedAt  *time.Time
+	CreatedAt    time.Time
+}
+
+// IdempotencyStore persists idempotency keys.
+type IdempotencyStore interface {
+	// Reserve stores rec as in progress. When the key is already taken it
+	// returns the stored record and false.
+	Reserve(ctx context.Context, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, rec *IdempotencyRecord) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// Idempotency replays the recorded response when a request is retried with the
+// same Idempotency-Key, so a double submit runs the handler only once. Reusing
+// a key with a different request, or while the first one is still running,
+// is rejected with 409. Requests without the header are not affected.
+//
+// Keys are scoped to the user, use it after Authorized on private routes.
+func (m *MiddlewareConfig) Idempotency(store IdempotencyStore) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(consts.HeaderIdempotencyKey)
+		if key == "" {
+			return ctx.Next()
+		}
+		if len(key) > consts.IdempotencyKeyMaxLen {
+			msg := fmt.Sprintf("%s must be at most %d characters", consts.HeaderIdempotencyKey, consts.IdempotencyKeyMaxLen)
+			return response.BadRequest(ctx, msg)
+		}
+
+		rec := &IdempotencyRecord{
+			Key:         key,
+			Scope:       idempotencyScope(ctx),
+			Method:      ctx.Method(),
+			Path:        ctx.Path(),
+			RequestHash: requestHash(ctx),
+		}
+
+		existing, reserved, err := store.Reserve(ctx.Context(), rec, consts.IdempotencyKeyTTL)
+		if err != nil {
+			return response.InternalServerError(ctx, err)
+		}
+
+		if !reserved {
+			switch {
+			case existing.RequestHash != rec.RequestHash:
+				return response.Conflict(ctx, "Idempotency-Key was already used with a different request")
+			case existing.CompletedAt == nil:
+				return response.Conflict(ctx, "a request with this Idempotency-Key is still in progress")
+			}
+
+			ctx.Set(consts.HeaderIdempotentReplay, "true")
+			if existing.ContentType != "" {
+				ctx.Set(fiber.HeaderContentType, existing.ContentType)
+			}
+			return ctx.Status(existing.StatusCode).Send(existing.ResponseBody)
+		}
+
+		// Server errors are not recorded, the client may retry with the same key
+		if err = ctx.Next(); err != nil || ctx.Response().StatusCode() >= http.StatusInternalServerError {
+			if relErr := store.Release(ctx.Context(), rec.Scope, rec.Key); relErr != nil {
+				return response.InternalServerError(ctx, relErr)
+			}
+			return err
+		}
+
+		rec.StatusCode = ctx.Response().StatusCode()
+		rec.ContentType = string(ctx.Response().Header.ContentType())
+		rec.ResponseBody = append([]byte(nil), ctx.Response().Body()...)
+
+		if err = store.Complete(ctx.Context(), rec); err != nil {
+			return response.InternalServerError(ctx, err)
+		}
+
+		return nil
+	}
+}
+
+// idempotencyScope is the user ID, or the client IP on public routes.
+func idempotencyScope(ctx *fiber.Ctx) string {
+	if user, err := GetUserFromContext(ctx); err == nil {
+		return "user:" + user.UserID
+	}
+	return "ip:" + ctx.IP()
+}
+
+func requestHash(ctx *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(ctx.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(ctx.Path()))
+	h.Write([]byte{0})
+	h.Write(ctx.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/attributes"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerAttributeRoutes() {
+	repo := attributes.NewAttributeRepository(cfg.DB)
+	handler := attributes.NewAttributeHandler(attributes.NewAttributeService(repo))
+
+	const attributeID = "/:attribute_id"
+	path := cfg.Prefix + "/attributes"
+
+	// Public, used to build filter sidebars
+	public := cfg.Router.Group(path)
+	public.Get("/", handler.List)
+	public.Get(attributeID, handler.GetByID)
+
+	// Admin & Staff
+	staff := cfg.Router.Group(
+		path,
+		cfg.Mid.Authorized(),
+		cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff),
+	)
+	staff.Post("/", handler.Create)
+	staff.Patch(attributeID, handler.Update)
+	staff.Delete(attributeID, handler.Delete)
+}
@@ -1,16 +1,40 @@
 package routes
 
-import "github.com/codepnw/core-ecommerce-system/internal/features/carts"
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/carts"
+	"github.com/codepnw/core-ecommerce-system/internal/features/shipping"
+)
 
-func (cfg *RoutesConfig) registerCartRoutes() {
-	repo := carts.NewCartRepository(cfg.DB)
-	service := carts.NewCartService(repo)
+func (cfg *RoutesConfig) registerCartRoutes() error {
+	service, err := cfg.newCartService()
+	if err != nil {
+		return err
+	}
 	handler := carts.NewCartHandler(service)
 
-	r := cfg.Router.Group(cfg.Prefix + "/cart", cfg.Mid.Authorized())
+	shippingService, err := cfg.newShippingService()
+	if err != nil {
+		return err
+	}
+	shippingHandler := shipping.NewShippingHandler(shippingService)
+
+	r := cfg.Router.Group(cfg.Prefix+"/cart", cfg.Mid.Authorized())
 
-	r.Post("/", handler.AddItem)
+	r.Post("/", cfg.idempotent(), handler.AddItem)
 	r.Get("/", handler.GetCart)
+	r.Get("/shipping-options", shippingHandler.CartShippingOptions)
 	r.Delete("/clear", handler.ClearCart)
 	r.Delete("/remove/:product_id", handler.RemoveItem)
+
+	return nil
+}
+
+func (cfg *RoutesConfig) newCartService() (carts.ICartService, error) {
+	pService, err := cfg.newProductService()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := carts.NewCartRepository(cfg.DB)
+	return carts.NewCartService(repo, pService, cfg.newExchangeRateService()), nil
 }
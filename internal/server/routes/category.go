@@ -7,18 +7,24 @@ import (
 
 func (cfg *RoutesConfig) registerCategoryRoutes() {
 	repo := categories.NewCategoryRepository(cfg.DB)
-	service := categories.NewCategoryService(repo)
+	service := categories.NewCategoryService(repo, cfg.Tx)
 	handler := categories.NewCategoryHandler(service)
 
 	const categoryID = "/:category_id"
 
+	// Public routes first, the staff group applies its middleware to the
+	// whole prefix once created
 	public := cfg.Router.Group(cfg.Prefix + "/categories")
-	staff := public.Group("", cfg.Mid.Authorized(), cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff))
-	
 	public.Get("/", handler.List)
+	public.Get("/tree", handler.Tree)
+	public.Get("/by-slug/:slug", handler.GetBySlug)
+	public.Get(categoryID+"/tree", handler.Subtree)
+
+	staff := public.Group("", cfg.Mid.Authorized(), cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff))
 
 	// Admin & Staff
 	staff.Post("/", handler.Create)
 	staff.Patch(categoryID, handler.Update)
 	staff.Delete(categoryID, handler.Delete)
+	staff.Patch(categoryID+"/move", handler.Move)
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/currencies"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerCurrencyRoutes() {
+	handler := currencies.NewExchangeRateHandler(cfg.newExchangeRateService())
+
+	// Admin & Staff
+	staff := cfg.Router.Group(
+		cfg.Prefix+"/exchange-rates",
+		cfg.Mid.Authorized(),
+		cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff),
+	)
+
+	staff.Get("/", handler.ListRates)
+	staff.Put("/", handler.UpsertRate)
+	staff.Delete("/:base/:quote", handler.DeleteRate)
+}
+
+func (cfg *RoutesConfig) newExchangeRateService() currencies.IExchangeRateService {
+	repo := currencies.NewExchangeRateRepository(cfg.DB)
+	return currencies.NewExchangeRateService(repo)
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"context"
+
+	"github.com/codepnw/core-ecommerce-system/internal/features/notifications"
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/features/products"
+)
+
+// StartJobs runs the background jobs until ctx is done.
+func StartJobs(ctx context.Context, cfg *RoutesConfig) error {
+	oService, err := cfg.newOrderService()
+	if err != nil {
+		return err
+	}
+
+	inv := cfg.Config.INVENTORY
+	go orders.RunReservationSweeper(ctx, oService, inv.SweepInterval, inv.ReservationTTL)
+
+	nService, err := cfg.newNotificationService()
+	if err != nil {
+		return err
+	}
+	go notifications.RunDispatcher(ctx, nService, cfg.Config.NOTIFY.DispatchInterval)
+
+	go products.RunQueryLog(ctx, cfg.searchTracker(), cfg.Config.SEARCH.QueryLogInterval)
+
+	return nil
+}
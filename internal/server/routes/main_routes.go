@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/codepnw/core-ecommerce-system/config"
 	"github.com/codepnw/core-ecommerce-system/internal/database"
+	"github.com/codepnw/core-ecommerce-system/internal/features/idempotency"
+	"github.com/codepnw/core-ecommerce-system/internal/features/products"
 	"github.com/codepnw/core-ecommerce-system/internal/middleware"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/security"
 	"github.com/codepnw/core-ecommerce-system/internal/utils/validate"
@@ -13,12 +16,17 @@ import (
 )
 
 type RoutesConfig struct {
+	Config *config.EnvConfig            `validate:"required"`
 	DB     *sql.DB                      `validate:"required"`
 	Tx     *database.TxManager          `validate:"required"`
 	Router *fiber.App                   `validate:"required"`
 	Prefix string                       `validate:"required"`
 	Mid    *middleware.MiddlewareConfig `validate:"required"`
 	Token  *security.JWTToken           `validate:"required"`
+
+	// tracker is shared so every product service logs to the same queue and
+	// reads the same suggestion cache
+	tracker *products.SearchTracker
 }
 
 func InitRoutes(cfg *RoutesConfig) error {
@@ -28,16 +36,45 @@ func InitRoutes(cfg *RoutesConfig) error {
 
 	cfg.registerCategoryRoutes()
 	cfg.registerAddressRoutes()
-	cfg.registerCartRoutes()
-	cfg.registerProductRoutes()
+
+	if err := cfg.registerCartRoutes(); err != nil {
+		return fmt.Errorf("CartRoutes: %w", err)
+	}
+
+	if err := cfg.registerProductRoutes(); err != nil {
+		return fmt.Errorf("ProductRoutes: %w", err)
+	}
+
+	cfg.registerPromotionRoutes()
+	cfg.registerCurrencyRoutes()
+	cfg.registerTaxRoutes()
+	cfg.registerWarehouseRoutes()
+	cfg.registerAttributeRoutes()
+
+	if err := cfg.registerShippingRoutes(); err != nil {
+		return fmt.Errorf("ShippingRoutes: %w", err)
+	}
 
 	if err := cfg.registerOrderRoutes(); err != nil {
 		return fmt.Errorf("OrderRoutes: %w", err)
 	}
 
+	if err := cfg.registerPaymentRoutes(); err != nil {
+		return fmt.Errorf("PaymentRoutes: %w", err)
+	}
+
+	if err := cfg.registerReturnRoutes(); err != nil {
+		return fmt.Errorf("ReturnRoutes: %w", err)
+	}
+
 	if err := cfg.registerUserRoutes(); err != nil {
 		return fmt.Errorf("UserRoutes: %w", err)
 	}
 
 	return nil
 }
+
+// idempotent replays the stored response of requests retried with the same Idempotency-Key.
+func (cfg *RoutesConfig) idempotent() fiber.Handler {
+	return cfg.Mid.Idempotency(idempotency.NewIdempotencyRepository(cfg.DB))
+}
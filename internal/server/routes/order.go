@@ -2,41 +2,94 @@ package routes
 
 import (
 	"github.com/codepnw/core-ecommerce-system/internal/features/addresses"
-	"github.com/codepnw/core-ecommerce-system/internal/features/carts"
+	"github.com/codepnw/core-ecommerce-system/internal/features/inventory"
 	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
-	"github.com/codepnw/core-ecommerce-system/internal/features/products"
+	"github.com/codepnw/core-ecommerce-system/internal/features/promotions"
+	"github.com/codepnw/core-ecommerce-system/internal/features/shipments"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
 )
 
 func (cfg *RoutesConfig) registerOrderRoutes() error {
-	pRepo := products.NewProductRepository(cfg.DB)
-	pSerivce := products.NewProductService(pRepo)
-
-	cRepo := carts.NewCartRepository(cfg.DB)
-	cService := carts.NewCartService(cRepo)
-
-	aRepo := addresses.NewAddressRepository(cfg.DB)
-	aService := addresses.NewAddressSerivce(aRepo)
+	oService, err := cfg.newOrderService()
+	if err != nil {
+		return err
+	}
+	handler := orders.NewOrderHandler(oService)
 
-	oRepo := orders.NewOrderRepository(cfg.DB)
-	oService, err := orders.NewOrderService(&orders.OrderServiceConfig{
-		OrderRepo: oRepo,
-		CartSrv:   cService,
-		ProdSrv:   pSerivce,
-		AddrSrv:   aService,
-		Tx:        cfg.Tx,
+	shipService, err := shipments.NewShipmentService(&shipments.ShipmentServiceConfig{
+		ShipmentRepo: shipments.NewShipmentRepository(cfg.DB),
+		OrderSrv:     oService,
+		Tx:           cfg.Tx,
 	})
 	if err != nil {
 		return err
 	}
-	handler := orders.NewOrderHandler(oService)
+	shipHandler := shipments.NewShipmentHandler(shipService)
+
+	const orderID = "/:order_id"
 
 	r := cfg.Router.Group(cfg.Prefix+"/orders", cfg.Mid.Authorized())
+	staffOnly := cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff)
 
-	r.Post("/", handler.CreateOrder)
+	r.Post("/", cfg.idempotent(), handler.CreateOrder)
 	r.Get("/", handler.ListOrders)
-	r.Get("/:order_id", handler.UpdateOrderStatus)
+	r.Get(orderID, handler.GetOrder)
+	r.Get(orderID+"/history", handler.GetStatusHistory)
+	r.Post(orderID+"/cancel", handler.CancelOrder)
+	r.Get(orderID+"/shipments", shipHandler.ListByOrder)
 
-	// TODO: admin get order, update status
+	// Admin & Staff
+	r.Patch(orderID+"/status", staffOnly, handler.UpdateOrderStatus)
+	r.Post(orderID+"/shipments", staffOnly, shipHandler.CreateShipment)
+	r.Post(orderID+"/shipments/:shipment_id/deliver", staffOnly, shipHandler.MarkDelivered)
 
 	return nil
 }
+
+func (cfg *RoutesConfig) newOrderService() (orders.IOrderService, error) {
+	rateService := cfg.newExchangeRateService()
+
+	pSerivce, err := cfg.newProductService()
+	if err != nil {
+		return nil, err
+	}
+
+	cService, err := cfg.newCartService()
+	if err != nil {
+		return nil, err
+	}
+
+	aRepo := addresses.NewAddressRepository(cfg.DB)
+	aService := addresses.NewAddressSerivce(aRepo)
+
+	promoRepo := promotions.NewPromotionRepository(cfg.DB)
+	promoService := promotions.NewPromotionService(promoRepo, cfg.Tx)
+
+	shipService, err := cfg.newShippingService()
+	if err != nil {
+		return nil, err
+	}
+
+	invService, err := inventory.NewInventoryService(&inventory.InventoryServiceConfig{
+		InventoryRepo:  inventory.NewInventoryRepository(cfg.DB),
+		ProdSrv:        pSerivce,
+		ReservationTTL: cfg.Config.INVENTORY.ReservationTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	oRepo := orders.NewOrderRepository(cfg.DB)
+	return orders.NewOrderService(&orders.OrderServiceConfig{
+		OrderRepo: oRepo,
+		CartSrv:   cService,
+		ProdSrv:   pSerivce,
+		AddrSrv:   aService,
+		PromoSrv:  promoService,
+		RateSrv:   rateService,
+		TaxSrv:    cfg.newTaxService(),
+		ShipSrv:   shipService,
+		InvSrv:    invService,
+		Tx:        cfg.Tx,
+	})
+}
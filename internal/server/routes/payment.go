@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/orders"
+	"github.com/codepnw/core-ecommerce-system/internal/features/payments"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerPaymentRoutes() error {
+	oService, err := cfg.newOrderService()
+	if err != nil {
+		return err
+	}
+
+	service, err := cfg.newPaymentService(oService)
+	if err != nil {
+		return err
+	}
+	handler := payments.NewPaymentHandler(service)
+
+	const paymentID = "/:payment_id"
+
+	// Webhooks are signed by the provider instead of a user token,
+	// registered before the authorized group so it is not guarded by it
+	cfg.Router.Post(cfg.Prefix+"/payments/webhooks/:provider", handler.HandleWebhook)
+
+	r := cfg.Router.Group(cfg.Prefix+"/payments", cfg.Mid.Authorized())
+	staffOnly := cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff)
+
+	r.Post("/", handler.CreatePayment)
+	r.Get("/orders/:order_id", handler.ListByOrder)
+	r.Post(paymentID+"/capture", handler.CapturePayment)
+
+	// Admin & Staff
+	r.Post(paymentID+"/refund", staffOnly, handler.RefundPayment)
+
+	return nil
+}
+
+func (cfg *RoutesConfig) newPaymentService(oService orders.IOrderService) (payments.IPaymentService, error) {
+	repo := payments.NewPaymentRepository(cfg.DB)
+	return payments.NewPaymentService(&payments.PaymentServiceConfig{
+		PaymentRepo: repo,
+		OrderSrv:    oService,
+		Providers:   payments.NewProviders(payments.NewFakeProvider()),
+		Tx:          cfg.Tx,
+		Webhook:     cfg.Config.PAYMENT,
+	})
+}
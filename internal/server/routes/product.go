@@ -3,19 +3,24 @@ package routes
 import (
 	"fmt"
 
+	"github.com/codepnw/core-ecommerce-system/internal/features/notifications"
 	"github.com/codepnw/core-ecommerce-system/internal/features/products"
 	"github.com/codepnw/core-ecommerce-system/internal/middleware"
 )
 
-func (cfg *RoutesConfig) registerProductRoutes() {
-	repo := products.NewProductRepository(cfg.DB)
-	service := products.NewProductService(repo)
+func (cfg *RoutesConfig) registerProductRoutes() error {
+	service, err := cfg.newProductService()
+	if err != nil {
+		return err
+	}
 	handler := products.NewProductHandler(service)
 
 	const (
 		productID         = "/:product_id"
 		categoryID        = "/:category_id"
 		productCategoryID = "/:product_id/categories"
+		productOptions    = "/:product_id/options"
+		productVariants   = "/:product_id/variants"
 	)
 	path := fmt.Sprintf("%s/products", cfg.Prefix)
 
@@ -24,6 +29,12 @@ func (cfg *RoutesConfig) registerProductRoutes() {
 	staff := protected.Group("", cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff))
 	admin := protected.Group("", cfg.Mid.RoleRequired(middleware.RoleAdmin))
 
+	// Static paths before /:product_id
+	staff.Get("/low-stock", handler.ListLowStock)
+	public.Get("/search", handler.SearchProducts)
+	public.Get("/suggest", handler.SuggestProducts)
+	public.Get("/by-slug/:slug", handler.GetProductBySlug)
+
 	// Public
 	public.Get("/", handler.GetProducts)
 	public.Get(productID, handler.GetProduct)
@@ -31,6 +42,21 @@ func (cfg *RoutesConfig) registerProductRoutes() {
 	// Admin & Staff
 	staff.Post("/", handler.CreateProduct)
 	staff.Patch(productID+"/stock", handler.UpdateStock)
+	staff.Get(productID+"/movements", handler.ListMovements)
+
+	// Attributes
+	staff.Put(productID+"/attributes", handler.SetAttributes)
+
+	// Options & Variants path /products/{product_id}/options|variants
+	staff.Post(productOptions, handler.CreateOption)
+	staff.Delete(productOptions+"/:option_id", handler.DeleteOption)
+	staff.Get(productVariants, handler.ListVariants)
+	staff.Post(productVariants, handler.CreateVariant)
+	staff.Patch(productVariants+"/:variant_id", handler.UpdateVariant)
+	staff.Delete(productVariants+"/:variant_id", handler.DeleteVariant)
+
+	// Customers
+	protected.Post(productID+"/notify-me", handler.NotifyMe)
 
 	// Admin Only
 	admin.Delete(productID, handler.DeleteProduct)
@@ -40,4 +66,37 @@ func (cfg *RoutesConfig) registerProductRoutes() {
 	admin.Delete(productCategoryID+categoryID, handler.DelCategoryByProduct)
 	admin.Get(productCategoryID, handler.GetCategoriesByProduct)
 	admin.Post(productCategoryID, handler.AssignCategories)
+
+	return nil
+}
+
+func (cfg *RoutesConfig) newProductService() (products.IProductService, error) {
+	notifyService, err := cfg.newNotificationService()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := products.NewProductRepository(cfg.DB)
+	return products.NewProductService(repo, cfg.newExchangeRateService(), notifyService, cfg.searchTracker(), cfg.Tx), nil
+}
+
+func (cfg *RoutesConfig) searchTracker() *products.SearchTracker {
+	if cfg.tracker == nil {
+		repo := products.NewProductRepository(cfg.DB)
+		cfg.tracker = products.NewSearchTracker(repo, cfg.Config.SEARCH.SuggestCacheTTL)
+	}
+	return cfg.tracker
+}
+
+func (cfg *RoutesConfig) newNotificationService() (notifications.INotificationService, error) {
+	sender, err := notifications.NewSender(cfg.Config.NOTIFY.Sender)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications.NewNotificationService(&notifications.NotificationServiceConfig{
+		NotificationRepo: notifications.NewNotificationRepository(cfg.DB),
+		Sender:           sender,
+		DB:               cfg.DB,
+	})
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/promotions"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerPromotionRoutes() {
+	repo := promotions.NewPromotionRepository(cfg.DB)
+	service := promotions.NewPromotionService(repo, cfg.Tx)
+	handler := promotions.NewPromotionHandler(service)
+
+	const couponID = "/:coupon_id"
+
+	// Admin & Staff
+	staff := cfg.Router.Group(
+		cfg.Prefix+"/promotions/coupons",
+		cfg.Mid.Authorized(),
+		cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff),
+	)
+
+	staff.Post("/", handler.CreateCoupon)
+	staff.Get("/", handler.ListCoupons)
+	staff.Get(couponID, handler.GetCoupon)
+	staff.Patch(couponID, handler.UpdateCoupon)
+	staff.Delete(couponID, handler.DeleteCoupon)
+}
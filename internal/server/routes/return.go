@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/returns"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerReturnRoutes() error {
+	oService, err := cfg.newOrderService()
+	if err != nil {
+		return err
+	}
+
+	payService, err := cfg.newPaymentService(oService)
+	if err != nil {
+		return err
+	}
+
+	pService, err := cfg.newProductService()
+	if err != nil {
+		return err
+	}
+
+	service, err := returns.NewReturnService(&returns.ReturnServiceConfig{
+		ReturnRepo: returns.NewReturnRepository(cfg.DB),
+		OrderSrv:   oService,
+		ProdSrv:    pService,
+		PaySrv:     payService,
+		Tx:         cfg.Tx,
+	})
+	if err != nil {
+		return err
+	}
+	handler := returns.NewReturnHandler(service)
+
+	const returnID = "/:return_id"
+
+	r := cfg.Router.Group(cfg.Prefix+"/returns", cfg.Mid.Authorized())
+	staffOnly := cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff)
+
+	r.Post("/", handler.CreateReturn)
+	r.Get("/orders/:order_id", handler.ListByOrder)
+
+	// Admin & Staff
+	r.Get("/", staffOnly, handler.ListReturns)
+	r.Get("/summary", staffOnly, handler.Summary)
+	r.Post(returnID+"/approve", staffOnly, handler.ApproveReturn)
+	r.Post(returnID+"/reject", staffOnly, handler.RejectReturn)
+	r.Post(returnID+"/receive", staffOnly, handler.ReceiveReturn)
+
+	r.Get(returnID, handler.GetReturn)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/addresses"
+	"github.com/codepnw/core-ecommerce-system/internal/features/shipping"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerShippingRoutes() error {
+	service, err := cfg.newShippingService()
+	if err != nil {
+		return err
+	}
+	handler := shipping.NewShippingHandler(service)
+
+	const (
+		zoneID   = "/zones/:zone_id"
+		methodID = "/methods/:method_id"
+	)
+
+	// Admin Only
+	admin := cfg.Router.Group(
+		cfg.Prefix+"/shipping",
+		cfg.Mid.Authorized(),
+		cfg.Mid.RoleRequired(middleware.RoleAdmin),
+	)
+
+	// Zones
+	admin.Post("/zones", handler.CreateZone)
+	admin.Get("/zones", handler.ListZones)
+	admin.Patch(zoneID, handler.UpdateZone)
+	admin.Delete(zoneID, handler.DeleteZone)
+
+	// Methods
+	admin.Post(zoneID+"/methods", handler.CreateMethod)
+	admin.Patch(methodID, handler.UpdateMethod)
+	admin.Delete(methodID, handler.DeleteMethod)
+
+	return nil
+}
+
+func (cfg *RoutesConfig) newShippingService() (shipping.IShippingService, error) {
+	rateService := cfg.newExchangeRateService()
+
+	cService, err := cfg.newCartService()
+	if err != nil {
+		return nil, err
+	}
+
+	aRepo := addresses.NewAddressRepository(cfg.DB)
+	aService := addresses.NewAddressSerivce(aRepo)
+
+	return shipping.NewShippingService(&shipping.ShippingServiceConfig{
+		ShippingRepo: shipping.NewShippingRepository(cfg.DB),
+		CartSrv:      cService,
+		AddrSrv:      aService,
+		RateSrv:      rateService,
+	})
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/tax"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerTaxRoutes() {
+	handler := tax.NewTaxHandler(cfg.newTaxService())
+
+	const (
+		classID    = "/classes/:class_id"
+		rateID     = "/rates/:rate_id"
+		categoryID = "/:category_id"
+	)
+
+	// Admin Only
+	admin := cfg.Router.Group(
+		cfg.Prefix+"/tax",
+		cfg.Mid.Authorized(),
+		cfg.Mid.RoleRequired(middleware.RoleAdmin),
+	)
+
+	// Tax classes
+	admin.Post("/classes", handler.CreateClass)
+	admin.Get("/classes", handler.ListClasses)
+	admin.Patch(classID, handler.UpdateClass)
+	admin.Delete(classID, handler.DeleteClass)
+	admin.Put(classID+"/categories", handler.AssignCategories)
+	admin.Delete(classID+"/categories"+categoryID, handler.UnassignCategory)
+
+	// Tax rates
+	admin.Post("/rates", handler.CreateRate)
+	admin.Get("/rates", handler.ListRates)
+	admin.Patch(rateID, handler.UpdateRate)
+	admin.Delete(rateID, handler.DeleteRate)
+}
+
+func (cfg *RoutesConfig) newTaxService() tax.ITaxService {
+	repo := tax.NewTaxRepository(cfg.DB)
+	return tax.NewTaxService(repo)
+}
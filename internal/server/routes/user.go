@@ -48,7 +48,7 @@ func (cfg *RoutesConfig) registerUserRoutes() error {
 
 	// Public Auth
 	public := cfg.Router.Group(authPath)
-	public.Post("/register", aHandler.Register)
+	public.Post("/register", cfg.idempotent(), aHandler.Register)
 	public.Post("/login", aHandler.Login)
 
 	// Private Auth
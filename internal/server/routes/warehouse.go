@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/codepnw/core-ecommerce-system/internal/features/warehouses"
+	"github.com/codepnw/core-ecommerce-system/internal/middleware"
+)
+
+func (cfg *RoutesConfig) registerWarehouseRoutes() {
+	repo := warehouses.NewWarehouseRepository(cfg.DB)
+	handler := warehouses.NewWarehouseHandler(warehouses.NewWarehouseService(repo))
+
+	const warehouseID = "/:warehouse_id"
+
+	// Admin & Staff
+	staff := cfg.Router.Group(
+		cfg.Prefix+"/warehouses",
+		cfg.Mid.Authorized(),
+		cfg.Mid.RoleRequired(middleware.RoleAdmin, middleware.RoleStaff),
+	)
+
+	staff.Post("/", handler.Create)
+	staff.Get("/", handler.List)
+	staff.Get(warehouseID, handler.GetByID)
+	staff.Patch(warehouseID, handler.Update)
+	staff.Delete(warehouseID, handler.Delete)
+	staff.Get(warehouseID+"/stock", handler.ListStock)
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -25,6 +26,7 @@ func Run(cfg *config.EnvConfig) error {
 
 	// Setup Routes
 	routeCfg := &routes.RoutesConfig{
+		Config: cfg,
 		DB:     db,
 		Tx:     database.NewTxManager(db),
 		Router: app,
@@ -36,6 +38,13 @@ func Run(cfg *config.EnvConfig) error {
 		return err
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	if err = routes.StartJobs(jobsCtx, routeCfg); err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("%s:%d%s", cfg.APP.Host, cfg.APP.Port, routeCfg.Prefix)
 	log.Printf("database %s connected...", cfg.DB.Name)
 	log.Printf("server running at %s", url)
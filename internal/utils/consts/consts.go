@@ -15,3 +15,11 @@ const (
 	ExpAccessToken  time.Duration = time.Hour * 24
 	ExpRefreshToken time.Duration = time.Hour * 24 * 7
 )
+
+// Idempotency
+const (
+	HeaderIdempotencyKey   = "Idempotency-Key"
+	HeaderIdempotentReplay = "Idempotent-Replayed"
+	IdempotencyKeyTTL      = time.Hour * 24
+	IdempotencyKeyMaxLen   = 255
+)
@@ -0,0 +1,208 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+// Cursor points at the first or last row of a page by its sort key and ID.
+// Clients only see it encoded, see Encode.
+type Cursor struct {
+	Listing string `json:"l"`
+	OrderBy string `json:"o"`
+	Sort    string `json:"s"`
+	Key     string `json:"k"`
+	ID      string `json:"i"`
+	Prev    bool   `json:"p,omitempty"`
+}
+
+// Encode returns the opaque token of the cursor.
+func Encode(c *Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode reads a token made by Encode.
+func Decode(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	c := new(Cursor)
+	if err = json.Unmarshal(data, c); err != nil || c.ID == "" {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// Page describes a page of a listing: the total of matching rows, where the
+// page starts (Offset, or Cursor when given) and the cursors around it, empty
+// when there is nothing there.
+type Page struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// OffsetPage describes a page of a listing without cursors, items are the
+// rows of a query limited to limit+1.
+func OffsetPage[T any](items []T, limit, offset int) ([]T, *Page) {
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	return items, &Page{Limit: limit, Offset: offset, HasMore: more}
+}
+
+// Key is the sort key and ID of a row, both as text.
+type Key struct {
+	Value string
+	ID    string
+}
+
+// Keyset orders a listing by Column then by ID, both in Sort order, and
+// continues after (or before) Cursor. Table is the alias of the listed rows.
+type Keyset struct {
+	Listing string
+	Table   string
+	Column  string
+	Sort    string
+	Cursor  *Cursor
+
+	token string
+}
+
+// Continue applies a cursor token, empty in offset mode. The listing keeps
+// the order the token was made for, so its column must be one of columns.
+func (k *Keyset) Continue(token string, columns map[string]bool) error {
+	if token == "" {
+		return nil
+	}
+
+	c, err := Decode(token)
+	if err != nil {
+		return err
+	}
+
+	if c.Listing != k.Listing || !columns[c.OrderBy] {
+		return errs.ErrInvalidCursor
+	}
+
+	k.Column = c.OrderBy
+	k.Sort = c.Sort
+	k.Cursor = c
+	k.token = token
+	return nil
+}
+
+// KeyColumns selects the sort key and ID as text, for scanning into a Key.
+func (k *Keyset) KeyColumns() string {
+	return fmt.Sprintf("%s::text, %s::text", k.column(k.Column), k.column("id"))
+}
+
+// Where returns the condition for the rows past the cursor, its placeholders
+// follow the given args. It is empty without a cursor.
+func (k *Keyset) Where(args []any) (string, []any) {
+	if k.Cursor == nil {
+		return "", args
+	}
+
+	op := ">"
+	if k.desc() != k.Cursor.Prev {
+		op = "<"
+	}
+
+	args = append(args, k.Cursor.Key, k.Cursor.ID)
+	col, id := k.column(k.Column), k.column("id")
+	key, idArg := len(args)-1, len(args)
+
+	return fmt.Sprintf("(%s %s $%d OR (%s = $%d AND %s %s $%d))",
+		col, op, key, col, key, id, op, idArg), args
+}
+
+// OrderBy returns the ORDER BY list, reversed when paging backwards so the
+// rows closest to the cursor come first.
+func (k *Keyset) OrderBy() string {
+	sort := "ASC"
+	if k.desc() != (k.Cursor != nil && k.Cursor.Prev) {
+		sort = "DESC"
+	}
+
+	if k.Column == "id" {
+		return fmt.Sprintf("%s %s", k.column("id"), sort)
+	}
+	return fmt.Sprintf("%s %s, %s %s", k.column(k.Column), sort, k.column("id"), sort)
+}
+
+func (k *Keyset) desc() bool {
+	return strings.EqualFold(k.Sort, "desc")
+}
+
+func (k *Keyset) column(name string) string {
+	if k.Table == "" {
+		return name
+	}
+	return k.Table + "." + name
+}
+
+func (k *Keyset) cursor(key Key, prev bool) string {
+	return Encode(&Cursor{
+		Listing: k.Listing,
+		OrderBy: k.Column,
+		Sort:    k.Sort,
+		Key:     key.Value,
+		ID:      key.ID,
+		Prev:    prev,
+	})
+}
+
+// Paginate takes the rows of a query limited to limit+1, with the key of
+// every row, and returns the page with its cursors. The extra row only tells
+// there is more, a backward page is put back in listing order. offset is the
+// offset mode position, ignored with a cursor. Total is left to the caller.
+func Paginate[T any](k *Keyset, limit, offset int, items []T, keys []Key) ([]T, *Page) {
+	more := len(items) > limit
+	if more {
+		items, keys = items[:limit], keys[:limit]
+	}
+
+	back := k.Cursor != nil && k.Cursor.Prev
+	if back {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	page := &Page{Limit: limit, Offset: offset, Cursor: k.token}
+	if len(items) == 0 {
+		return items, page
+	}
+
+	// A backward page always has the page it came from after it, a forward
+	// page has rows before it when it continues a cursor or skips an offset
+	hasNext, hasPrev := more, k.Cursor != nil || offset > 0
+	if back {
+		hasNext, hasPrev = true, more
+	}
+
+	page.HasMore = hasNext
+	if hasNext {
+		page.NextCursor = k.cursor(keys[len(keys)-1], false)
+	}
+	if hasPrev {
+		page.PrevCursor = k.cursor(keys[0], true)
+	}
+
+	return items, page
+}
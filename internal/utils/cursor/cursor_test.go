@@ -0,0 +1,100 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "forward", cursor: Cursor{Listing: "products", OrderBy: "price", Sort: "asc", Key: "19.99", ID: "42"}},
+		{name: "backward", cursor: Cursor{Listing: "orders", OrderBy: "created_at", Sort: "desc", Key: "2026-01-02 03:04:05+00", ID: "7", Prev: true}},
+		{name: "key with symbols", cursor: Cursor{Listing: "products", OrderBy: "name", Key: `ข้าว "ผัด" / 1`, ID: "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(Encode(&tt.cursor))
+			if err != nil {
+				t.Fatalf("Decode error = %v", err)
+			}
+			if *got != tt.cursor {
+				t.Errorf("Decode = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := Encode(&Cursor{Listing: "products", OrderBy: "id", Key: "1", ID: "1"})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "!!!"},
+		{name: "not json", token: base64.RawURLEncoding.EncodeToString([]byte("garbage"))},
+		{name: "wrong types", token: base64.RawURLEncoding.EncodeToString([]byte(`{"i":1}`))},
+		{name: "missing id", token: base64.RawURLEncoding.EncodeToString([]byte(`{"l":"products","k":"1"}`))},
+		{name: "truncated", token: valid[:len(valid)-4]},
+		{name: "tampered", token: "x" + valid[1:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.token); !errors.Is(err, errs.ErrInvalidCursor) {
+				t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", tt.token, err)
+			}
+		})
+	}
+}
+
+func TestKeysetContinue(t *testing.T) {
+	columns := map[string]bool{"id": true, "price": true}
+
+	tests := []struct {
+		name    string
+		cursor  *Cursor
+		token   string
+		wantErr bool
+	}{
+		{name: "empty token", token: ""},
+		{name: "same listing", cursor: &Cursor{Listing: "products", OrderBy: "price", Sort: "desc", Key: "10.00", ID: "3"}},
+		{name: "other listing", cursor: &Cursor{Listing: "orders", OrderBy: "price", Key: "10.00", ID: "3"}, wantErr: true},
+		{name: "unknown column", cursor: &Cursor{Listing: "products", OrderBy: "cost", Key: "10.00", ID: "3"}, wantErr: true},
+		{name: "garbage", token: "not-a-cursor", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token
+			if tt.cursor != nil {
+				token = Encode(tt.cursor)
+			}
+
+			k := &Keyset{Listing: "products", Column: "id", Sort: "asc"}
+			err := k.Continue(token, columns)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrInvalidCursor) {
+					t.Fatalf("Continue error = %v, want ErrInvalidCursor", err)
+				}
+				if k.Cursor != nil || k.Column != "id" {
+					t.Errorf("Continue changed the keyset on error: %+v", k)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Continue error = %v", err)
+			}
+			if tt.cursor != nil && (k.Column != tt.cursor.OrderBy || k.Sort != tt.cursor.Sort || *k.Cursor != *tt.cursor) {
+				t.Errorf("Continue = %+v, want the order of %+v", k, tt.cursor)
+			}
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package errs
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Categories
 var (
-	ErrCategoryNotFound = errors.New("category not found")
-	ErrNoFieldUpdate    = errors.New("no fields to update")
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrNoFieldUpdate          = errors.New("no fields to update")
+	ErrCategoryParentNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot move into its own subtree")
+	ErrCategoryHasChildren    = errors.New("category has child categories")
 )
 
 // Products
@@ -37,5 +43,145 @@ var (
 
 // Orders
 var (
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderForbidden     = errors.New("no permissions for this order")
+	ErrOrderCannotCancel  = errors.New("order cannot be cancelled")
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+)
+
+// Payments
+var (
+	ErrPaymentNotFound         = errors.New("payment not found")
+	ErrPaymentProviderNotFound = errors.New("payment provider not found")
+	ErrPaymentNotPending       = errors.New("payment is not pending")
+	ErrPaymentNotCaptured      = errors.New("payment is not captured")
+	ErrPaymentDeclined         = errors.New("payment declined")
+	ErrOrderNotPayable         = errors.New("order is not awaiting payment")
+	ErrWebhookInvalidSignature = errors.New("invalid webhook signature")
+	ErrWebhookInvalidTimestamp = errors.New("invalid or expired webhook timestamp")
+	ErrWebhookInvalidPayload   = errors.New("invalid webhook payload")
+	ErrWebhookDuplicate        = errors.New("webhook event already processed")
+	ErrRefundExceedsPayment    = errors.New("refund exceeds the captured amount")
+)
+
+// Promotions
+var (
+	ErrCouponNotFound        = errors.New("coupon not found")
+	ErrCouponCodeExists      = errors.New("coupon code already exists")
+	ErrCouponInvalidDiscount = errors.New("percentage discount must not exceed 100")
+	ErrCouponDiscountValue   = errors.New("percentage coupons take discount_percent, fixed coupons discount_amount")
+	ErrCouponCurrency        = errors.New("coupon amounts must be in the coupon currency")
+	ErrCouponInactive        = errors.New("coupon is not active or expired")
+	ErrCouponUsageLimit      = errors.New("coupon usage limit reached")
+	ErrCouponMinOrderValue   = errors.New("order total is below the coupon minimum")
+	ErrCouponNotApplicable   = errors.New("coupon does not apply to any cart items")
+)
+
+// Currencies
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrSameCurrency         = errors.New("base and quote currency must be different")
+)
+
+// Tax
+var (
+	ErrTaxClassNotFound = errors.New("tax class not found")
+	ErrTaxClassExists   = errors.New("tax class already exists")
+	ErrTaxRateNotFound  = errors.New("tax rate not found")
+)
+
+// Shipping
+var (
+	ErrShippingZoneNotFound      = errors.New("shipping zone not found")
+	ErrShippingMethodNotFound    = errors.New("shipping method not found")
+	ErrShippingMethodUnavailable = errors.New("shipping method is not available for this address")
+)
+
+// Inventory
+var (
+	ErrReservationNotFound = errors.New("stock reservation not found")
+	ErrReservationExpired  = errors.New("stock reservation expired")
+)
+
+// Shipments
+var (
+	ErrShipmentNotFound         = errors.New("shipment not found")
+	ErrShipmentDelivered        = errors.New("shipment already delivered")
+	ErrShipmentItemInvalid      = errors.New("order item does not belong to this order")
+	ErrShipmentQuantityExceeded = errors.New("shipment quantity exceeds unshipped quantity")
+	ErrOrderNotShippable        = errors.New("order is not ready to ship")
+)
+
+// Returns
+var (
+	ErrReturnNotFound         = errors.New("return not found")
+	ErrReturnForbidden        = errors.New("no permissions for this return")
+	ErrOrderNotReturnable     = errors.New("only completed orders can be returned")
+	ErrReturnItemInvalid      = errors.New("order item does not belong to this order")
+	ErrReturnQuantityExceeded = errors.New("return quantity exceeds returnable quantity")
+	ErrReturnNotRequested     = errors.New("return is not awaiting review")
+	ErrReturnNotApproved      = errors.New("return is not approved")
+)
+
+// Warehouses
+var (
+	ErrWarehouseNotFound   = errors.New("warehouse not found")
+	ErrWarehouseCodeExists = errors.New("warehouse code already exists")
+	ErrWarehouseInUse      = errors.New("warehouse still holds stock")
+)
+
+// Notifications
+var (
+	ErrNotificationNotFound    = errors.New("notification not found")
+	ErrStockSubscriptionExists = errors.New("already subscribed to this product")
+	ErrProductInStock          = errors.New("product is in stock")
+)
+
+// Variants
+var (
+	ErrVariantNotFound       = errors.New("product variant not found")
+	ErrVariantRequired       = errors.New("product has several variants, variant_id is required")
+	ErrVariantSKUExists      = errors.New("sku already exists")
+	ErrVariantOptionsInvalid = errors.New("variant needs exactly one value for every product option")
+	ErrVariantExists         = errors.New("a variant with these options already exists")
+	ErrVariantInUse          = errors.New("variant still has stock or orders")
+	ErrVariantCurrency       = errors.New("variant price must be in the product currency")
+	ErrOptionNotFound        = errors.New("product option not found")
+	ErrOptionExists          = errors.New("product option already exists")
+	ErrOptionInUse           = errors.New("product option is used by variants")
+)
+
+// Attributes
+var (
+	ErrAttributeNotFound     = errors.New("attribute not found")
+	ErrAttributeCodeExists   = errors.New("attribute code already exists")
+	ErrAttributeCodeInvalid  = errors.New("attribute code cannot contain '.', ',', '=', '&' or spaces")
+	ErrAttributeValueInvalid = errors.New("attribute value does not match the attribute type")
+	ErrInvalidPriceFilter    = errors.New("price_min and price_max must be numbers")
+)
+
+// Search
+var (
+	ErrSearchQueryRequired = errors.New("search query q is required")
+)
+
+// Pagination
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Slugs
+var (
+	ErrSlugExists  = errors.New("slug already in use")
+	ErrSlugInvalid = errors.New("slug must contain letters or digits")
 )
+
+// InvalidTransitionError reports an order status change the state machine does not allow.
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
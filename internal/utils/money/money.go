@@ -0,0 +1,286 @@
+// Package money stores monetary amounts as integer minor units (satang, cents)
+// together with an ISO 4217 currency code. Amounts are never held as floats;
+// whenever a fractional minor unit appears (percentages, conversions, extra
+// decimal places) it is rounded half away from zero.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "THB"
+
+// currencyExponents lists currencies whose minor unit is not 1/100.
+var currencyExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+type Money struct {
+	Amount   int64  // minor units
+	Currency string // ISO 4217, empty means DefaultCurrency
+}
+
+// Exponent returns the number of decimal places of the currency minor unit.
+func Exponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: normalize(currency)}
+}
+
+// FromMajor converts a major unit value (e.g. 19.99) to Money.
+func FromMajor(value float64, currency string) Money {
+	currency = normalize(currency)
+	scale := math.Pow10(Exponent(currency))
+	return Money{Amount: int64(math.Round(value * scale)), Currency: currency}
+}
+
+// Parse reads a decimal string such as "19.99" or "-5" without going through
+// float64. Digits beyond the currency exponent are rounded.
+func Parse(s, currency string) (Money, error) {
+	currency = normalize(currency)
+	exp := Exponent(currency)
+
+	s = strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	roundUp := false
+	if len(fracPart) > exp {
+		roundUp = fracPart[exp] >= '5'
+		fracPart = fracPart[:exp]
+	}
+	fracPart += strings.Repeat("0", exp-len(fracPart))
+
+	amount, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if roundUp {
+		amount++
+	}
+	if neg {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func (m Money) CurrencyCode() string {
+	return normalize(m.Currency)
+}
+
+// Add returns m + o. Both values are expected to be in the same currency.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: m.pick(o)}
+}
+
+// Sub returns m - o. Both values are expected to be in the same currency.
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: m.pick(o)}
+}
+
+func (m Money) Mul(qty int64) Money {
+	return Money{Amount: m.Amount * qty, Currency: m.CurrencyCode()}
+}
+
+// Percent returns p percent of m, rounded to the nearest minor unit.
+func (m Money) Percent(p float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * p / 100)), Currency: m.CurrencyCode()}
+}
+
+// Convert returns m in another currency using rate (units of `to` per one
+// unit of m's currency), rounded to the nearest minor unit of `to`.
+func (m Money) Convert(to string, rate float64) Money {
+	to = normalize(to)
+	scale := math.Pow10(Exponent(to) - Exponent(m.CurrencyCode()))
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate * scale)), Currency: to}
+}
+
+// WithCurrency relabels m with currency, adjusting the minor units when the
+// currencies use a different number of decimals. No exchange rate is applied.
+func (m Money) WithCurrency(currency string) Money {
+	return m.Convert(currency, 1)
+}
+
+func (m Money) Min(o Money) Money {
+	if o.Amount < m.Amount {
+		return o
+	}
+	return m
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// Major returns the amount in major units. Use it for display or for third
+// parties that require floats, never for arithmetic.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(Exponent(m.CurrencyCode()))
+}
+
+// String formats the amount as a plain decimal, e.g. "19.99".
+func (m Money) String() string {
+	exp := Exponent(m.CurrencyCode())
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	cut := len(digits) - exp
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+// Scan implements sql.Scanner for NUMERIC columns. The currency already set on
+// m is kept, otherwise DefaultCurrency is used.
+func (m *Money) Scan(src any) error {
+	currency := m.CurrencyCode()
+
+	var (
+		v   Money
+		err error
+	)
+	switch val := src.(type) {
+	case nil:
+		v = Money{Currency: currency}
+	case []byte:
+		v, err = Parse(string(val), currency)
+	case string:
+		v, err = Parse(val, currency)
+	case int64:
+		v = Money{Amount: val * int64(math.Pow10(Exponent(currency))), Currency: currency}
+	case float64:
+		v = FromMajor(val, currency)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	if err != nil {
+		return err
+	}
+
+	*m = v
+	return nil
+}
+
+// Value implements driver.Valuer and stores the amount as a decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Display  string `json:"display"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:   m.Amount,
+		Currency: m.CurrencyCode(),
+		Display:  m.String(),
+	})
+}
+
+// UnmarshalJSON accepts a major unit number or string ("19.99") in the
+// currency already set on m, or an object {"amount": 1999, "currency": "THB"}
+// in minor units.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	switch data[0] {
+	case '{':
+		var v struct {
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if v.Currency == "" {
+			v.Currency = m.Currency
+		}
+		*m = New(v.Amount, v.Currency)
+		return nil
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+
+	v, err := Parse(string(data), m.CurrencyCode())
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func (m Money) pick(o Money) string {
+	if m.Currency == "" {
+		return o.CurrencyCode()
+	}
+	return m.CurrencyCode()
+}
+
+func normalize(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
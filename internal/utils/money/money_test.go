@@ -0,0 +1,187 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "whole", input: "19", currency: "THB", want: Money{1900, "THB"}},
+		{name: "decimals", input: "19.99", currency: "thb", want: Money{1999, "THB"}},
+		{name: "default currency", input: "5.5", want: Money{550, "THB"}},
+		{name: "round half up", input: "0.125", currency: "USD", want: Money{13, "USD"}},
+		{name: "round down", input: "0.124", currency: "USD", want: Money{12, "USD"}},
+		{name: "negative rounds away from zero", input: "-0.125", currency: "USD", want: Money{-13, "USD"}},
+		{name: "no integer part", input: ".5", currency: "THB", want: Money{50, "THB"}},
+		{name: "zero decimal currency", input: "1500.6", currency: "JPY", want: Money{1501, "JPY"}},
+		{name: "plus sign and spaces", input: " +3 ", currency: "THB", want: Money{300, "THB"}},
+		{name: "empty", input: "", currency: "THB", wantErr: true},
+		{name: "letters", input: "12a", currency: "THB", wantErr: true},
+		{name: "two dots", input: "1.2.3", currency: "THB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, tt.currency)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInvalidAmount", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Money
+		percent float64
+		want    int64
+	}{
+		{name: "exact", amount: New(10000, "THB"), percent: 10, want: 1000},
+		{name: "half rounds up", amount: New(5, "THB"), percent: 50, want: 3},
+		{name: "below half rounds down", amount: New(333, "THB"), percent: 10, want: 33},
+		{name: "fractional percent", amount: New(1999, "THB"), percent: 7, want: 140},
+		{name: "negative rounds away from zero", amount: New(-5, "THB"), percent: 50, want: -3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.amount.Percent(tt.percent)
+			if got.Amount != tt.want || got.Currency != tt.amount.Currency {
+				t.Errorf("Percent(%v) = %+v, want %d %s", tt.percent, got, tt.want, tt.amount.Currency)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		from Money
+		to   string
+		rate float64
+		want Money
+	}{
+		{name: "same exponent", from: New(10000, "USD"), to: "THB", rate: 35.5, want: Money{355000, "THB"}},
+		{name: "rounds to minor unit", from: New(100, "THB"), to: "USD", rate: 0.028169, want: Money{3, "USD"}},
+		{name: "into zero decimal currency", from: New(1000, "USD"), to: "jpy", rate: 150.25, want: Money{1503, "JPY"}},
+		{name: "from zero decimal currency", from: New(1000, "JPY"), to: "USD", rate: 0.0066, want: Money{660, "USD"}},
+		{name: "relabel", from: New(1999, "THB"), to: "USD", rate: 1, want: Money{1999, "USD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.Convert(tt.to, tt.rate); got != tt.want {
+				t.Errorf("Convert(%s, %v) = %+v, want %+v", tt.to, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCurrency(t *testing.T) {
+	tests := []struct {
+		name string
+		from Money
+		to   string
+		want Money
+	}{
+		{name: "same exponent", from: New(1999, "THB"), to: "USD", want: Money{1999, "USD"}},
+		{name: "to zero decimals", from: New(1950, "THB"), to: "JPY", want: Money{20, "JPY"}},
+		{name: "from zero decimals", from: New(20, "JPY"), to: "THB", want: Money{2000, "THB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.WithCurrency(tt.to); got != tt.want {
+				t.Errorf("WithCurrency(%s) = %+v, want %+v", tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{amount: New(1999, "THB"), want: "19.99"},
+		{amount: New(5, "THB"), want: "0.05"},
+		{amount: New(0, "THB"), want: "0.00"},
+		{amount: New(-150, "USD"), want: "-1.50"},
+		{amount: New(1500, "JPY"), want: "1500"},
+		{amount: Money{Amount: 100}, want: "1.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.amount.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		input    string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "number", currency: "THB", input: `19.99`, want: Money{1999, "THB"}},
+		{name: "string", currency: "USD", input: `"5.5"`, want: Money{550, "USD"}},
+		{name: "object in minor units", input: `{"amount": 1999, "currency": "usd"}`, want: Money{1999, "USD"}},
+		{name: "object keeps set currency", currency: "JPY", input: `{"amount": 1500}`, want: Money{1500, "JPY"}},
+		{name: "null leaves it unset", currency: "THB", input: `null`, want: Money{0, "THB"}},
+		{name: "invalid", currency: "THB", input: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Money{Currency: tt.currency}
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %+v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Money{Amount: 1999})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"amount":1999,"currency":"THB","display":"19.99"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+}
@@ -1,8 +1,13 @@
 package response
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/codepnw/core-ecommerce-system/internal/utils/cursor"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,6 +27,57 @@ func Success(ctx *fiber.Ctx, msg string, data any) error {
 	})
 }
 
+// Paginated returns a page of a list with its meta, and Link headers
+// (RFC 5988) to the next and previous pages.
+func Paginated(ctx *fiber.Ctx, msg string, data any, meta *cursor.Page) error {
+	if links := pageLinks(ctx, meta); links != "" {
+		ctx.Set(fiber.HeaderLink, links)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(&fiber.Map{
+		"message": msg,
+		"data":    data,
+		"meta":    meta,
+	})
+}
+
+// pageLinks keeps the query of the request and only moves its cursor, or its
+// offset when the list has no cursors.
+func pageLinks(ctx *fiber.Ctx, meta *cursor.Page) string {
+	query, err := url.ParseQuery(string(ctx.Request().URI().QueryString()))
+	if err != nil {
+		return ""
+	}
+
+	link := func(rel, key, value string) string {
+		q := url.Values{}
+		for k, v := range query {
+			if k != "cursor" && k != "offset" {
+				q[k] = v
+			}
+		}
+		q.Set(key, value)
+		return fmt.Sprintf(`<%s%s?%s>; rel="%s"`, ctx.BaseURL(), ctx.Path(), q.Encode(), rel)
+	}
+
+	var links []string
+	switch {
+	case meta.NextCursor != "":
+		links = append(links, link("next", "cursor", meta.NextCursor))
+	case meta.HasMore:
+		links = append(links, link("next", "offset", strconv.Itoa(meta.Offset+meta.Limit)))
+	}
+
+	switch {
+	case meta.PrevCursor != "":
+		links = append(links, link("prev", "cursor", meta.PrevCursor))
+	case meta.Cursor == "" && meta.Offset > 0:
+		links = append(links, link("prev", "offset", strconv.Itoa(max(meta.Offset-meta.Limit, 0))))
+	}
+
+	return strings.Join(links, ", ")
+}
+
 func NoContent(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusNoContent).JSON(nil)
 }
@@ -47,3 +103,7 @@ func Unauthorized(ctx *fiber.Ctx, msg string) error {
 func Forbidden(ctx *fiber.Ctx, msg string) error {
 	return ctx.Status(http.StatusForbidden).JSON(&fiber.Map{"message": msg})
 }
+
+func Conflict(ctx *fiber.Ctx, msg string) error {
+	return ctx.Status(http.StatusConflict).JSON(&fiber.Map{"message": msg})
+}
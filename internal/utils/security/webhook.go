@@ -0,0 +1,40 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<payload>".
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature and rejects timestamps older than tolerance.
+func VerifyWebhook(secret, timestamp, signature string, payload []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errs.ErrWebhookInvalidTimestamp
+	}
+
+	age := time.Since(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return errs.ErrWebhookInvalidTimestamp
+	}
+
+	expected := SignWebhook(secret, timestamp, payload)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errs.ErrWebhookInvalidSignature
+	}
+
+	return nil
+}
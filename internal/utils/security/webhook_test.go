@@ -0,0 +1,120 @@
+package security
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+func TestSignWebhook(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	sig := SignWebhook("secret", "1700000000", payload)
+
+	if len(sig) != 64 {
+		t.Fatalf("signature length = %d, want 64 hex characters", len(sig))
+	}
+	if sig != SignWebhook("secret", "1700000000", payload) {
+		t.Error("signature is not deterministic")
+	}
+
+	others := map[string]string{
+		"secret":    SignWebhook("other", "1700000000", payload),
+		"timestamp": SignWebhook("secret", "1700000001", payload),
+		"payload":   SignWebhook("secret", "1700000000", []byte(`{"id":"evt_2"}`)),
+	}
+	for changed, other := range others {
+		if other == sig {
+			t.Errorf("signature does not change with the %s", changed)
+		}
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	const (
+		secret    = "whsec_test"
+		tolerance = 5 * time.Minute
+	)
+	payload := []byte(`{"id":"evt_1","type":"payment.captured"}`)
+	at := func(d time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(d).Unix(), 10)
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature func(ts string) string
+		payload   []byte
+		wantErr   error
+	}{
+		{
+			name:      "valid",
+			timestamp: at(0),
+			signature: func(ts string) string { return SignWebhook(secret, ts, payload) },
+		},
+		{
+			name:      "valid near the tolerance",
+			timestamp: at(-tolerance + 5*time.Second),
+			signature: func(ts string) string { return SignWebhook(secret, ts, payload) },
+		},
+		{
+			name:      "wrong secret",
+			timestamp: at(0),
+			signature: func(ts string) string { return SignWebhook("other", ts, payload) },
+			wantErr:   errs.ErrWebhookInvalidSignature,
+		},
+		{
+			name:      "tampered payload",
+			timestamp: at(0),
+			signature: func(ts string) string { return SignWebhook(secret, ts, payload) },
+			payload:   []byte(`{"id":"evt_1","type":"payment.refunded"}`),
+			wantErr:   errs.ErrWebhookInvalidSignature,
+		},
+		{
+			name:      "signature of another timestamp",
+			timestamp: at(0),
+			signature: func(string) string { return SignWebhook(secret, at(-time.Minute), payload) },
+			wantErr:   errs.ErrWebhookInvalidSignature,
+		},
+		{
+			name:      "empty signature",
+			timestamp: at(0),
+			signature: func(string) string { return "" },
+			wantErr:   errs.ErrWebhookInvalidSignature,
+		},
+		{
+			name:      "too old",
+			timestamp: at(-tolerance - time.Minute),
+			signature: func(ts string) string { return SignWebhook(secret, ts, payload) },
+			wantErr:   errs.ErrWebhookInvalidTimestamp,
+		},
+		{
+			name:      "too far in the future",
+			timestamp: at(tolerance + time.Minute),
+			signature: func(ts string) string { return SignWebhook(secret, ts, payload) },
+			wantErr:   errs.ErrWebhookInvalidTimestamp,
+		},
+		{
+			name:      "not a number",
+			timestamp: "yesterday",
+			signature: func(ts string) string { return SignWebhook(secret, ts, payload) },
+			wantErr:   errs.ErrWebhookInvalidTimestamp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := payload
+			if tt.payload != nil {
+				body = tt.payload
+			}
+
+			err := VerifyWebhook(secret, tt.timestamp, tt.signature(tt.timestamp), body, tolerance)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWebhook error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
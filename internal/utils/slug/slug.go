@@ -0,0 +1,93 @@
+package slug
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+// MaxLength is the longest slug Make returns, suffixes from Unique may add a
+// few characters.
+const MaxLength = 80
+
+// maxSuffix bounds the suffixes Unique tries before giving up.
+const maxSuffix = 100
+
+// Make turns text into a lower-case slug of ASCII letters, digits and single
+// dashes. Accented Latin letters lose their accents and Thai is romanized,
+// anything else separates words. The result is empty when nothing is left.
+func Make(text string) string {
+	var sb strings.Builder
+	runes := []rune(strings.ToLower(text))
+
+	dash := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "-") {
+			sb.WriteByte('-')
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			sb.WriteRune(r)
+		case latin[r] != "":
+			sb.WriteString(latin[r])
+		case isThai(r):
+			j := i
+			for j < len(runes) && isThai(runes[j]) {
+				j++
+			}
+			sb.WriteString(romanizeThai(runes[i:j]))
+			i = j - 1
+		default:
+			dash()
+		}
+	}
+
+	s := strings.Trim(sb.String(), "-")
+	if len(s) > MaxLength {
+		s = strings.TrimRight(s[:MaxLength], "-")
+	}
+	return s
+}
+
+// Unique returns base when it is free, otherwise base with the first free
+// numeric suffix (base-2, base-3, ...).
+func Unique(ctx context.Context, base string, taken func(ctx context.Context, slug string) (bool, error)) (string, error) {
+	for n := 1; n <= maxSuffix; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		used, err := taken(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return candidate, nil
+		}
+	}
+
+	return "", errs.ErrSlugExists
+}
+
+// latin folds accented Latin letters to ASCII.
+var latin = func() map[rune]string {
+	m := make(map[rune]string)
+	for ascii, letters := range map[string]string{
+		"a": "àáâãäåāăą", "c": "çćč", "d": "ďđ", "e": "èéêëēėęě",
+		"g": "ğ", "i": "ìíîïīį", "l": "ł", "n": "ñńň", "o": "òóôõöøō",
+		"r": "ř", "s": "śšş", "t": "ť", "u": "ùúûüūůű", "y": "ýÿ", "z": "źżž",
+		"ss": "ß", "ae": "æ", "oe": "œ",
+	} {
+		for _, r := range letters {
+			m[r] = ascii
+		}
+	}
+	return m
+}()
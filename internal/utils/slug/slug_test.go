@@ -0,0 +1,103 @@
+package slug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/errs"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Red Shoes", want: "red-shoes"},
+		{input: "  iPhone 15 Pro  ", want: "iphone-15-pro"},
+		{input: "Crème Brûlée -- Straße!", want: "creme-brulee-strasse"},
+		{input: "เสื้อผ้า", want: "sueapha"},
+		{input: "อาหารเช้า", want: "ahanchao"},
+		{input: "ข้าวผัด", want: "khaophat"},
+		{input: "ไทย", want: "thai"},
+		{input: "คน", want: "khon"},
+		{input: "จันทร์", want: "chan"},
+		{input: "ข้าวผัด Thai Style", want: "khaophat-thai-style"},
+		{input: "", want: ""},
+		{input: "!!! --- ???", want: ""},
+		{input: "日本語", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Make(tt.input); got != tt.want {
+				t.Errorf("Make(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeTruncates(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "long word", input: strings.Repeat("a", 100), want: strings.Repeat("a", MaxLength)},
+		{name: "no trailing dash", input: strings.Repeat("a", MaxLength-1) + " bcd", want: strings.Repeat("a", MaxLength-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Make(tt.input); got != tt.want {
+				t.Errorf("Make = %q (%d), want %q (%d)", got, len(got), tt.want, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestUnique(t *testing.T) {
+	errDB := errors.New("db down")
+
+	tests := []struct {
+		name    string
+		taken   map[string]bool
+		err     error
+		want    string
+		wantErr error
+	}{
+		{name: "free", taken: map[string]bool{}, want: "red-shoes"},
+		{name: "taken", taken: map[string]bool{"red-shoes": true}, want: "red-shoes-2"},
+		{name: "several taken", taken: map[string]bool{"red-shoes": true, "red-shoes-2": true, "red-shoes-3": true}, want: "red-shoes-4"},
+		{name: "gap", taken: map[string]bool{"red-shoes": true, "red-shoes-3": true}, want: "red-shoes-2"},
+		{name: "exhausted", taken: exhausted("red-shoes"), wantErr: errs.ErrSlugExists},
+		{name: "lookup fails", err: errDB, wantErr: errDB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taken := func(_ context.Context, slug string) (bool, error) {
+				return tt.taken[slug], tt.err
+			}
+
+			got, err := Unique(context.Background(), "red-shoes", taken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unique error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Unique = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// exhausted marks base and every suffix Unique tries as taken.
+func exhausted(base string) map[string]bool {
+	taken := map[string]bool{base: true}
+	for n := 2; n <= maxSuffix; n++ {
+		taken[fmt.Sprintf("%s-%d", base, n)] = true
+	}
+	return taken
+}
@@ -0,0 +1,151 @@
+package slug
+
+import "strings"
+
+// Thai is romanized following the main rules of the Royal Thai General
+// System (RTGS): leading vowels are read after their consonant, consonants
+// closing a syllable take their final sound, a lone consonant pair gets the
+// implicit "o" and tone marks are dropped. Syllable boundaries are guessed,
+// so the result is a readable approximation, good enough for a slug.
+
+var thaiInitials = map[rune]string{
+	'ก': "k", 'ข': "kh", 'ฃ': "kh", 'ค': "kh", 'ฅ': "kh", 'ฆ': "kh", 'ง': "ng",
+	'จ': "ch", 'ฉ': "ch", 'ช': "ch", 'ซ': "s", 'ฌ': "ch", 'ญ': "y",
+	'ฎ': "d", 'ฏ': "t", 'ฐ': "th", 'ฑ': "th", 'ฒ': "th", 'ณ': "n",
+	'ด': "d", 'ต': "t", 'ถ': "th", 'ท': "th", 'ธ': "th", 'น': "n",
+	'บ': "b", 'ป': "p", 'ผ': "ph", 'ฝ': "f", 'พ': "ph", 'ฟ': "f", 'ภ': "ph", 'ม': "m",
+	'ย': "y", 'ร': "r", 'ฤ': "rue", 'ล': "l", 'ฦ': "lue", 'ว': "w",
+	'ศ': "s", 'ษ': "s", 'ส': "s", 'ห': "h", 'ฬ': "l", 'อ': "o", 'ฮ': "h",
+}
+
+var thaiFinals = map[rune]string{
+	'ก': "k", 'ข': "k", 'ฃ': "k", 'ค': "k", 'ฅ': "k", 'ฆ': "k",
+	'จ': "t", 'ช': "t", 'ซ': "t", 'ฌ': "t", 'ฎ': "t", 'ฏ': "t", 'ฐ': "t", 'ฑ': "t",
+	'ฒ': "t", 'ด': "t", 'ต': "t", 'ถ': "t", 'ท': "t", 'ธ': "t", 'ศ': "t", 'ษ': "t", 'ส': "t",
+	'บ': "p", 'ป': "p", 'พ': "p", 'ฟ': "p", 'ภ': "p",
+	'ญ': "n", 'ณ': "n", 'น': "n", 'ร': "n", 'ล': "n", 'ฬ': "n",
+	'ง': "ng", 'ม': "m", 'ย': "y", 'ว': "o", 'อ': "", 'ห': "", 'ฮ': "",
+}
+
+var thaiVowels = map[rune]string{
+	'ะ': "a", 'ั': "a", 'า': "a", 'ำ': "am", 'ิ': "i", 'ี': "i", 'ึ': "ue", 'ื': "ue",
+	'ุ': "u", 'ู': "u", 'เ': "e", 'แ': "ae", 'โ': "o", 'ใ': "ai", 'ไ': "ai",
+}
+
+func isThai(r rune) bool { return r >= 0x0E00 && r <= 0x0E7F }
+
+func isConsonant(r rune) bool { return r >= 'ก' && r <= 'ฮ' }
+
+func isLeadingVowel(r rune) bool { return r >= 'เ' && r <= 'ไ' }
+
+func isFollowingVowel(r rune) bool { return r >= 'ะ' && r <= 'ู' }
+
+func isTone(r rune) bool { return r >= '่' && r <= '๋' }
+
+// skipTones returns the index of the first rune from i on that is not a
+// tone mark.
+func skipTones(run []rune, i int) int {
+	for i < len(run) && isTone(run[i]) {
+		i++
+	}
+	return i
+}
+
+func at(run []rune, i int) rune {
+	if i >= 0 && i < len(run) {
+		return run[i]
+	}
+	return 0
+}
+
+// romanizeThai romanizes a run of Thai characters.
+func romanizeThai(run []rune) string {
+	var sb strings.Builder
+	afterVowel := false
+
+	for i := 0; i < len(run); i++ {
+		r := run[i]
+		switch {
+		case r >= '๐' && r <= '๙':
+			sb.WriteRune('0' + r - '๐')
+			afterVowel = false
+
+		case isLeadingVowel(r) && isConsonant(at(run, i+1)):
+			// Written before its consonant, read after it
+			sb.WriteString(initial(run, i+1))
+			vowel, used := leadingVowel(run, i)
+			sb.WriteString(vowel)
+			i += 1 + used
+			afterVowel = true
+
+		case isConsonant(r):
+			next := skipTones(run, i+1)
+			switch {
+			case at(run, next) == '์':
+				// Thanthakhat silences the consonant
+				i = next
+			case isConsonant(at(run, i-1)) && isConsonant(at(run, next)) && at(run, skipTones(run, next+1)) == '์':
+				// and a consonant cluster closing the syllable, จันทร์ is chan
+			case afterVowel && !isFollowingVowel(at(run, next)):
+				// ไทย is read thai, not thaiy
+				if r != 'ย' || !strings.HasSuffix(sb.String(), "i") {
+					sb.WriteString(thaiFinals[r])
+				}
+				afterVowel = false
+			default:
+				sb.WriteString(initial(run, i))
+				afterVowel = false
+
+				// A consonant pair ending the word reads with an "o" between
+				if isConsonant(at(run, next)) && skipTones(run, next+1) >= len(run) {
+					sb.WriteString("o")
+					afterVowel = true
+				}
+			}
+
+		case thaiVowels[r] != "":
+			sb.WriteString(thaiVowels[r])
+			afterVowel = true
+		}
+	}
+
+	return sb.String()
+}
+
+// initial is the sound of the consonant at i starting a syllable, อ only
+// carries the vowel.
+func initial(run []rune, i int) string {
+	next := at(run, skipTones(run, i+1))
+	if run[i] == 'อ' && (isFollowingVowel(next) || isLeadingVowel(at(run, i-1))) {
+		return ""
+	}
+	return thaiInitials[run[i]]
+}
+
+// leadingVowel reads the vowel written around the consonant after the leading
+// vowel at i, used is how many runes after the consonant belong to it.
+func leadingVowel(run []rune, i int) (string, int) {
+	if run[i] != 'เ' {
+		return thaiVowels[run[i]], 0
+	}
+
+	j := skipTones(run, i+2)
+	switch at(run, j) {
+	case 'ี':
+		if k := skipTones(run, j+1); at(run, k) == 'ย' {
+			return "ia", k - (i + 1)
+		}
+	case 'ื':
+		if k := skipTones(run, j+1); at(run, k) == 'อ' {
+			return "uea", k - (i + 1)
+		}
+	case 'า':
+		return "ao", j - (i + 1)
+	case 'อ', 'ิ':
+		return "oe", j - (i + 1)
+	case '็':
+		return "e", j - (i + 1)
+	}
+
+	return "e", 0
+}
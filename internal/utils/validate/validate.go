@@ -1,8 +1,20 @@
 package validate
 
-import "github.com/go-playground/validator/v10"
+import (
+	"reflect"
+
+	"github.com/codepnw/core-ecommerce-system/internal/utils/money"
+	"github.com/go-playground/validator/v10"
+)
 
 func Struct(input any) error {
 	v := validator.New()
+	// money.Money is validated by its minor unit amount, so `gt=0` works as expected.
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		if m, ok := field.Interface().(money.Money); ok {
+			return m.Amount
+		}
+		return nil
+	}, money.Money{})
 	return v.Struct(input)
 }